@@ -0,0 +1,95 @@
+// +build !confonly
+
+package dispatcher
+
+import (
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/ratelimit"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/policy"
+)
+
+const bandwidthIdleTimeout = time.Minute
+
+// userBandwidth holds the buckets shared by all connections of a user.
+type userBandwidth struct {
+	level    uint32
+	uplink   *ratelimit.Bucket
+	downlink *ratelimit.Bucket
+}
+
+func (u *userBandwidth) update(b policy.Bandwidth) {
+	u.uplink.SetLimit(b.Uplink, b.UplinkBurst)
+	u.downlink.SetLimit(b.Downlink, b.DownlinkBurst)
+}
+
+func (u *userBandwidth) idle() bool {
+	return u.uplink.Idle() > bandwidthIdleTimeout && u.downlink.Idle() > bandwidthIdleTimeout
+}
+
+// bandwidthLimiter keeps buckets of users, and keeps their limits in sync with policy, so that changes of policy apply to existing connections.
+type bandwidthLimiter struct {
+	access  sync.Mutex
+	policy  policy.Manager
+	users   map[string]*userBandwidth
+	refresh *task.Periodic
+}
+
+func newBandwidthLimiter(pm policy.Manager) *bandwidthLimiter {
+	l := &bandwidthLimiter{
+		policy: pm,
+		users:  make(map[string]*userBandwidth),
+	}
+	l.refresh = &task.Periodic{
+		Interval: time.Second * 10,
+		Execute:  l.update,
+	}
+	return l
+}
+
+// get returns the buckets of a user, creating them if necessary.
+func (l *bandwidthLimiter) get(level uint32, email string, b policy.Bandwidth) *userBandwidth {
+	l.access.Lock()
+	u, found := l.users[email]
+	if !found {
+		u = &userBandwidth{
+			level:    level,
+			uplink:   ratelimit.New(b.Uplink, b.UplinkBurst),
+			downlink: ratelimit.New(b.Downlink, b.DownlinkBurst),
+		}
+		l.users[email] = u
+	} else {
+		u.level = level
+		u.update(b)
+	}
+	l.access.Unlock()
+
+	if !found {
+		l.refresh.Start() // nolint: errcheck
+	}
+	return u
+}
+
+func (l *bandwidthLimiter) update() error {
+	l.access.Lock()
+	defer l.access.Unlock()
+
+	for email, u := range l.users {
+		if u.idle() {
+			delete(l.users, email)
+			continue
+		}
+		u.update(l.policy.ForUser(u.level, email).Bandwidth)
+	}
+
+	if len(l.users) == 0 {
+		return newError("no more users. stopping...")
+	}
+	return nil
+}
+
+func (l *bandwidthLimiter) Close() error {
+	return l.refresh.Close()
+}
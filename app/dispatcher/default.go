@@ -16,6 +16,7 @@ import (
 	"v2ray.com/core/common/log"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/ratelimit"
 	"v2ray.com/core/common/session"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/features/policy"
@@ -91,10 +92,15 @@ func (r *cachedReader) Interrupt() {
 
 // DefaultDispatcher is a default implementation of Dispatcher.
 type DefaultDispatcher struct {
-	ohm    outbound.Manager
-	router routing.Router
-	policy policy.Manager
-	stats  stats.Manager
+	ohm       outbound.Manager
+	router    routing.Router
+	policy    policy.Manager
+	stats     stats.Manager
+	bandwidth *bandwidthLimiter
+
+	access   sync.Mutex
+	sessions int
+	idle     chan struct{}
 }
 
 func init() {
@@ -115,6 +121,7 @@ func (d *DefaultDispatcher) Init(config *Config, om outbound.Manager, router rou
 	d.router = router
 	d.policy = pm
 	d.stats = sm
+	d.bandwidth = newBandwidthLimiter(pm)
 	return nil
 }
 
@@ -129,11 +136,82 @@ func (*DefaultDispatcher) Start() error {
 }
 
 // Close implements common.Closable.
-func (*DefaultDispatcher) Close() error { return nil }
+func (d *DefaultDispatcher) Close() error {
+	if d.bandwidth != nil {
+		return d.bandwidth.Close()
+	}
+	return nil
+}
+
+// Drain implements common.Drainable. It waits for all ongoing sessions to finish.
+func (d *DefaultDispatcher) Drain(ctx context.Context) error {
+	d.access.Lock()
+	if d.sessions == 0 {
+		d.access.Unlock()
+		return nil
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle := d.idle
+	d.access.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return newError("failed to wait for ", d.activeSessions(), " sessions").Base(ctx.Err())
+	}
+}
+
+func (d *DefaultDispatcher) activeSessions() int {
+	d.access.Lock()
+	defer d.access.Unlock()
+	return d.sessions
+}
+
+func (d *DefaultDispatcher) sessionStarted() {
+	d.access.Lock()
+	d.sessions++
+	d.access.Unlock()
+}
+
+func (d *DefaultDispatcher) sessionEnded() {
+	d.access.Lock()
+	d.sessions--
+	if d.sessions == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+	d.access.Unlock()
+}
+
+func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *transport.Link, *sessionStats, error) {
+	sessionInbound := session.InboundFromContext(ctx)
+	var user *protocol.MemoryUser
+	if sessionInbound != nil {
+		user = sessionInbound.User
+	}
+
+	var p policy.Session
+	var quota *policy.QuotaUsage
+	if user != nil && len(user.Email) > 0 {
+		p = d.policy.ForUser(user.Level, user.Email)
+		if p.Quota.Enabled() {
+			// Quota is not enforced if the policy manager doesn't track usage.
+			if usage := policy.GetQuotaUsage(d.policy, user.Email); usage != nil {
+				usage.Refresh(p.Quota, time.Now())
+				if usage.Remaining(p.Quota) <= 0 {
+					return nil, nil, nil, newError("user ", user.Email, " has exceeded traffic quota")
+				}
+				quota = usage
+			}
+		}
+	}
 
-func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *transport.Link) {
 	opt := pipe.OptionsFromContext(ctx)
-	uplinkReader, uplinkWriter := pipe.New(opt...)
+	// Inbounds stop reading under memory pressure, when they are blocked on writing to the uplink.
+	uplinkReader, uplinkWriter := pipe.New(append(opt, pipe.PauseOnPressure())...)
 	downlinkReader, downlinkWriter := pipe.New(opt...)
 
 	inboundLink := &transport.Link{
@@ -146,14 +224,9 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 		Writer: downlinkWriter,
 	}
 
-	sessionInbound := session.InboundFromContext(ctx)
-	var user *protocol.MemoryUser
-	if sessionInbound != nil {
-		user = sessionInbound.User
-	}
+	var sessionStat *sessionStats
 
 	if user != nil && len(user.Email) > 0 {
-		p := d.policy.ForLevel(user.Level)
 		if p.Stats.UserUplink {
 			name := "user>>>" + user.Email + ">>>traffic>>>uplink"
 			if c, _ := stats.GetOrRegisterCounter(d.stats, name); c != nil {
@@ -172,9 +245,59 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 				}
 			}
 		}
+		if p.Stats.UserRate {
+			name := "user>>>" + user.Email + ">>>rate>>>uplink"
+			if r, _ := stats.GetOrRegisterRate(d.stats, name); r != nil {
+				inboundLink.Writer = &RateStatWriter{
+					Rate:   r,
+					Writer: inboundLink.Writer,
+				}
+			}
+			name = "user>>>" + user.Email + ">>>rate>>>downlink"
+			if r, _ := stats.GetOrRegisterRate(d.stats, name); r != nil {
+				outboundLink.Writer = &RateStatWriter{
+					Rate:   r,
+					Writer: outboundLink.Writer,
+				}
+			}
+		}
+		if p.Stats.UserConnection {
+			prefix := "user>>>" + user.Email + ">>>connection>>>"
+			active, _ := stats.GetOrRegisterGauge(d.stats, prefix+"active")
+			duration, _ := stats.GetOrRegisterHistogram(d.stats, prefix+"duration", durationBounds)
+			traffic, _ := stats.GetOrRegisterHistogram(d.stats, prefix+"traffic", trafficBounds)
+			sessionStat = newSessionStats(active, duration, traffic)
+			inboundLink.Writer = &sessionStatWriter{
+				session: sessionStat,
+				writer:  inboundLink.Writer,
+			}
+			outboundLink.Writer = &sessionStatWriter{
+				session: sessionStat,
+				writer:  outboundLink.Writer,
+			}
+		}
+		if b := p.Bandwidth; b.Uplink > 0 || b.Downlink > 0 {
+			u := d.bandwidth.get(user.Level, user.Email, b)
+			inboundLink.Writer = ratelimit.NewWriter(u.uplink, inboundLink.Writer)
+			outboundLink.Writer = ratelimit.NewWriter(u.downlink, outboundLink.Writer)
+		}
+		if quota != nil {
+			inboundLink.Writer = &quotaWriter{
+				email:  user.Email,
+				quota:  p.Quota,
+				usage:  quota,
+				writer: inboundLink.Writer,
+			}
+			outboundLink.Writer = &quotaWriter{
+				email:  user.Email,
+				quota:  p.Quota,
+				usage:  quota,
+				writer: outboundLink.Writer,
+			}
+		}
 	}
 
-	return inboundLink, outboundLink
+	return inboundLink, outboundLink, sessionStat, nil
 }
 
 func shouldOverride(result SniffResult, domainOverride []string) bool {
@@ -196,17 +319,30 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 	}
 	ctx = session.ContextWithOutbound(ctx, ob)
 
-	inbound, outbound := d.getLink(ctx)
+	inbound, outbound, sessionStat, err := d.getLink(ctx)
+	if err != nil {
+		return nil, err
+	}
 	content := session.ContentFromContext(ctx)
 	if content == nil {
 		content = new(session.Content)
 		ctx = session.ContextWithContent(ctx, content)
 	}
 	sniffingRequest := content.SniffingRequest
+	d.sessionStarted()
+	// Metrics of the session are recorded when the session ends, even if a writer of its link is never closed.
+	ended := func() {
+		sessionStat.end()
+		d.sessionEnded()
+	}
 	if destination.Network != net.Network_TCP || !sniffingRequest.Enabled {
-		go d.routedDispatch(ctx, outbound, destination)
+		go func() {
+			defer ended()
+			d.routedDispatch(ctx, outbound, destination)
+		}()
 	} else {
 		go func() {
+			defer ended()
 			cReader := &cachedReader{
 				reader: outbound.Reader.(*pipe.Reader),
 			}
@@ -271,6 +407,11 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 			if h := d.ohm.GetHandler(tag); h != nil {
 				newError("taking detour [", tag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
 				handler = h
+				if r, ok := route.(routing.SockoptRoute); ok {
+					if outbound := session.OutboundFromContext(ctx); outbound != nil {
+						outbound.Sockopt = r.GetSockopt()
+					}
+				}
 			} else {
 				newError("non existing tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
 			}
@@ -0,0 +1,187 @@
+package dispatcher_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/stats"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/features/outbound"
+	feature_stats "v2ray.com/core/features/stats"
+	"v2ray.com/core/transport"
+)
+
+type testOutboundManager struct {
+	outbound.Manager
+	handler outbound.Handler
+}
+
+func (m *testOutboundManager) GetDefaultHandler() outbound.Handler {
+	return m.handler
+}
+
+type testOutboundHandler struct {
+	outbound.Handler
+	dispatch func(context.Context, *transport.Link)
+}
+
+func (*testOutboundHandler) Tag() string {
+	return "test"
+}
+
+func (h *testOutboundHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	h.dispatch(ctx, link)
+}
+
+const testEmail = "love@v2fly.org"
+
+func newStatsManager() *stats.Manager {
+	sm, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	return sm
+}
+
+func newTestDispatcher(config *policy.Config, sm feature_stats.Manager, dispatch func(context.Context, *transport.Link)) *DefaultDispatcher {
+	pm, err := policy.New(context.Background(), config)
+	common.Must(err)
+
+	d := new(DefaultDispatcher)
+	om := &testOutboundManager{handler: &testOutboundHandler{dispatch: dispatch}}
+	common.Must(d.Init(&Config{}, om, nil, pm, sm))
+	return d
+}
+
+func discardLink(ctx context.Context, link *transport.Link) {
+	buf.Copy(link.Reader, buf.Discard) // nolint: errcheck
+}
+
+var testDestination = net.TCPDestination(net.DomainAddress("v2fly.org"), 80)
+
+func userContext() context.Context {
+	return session.ContextWithInbound(context.Background(), &session.Inbound{
+		User: &protocol.MemoryUser{Email: testEmail},
+	})
+}
+
+func drain(d *DefaultDispatcher) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	common.Must(d.Drain(ctx))
+}
+
+func TestDispatcherSessionStatsWithoutClose(t *testing.T) {
+	config := &policy.Config{
+		User: map[string]*policy.Policy{
+			testEmail: {Stats: &policy.Policy_Stats{UserConnection: true}},
+		},
+	}
+	// The outbound returns without closing or interrupting its link.
+	sm := newStatsManager()
+	d := newTestDispatcher(config, sm, func(ctx context.Context, link *transport.Link) {
+		mb, _ := link.Reader.ReadMultiBuffer()
+		buf.ReleaseMulti(mb)
+	})
+
+	link, err := d.Dispatch(userContext(), testDestination)
+	common.Must(err)
+	common.Must(link.Writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("test"))))
+	drain(d)
+
+	prefix := "user>>>" + testEmail + ">>>connection>>>"
+	if v := sm.GetGauge(prefix + "active").Value(); v != 0 {
+		t.Error("active connections: ", v)
+	}
+	if c := sm.GetHistogram(prefix + "duration").Snapshot().Count; c != 1 {
+		t.Error("observed durations: ", c)
+	}
+}
+
+func writeBytes(w buf.Writer, n int) error {
+	return w.WriteMultiBuffer(buf.MergeBytes(nil, make([]byte, n)))
+}
+
+func TestDispatcherQuota(t *testing.T) {
+	config := &policy.Config{
+		User: map[string]*policy.Policy{
+			testEmail: {Quota: &policy.Policy_Quota{Bytes: 10}},
+		},
+	}
+	sm := newStatsManager()
+	d := newTestDispatcher(config, sm, discardLink)
+
+	link, err := d.Dispatch(userContext(), testDestination)
+	common.Must(err)
+	common.Must(writeBytes(link.Writer, 8))
+	common.Must(writeBytes(link.Writer, 8))
+	if err := writeBytes(link.Writer, 1); err == nil {
+		t.Error("expect error on writing after quota exceeded")
+	}
+	common.Interrupt(link.Writer)
+	drain(d)
+
+	if _, err := d.Dispatch(userContext(), testDestination); err == nil {
+		t.Error("expect new connection rejected after quota exceeded")
+	}
+
+	// Resetting stats counters doesn't affect the quota.
+	sm.VisitCounters(func(name string, c feature_stats.Counter) bool {
+		c.Set(0)
+		return true
+	})
+	if _, err := d.Dispatch(userContext(), testDestination); err == nil {
+		t.Error("expect quota kept after stats reset")
+	}
+}
+
+func TestDispatcherQuotaWithoutStats(t *testing.T) {
+	config := &policy.Config{
+		User: map[string]*policy.Policy{
+			testEmail: {Quota: &policy.Policy_Quota{Bytes: 10}},
+		},
+	}
+	d := newTestDispatcher(config, feature_stats.NoopManager{}, discardLink)
+
+	link, err := d.Dispatch(userContext(), testDestination)
+	common.Must(err)
+	common.Must(writeBytes(link.Writer, 10))
+	if err := writeBytes(link.Writer, 1); err == nil {
+		t.Error("expect error on writing after quota exceeded")
+	}
+	common.Interrupt(link.Writer)
+	drain(d)
+}
+
+func TestDispatcherBandwidth(t *testing.T) {
+	config := &policy.Config{
+		User: map[string]*policy.Policy{
+			testEmail: {Bandwidth: &policy.Policy_Bandwidth{Uplink: 100 * 1024, UplinkBurst: 10 * 1024}},
+		},
+	}
+	d := newTestDispatcher(config, newStatsManager(), discardLink)
+
+	link, err := d.Dispatch(userContext(), testDestination)
+	common.Must(err)
+
+	start := time.Now()
+	common.Must(writeBytes(link.Writer, 10*1024))
+	if elapsed := time.Since(start); elapsed > time.Millisecond*100 {
+		t.Error("write within burst took ", elapsed)
+	}
+
+	start = time.Now()
+	common.Must(writeBytes(link.Writer, 20*1024))
+	if elapsed := time.Since(start); elapsed < time.Millisecond*150 || elapsed > time.Second*2 {
+		t.Error("write over burst took ", elapsed, ", want about 200ms")
+	}
+
+	common.Must(common.Close(link.Writer))
+	drain(d)
+	common.Must(d.Close())
+}
@@ -0,0 +1,37 @@
+// +build !confonly
+
+package dispatcher
+
+import (
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/features/policy"
+)
+
+// quotaWriter counts traffic against the quota of a user, and fails once the quota is exceeded.
+type quotaWriter struct {
+	email  string
+	quota  policy.Quota
+	usage  *policy.QuotaUsage
+	writer buf.Writer
+}
+
+func (w *quotaWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.usage.Refresh(w.quota, time.Now())
+	if w.usage.Remaining(w.quota) <= 0 {
+		buf.ReleaseMulti(mb)
+		return newError("user ", w.email, " has exceeded traffic quota")
+	}
+	w.usage.Add(int64(mb.Len()))
+	return w.writer.WriteMultiBuffer(mb)
+}
+
+func (w *quotaWriter) Close() error {
+	return common.Close(w.writer)
+}
+
+func (w *quotaWriter) Interrupt() {
+	common.Interrupt(w.writer)
+}
@@ -3,11 +3,22 @@
 package dispatcher
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
 	"v2ray.com/core/features/stats"
 )
 
+var (
+	// durationBounds are bucket bounds of connection duration histograms, in milliseconds.
+	durationBounds = []int64{100, 500, 1000, 5000, 10000, 30000, 60000, 300000, 1800000, 3600000}
+	// trafficBounds are bucket bounds of connection traffic histograms, in bytes.
+	trafficBounds = []int64{1 << 10, 16 << 10, 128 << 10, 1 << 20, 16 << 20, 128 << 20, 1 << 30}
+)
+
 type SizeStatWriter struct {
 	Counter stats.Counter
 	Writer  buf.Writer
@@ -25,3 +36,99 @@ func (w *SizeStatWriter) Close() error {
 func (w *SizeStatWriter) Interrupt() {
 	common.Interrupt(w.Writer)
 }
+
+type RateStatWriter struct {
+	Rate   stats.Rate
+	Writer buf.Writer
+}
+
+func (w *RateStatWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.Rate.Add(int64(mb.Len()))
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *RateStatWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+func (w *RateStatWriter) Interrupt() {
+	common.Interrupt(w.Writer)
+}
+
+// sessionStats tracks a session from both directions of its link, and records its metrics when both are finished,
+// or when the dispatcher ends the session.
+type sessionStats struct {
+	once     sync.Once
+	start    time.Time
+	pending  int32
+	traffic  int64
+	active   stats.Gauge
+	duration stats.Histogram
+	bytes    stats.Histogram
+}
+
+func newSessionStats(active stats.Gauge, duration stats.Histogram, bytes stats.Histogram) *sessionStats {
+	if active != nil {
+		active.Add(1)
+	}
+	return &sessionStats{
+		start:    time.Now(),
+		pending:  2,
+		active:   active,
+		duration: duration,
+		bytes:    bytes,
+	}
+}
+
+func (s *sessionStats) finish() {
+	if atomic.AddInt32(&s.pending, -1) == 0 {
+		s.end()
+	}
+}
+
+// end records the metrics of the session, if not yet. It is safe to call on nil.
+func (s *sessionStats) end() {
+	if s != nil {
+		s.once.Do(s.record)
+	}
+}
+
+func (s *sessionStats) record() {
+	if s.active != nil {
+		s.active.Add(-1)
+	}
+	if s.duration != nil {
+		s.duration.Observe(int64(time.Since(s.start) / time.Millisecond))
+	}
+	if s.bytes != nil {
+		s.bytes.Observe(atomic.LoadInt64(&s.traffic))
+	}
+}
+
+// sessionStatWriter is one direction of a session tracked by sessionStats.
+type sessionStatWriter struct {
+	session *sessionStats
+	writer  buf.Writer
+	done    int32
+}
+
+func (w *sessionStatWriter) finish() {
+	if atomic.CompareAndSwapInt32(&w.done, 0, 1) {
+		w.session.finish()
+	}
+}
+
+func (w *sessionStatWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	atomic.AddInt64(&w.session.traffic, int64(mb.Len()))
+	return w.writer.WriteMultiBuffer(mb)
+}
+
+func (w *sessionStatWriter) Close() error {
+	w.finish()
+	return common.Close(w.writer)
+}
+
+func (w *sessionStatWriter) Interrupt() {
+	w.finish()
+	common.Interrupt(w.writer)
+}
@@ -128,6 +128,11 @@ func baseDOHNameServer(url *url.URL, prefix string, clientIP net.IP) *DoHNameSer
 	return s
 }
 
+// Close implements common.Closable.
+func (s *DoHNameServer) Close() error {
+	return s.cleanup.Close()
+}
+
 // Name returns client name
 func (s *DoHNameServer) Name() string {
 	return s.name
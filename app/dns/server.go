@@ -7,10 +7,10 @@ package dns
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"v2ray.com/core"
@@ -37,6 +37,11 @@ type Server struct {
 	domainMatcher strmatcher.IndexMatcher
 	matcherInfos  []DomainMatcherInfo // matcherIdx -> DomainMatcherInfo
 	tag           string
+	dns64         *net.NAT64Prefix
+
+	ctx context.Context
+	// reloaded holds the Server built from the latest reloaded config.
+	reloaded atomic.Value
 }
 
 // DomainMatcherInfo contains information attached to index returned by Server.domainMatcher
@@ -77,6 +82,7 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 	server := &Server{
 		clients: make([]Client, 0, len(config.NameServers)+len(config.NameServer)),
 		tag:     config.Tag,
+		ctx:     ctx,
 	}
 	if server.tag == "" {
 		server.tag = generateRandomTag()
@@ -87,6 +93,12 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		}
 		server.clientIP = net.IP(config.ClientIp)
 	}
+	if config.Dns64 != nil {
+		if !config.Dns64.IsValid() {
+			return nil, newError("invalid DNS64 prefix")
+		}
+		server.dns64 = config.Dns64
+	}
 
 	hosts, err := NewStaticHosts(config.StaticHosts, config.Hosts)
 	if err != nil {
@@ -94,7 +106,7 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 	}
 	server.hosts = hosts
 
-	addNameServer := func(ns *NameServer) int {
+	addNameServer := func(ns *NameServer) (int, error) {
 		endpoint := ns.Address
 		address := endpoint.Address.AsAddress()
 		if address.Family().IsDomain() && address.Domain() == "localhost" {
@@ -120,26 +132,29 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 			// DOH Local mode
 			u, err := url.Parse(address.Domain())
 			if err != nil {
-				log.Fatalln(newError("DNS config error").Base(err))
+				return 0, newError("DNS config error").Base(err)
 			}
 			server.clients = append(server.clients, NewDoHLocalNameServer(u, server.clientIP))
 		} else if address.Family().IsDomain() && strings.HasPrefix(address.Domain(), "https://") {
 			// DOH Remote mode
 			u, err := url.Parse(address.Domain())
 			if err != nil {
-				log.Fatalln(newError("DNS config error").Base(err))
+				return 0, newError("DNS config error").Base(err)
 			}
 			idx := len(server.clients)
 			server.clients = append(server.clients, nil)
 
 			// need the core dispatcher, register DOHClient at callback
-			common.Must(core.RequireFeatures(ctx, func(d routing.Dispatcher) {
+			if err := core.RequireFeatures(ctx, func(d routing.Dispatcher) error {
 				c, err := NewDoHNameServer(u, d, server.clientIP)
 				if err != nil {
-					log.Fatalln(newError("DNS config error").Base(err))
+					return newError("DNS config error").Base(err)
 				}
 				server.clients[idx] = c
-			}))
+				return nil
+			}); err != nil {
+				return 0, err
+			}
 		} else {
 			// UDP classic DNS mode
 			dest := endpoint.AsDestination()
@@ -156,13 +171,15 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 			}
 		}
 		server.ipIndexMap = append(server.ipIndexMap, nil)
-		return len(server.clients) - 1
+		return len(server.clients) - 1, nil
 	}
 
 	if len(config.NameServers) > 0 {
 		features.PrintDeprecatedFeatureWarning("simple DNS server")
 		for _, destPB := range config.NameServers {
-			addNameServer(&NameServer{Address: destPB})
+			if _, err := addNameServer(&NameServer{Address: destPB}); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -170,7 +187,10 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		clientIndices := []int{}
 		domainRuleCount := 0
 		for _, ns := range config.NameServer {
-			idx := addNameServer(ns)
+			idx, err := addNameServer(ns)
+			if err != nil {
+				return nil, err
+			}
 			clientIndices = append(clientIndices, idx)
 			domainRuleCount += len(ns.PrioritizedDomain)
 		}
@@ -255,12 +275,63 @@ func (s *Server) Start() error {
 
 // Close implements common.Closable.
 func (s *Server) Close() error {
-	return nil
+	var errs []error
+	if reloaded, ok := s.reloaded.Load().(*Server); ok {
+		errs = append(errs, reloaded.closeClients())
+	}
+	errs = append(errs, s.closeClients())
+	return errors.Combine(errs...)
+}
+
+// closeClients stops the name servers.
+func (s *Server) closeClients() error {
+	var errs []error
+	for _, client := range s.clients {
+		errs = append(errs, common.Close(client))
+	}
+	return errors.Combine(errs...)
+}
+
+// current returns the Server that takes effect.
+func (s *Server) current() *Server {
+	if reloaded, ok := s.reloaded.Load().(*Server); ok {
+		return reloaded
+	}
+	return s
+}
+
+// PrepareReload implements features.Reloadable.
+func (s *Server) PrepareReload(config interface{}) (func(), func(), error) {
+	c, ok := config.(*Config)
+	if !ok {
+		return nil, nil, newError("not a DNS config")
+	}
+	reloaded, err := New(s.ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Tag == "" {
+		// Keep the generated tag, so that queries sent before reloading are still recognized as its own.
+		reloaded.tag = s.tag
+	}
+	return func() {
+		old := s.current()
+		if err := reloaded.Start(); err != nil {
+			newError("failed to start reloaded DNS server").Base(err).AtWarning().WriteToLog()
+		}
+		s.reloaded.Store(reloaded)
+		// The name servers replaced are stopped, while the Server itself keeps serving as the feature.
+		if err := old.closeClients(); err != nil {
+			newError("failed to close replaced DNS server").Base(err).AtWarning().WriteToLog()
+		}
+	}, func() {
+		reloaded.closeClients() // nolint: errcheck
+	}, nil
 }
 
 func (s *Server) IsOwnLink(ctx context.Context) bool {
 	inbound := session.InboundFromContext(ctx)
-	return inbound != nil && inbound.Tag == s.tag
+	return inbound != nil && inbound.Tag == s.current().tag
 }
 
 // Match check dns ip match geoip
@@ -311,15 +382,25 @@ func (s *Server) queryIPTimeout(idx int, client Client, domain string, option IP
 
 // LookupIP implements dns.Client.
 func (s *Server) LookupIP(domain string) ([]net.IP, error) {
-	return s.lookupIPInternal(domain, IPOption{
+	server := s.current()
+	ips, err := server.lookupIPInternal(domain, IPOption{
 		IPv4Enable: true,
 		IPv6Enable: true,
 	})
+	if server.dns64 == nil || err != nil {
+		return ips, err
+	}
+	for _, ip := range ips {
+		if ip.To4() == nil {
+			return ips, nil
+		}
+	}
+	return append(ips, server.synthesizeIPv6(domain, ips)...), nil
 }
 
 // LookupIPv4 implements dns.IPv4Lookup.
 func (s *Server) LookupIPv4(domain string) ([]net.IP, error) {
-	return s.lookupIPInternal(domain, IPOption{
+	return s.current().lookupIPInternal(domain, IPOption{
 		IPv4Enable: true,
 		IPv6Enable: false,
 	})
@@ -327,10 +408,40 @@ func (s *Server) LookupIPv4(domain string) ([]net.IP, error) {
 
 // LookupIPv6 implements dns.IPv6Lookup.
 func (s *Server) LookupIPv6(domain string) ([]net.IP, error) {
-	return s.lookupIPInternal(domain, IPOption{
+	server := s.current()
+	ips, err := server.lookupIPInternal(domain, IPOption{
 		IPv4Enable: false,
 		IPv6Enable: true,
 	})
+	if server.dns64 == nil || len(ips) > 0 {
+		return ips, err
+	}
+
+	ipv4, err4 := server.lookupIPInternal(domain, IPOption{
+		IPv4Enable: true,
+		IPv6Enable: false,
+	})
+	if synthesized := server.synthesizeIPv6(domain, ipv4); len(synthesized) > 0 {
+		return synthesized, nil
+	}
+	if err == nil {
+		err = err4
+	}
+	return nil, err
+}
+
+// synthesizeIPv6 returns the IPv6 addresses synthesized from the IPv4 addresses of the domain for DNS64.
+func (s *Server) synthesizeIPv6(domain string, ips []net.IP) []net.IP {
+	var synthesized []net.IP
+	for _, ip := range ips {
+		if ip6 := s.dns64.Synthesize(ip); ip6 != nil {
+			synthesized = append(synthesized, ip6)
+		}
+	}
+	if len(synthesized) > 0 {
+		newError("synthesized ", len(synthesized), " IPv6 addresses for domain ", domain).AtDebug().WriteToLog()
+	}
+	return synthesized
 }
 
 func (s *Server) lookupStatic(domain string, option IPOption, depth int32) []net.Address {
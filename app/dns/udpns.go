@@ -59,6 +59,11 @@ func NewClassicNameServer(address net.Destination, dispatcher routing.Dispatcher
 	return s
 }
 
+// Close implements common.Closable.
+func (s *ClassicNameServer) Close() error {
+	return s.cleanup.Close()
+}
+
 func (s *ClassicNameServer) Name() string {
 	return s.name
 }
@@ -114,6 +114,43 @@ func (g *Instance) Handle(msg log.Message) {
 	}
 }
 
+// PrepareReload implements features.Reloadable.
+func (g *Instance) PrepareReload(config interface{}) (func(), func(), error) {
+	c, ok := config.(*Config)
+	if !ok {
+		return nil, nil, newError("not a log config")
+	}
+	accessLogger, err := createHandler(c.AccessLogType, HandlerCreatorOptions{
+		Path: c.AccessLogPath,
+	})
+	if err != nil {
+		return nil, nil, newError("failed to create access logger").Base(err)
+	}
+	errorLogger, err := createHandler(c.ErrorLogType, HandlerCreatorOptions{
+		Path: c.ErrorLogPath,
+	})
+	if err != nil {
+		common.Close(accessLogger) // nolint: errcheck
+		return nil, nil, newError("failed to create error logger").Base(err)
+	}
+
+	return func() {
+		g.Lock()
+		defer g.Unlock()
+
+		common.Close(g.accessLogger) // nolint: errcheck
+		common.Close(g.errorLogger)  // nolint: errcheck
+
+		g.config = c
+		g.accessLogger = accessLogger
+		g.errorLogger = errorLogger
+		g.active = true
+	}, func() {
+		common.Close(accessLogger) // nolint: errcheck
+		common.Close(errorLogger)  // nolint: errcheck
+	}, nil
+}
+
 // Close implements common.Closable.Close().
 func (g *Instance) Close() error {
 	newError("Logger closing").AtDebug().WriteToLog()
@@ -50,3 +50,46 @@ func TestCustomLogHandler(t *testing.T) {
 
 	common.Must(logger.Close())
 }
+
+type closableHandler struct {
+	closed int
+}
+
+func (*closableHandler) Handle(clog.Message) {}
+
+func (h *closableHandler) Close() error {
+	h.closed++
+	return nil
+}
+
+func TestReloadDiscard(t *testing.T) {
+	var handlers []*closableHandler
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		h := new(closableHandler)
+		handlers = append(handlers, h)
+		return h, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		ErrorLogType:  log.LogType_None,
+		AccessLogType: log.LogType_None,
+	})
+	common.Must(err)
+
+	_, discard, err := logger.PrepareReload(&log.Config{
+		ErrorLogType:  log.LogType_Console,
+		AccessLogType: log.LogType_Console,
+	})
+	common.Must(err)
+	if len(handlers) != 2 {
+		t.Fatal("expect 2 handlers created, but got ", len(handlers))
+	}
+
+	// Handlers created for a reload that is not applied are closed.
+	discard()
+	for _, h := range handlers {
+		if h.closed != 1 {
+			t.Error("handler closed ", h.closed, " times")
+		}
+	}
+}
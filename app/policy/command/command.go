@@ -0,0 +1,208 @@
+// +build !confonly
+
+package command
+
+//go:generate go run v2ray.com/core/common/errors/errorgen
+
+import (
+	"context"
+	"time"
+
+	grpc "google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/common"
+	feature_policy "v2ray.com/core/features/policy"
+)
+
+// quotaServer is an implementation of QuotaService.
+type quotaServer struct {
+	policy feature_policy.Manager
+}
+
+func NewQuotaServer(pm feature_policy.Manager) QuotaServiceServer {
+	return &quotaServer{
+		policy: pm,
+	}
+}
+
+func (s *quotaServer) getUsage(email string, level uint32) (feature_policy.Quota, *feature_policy.QuotaUsage, error) {
+	if len(email) == 0 {
+		return feature_policy.Quota{}, nil, newError("empty email")
+	}
+	quota := s.policy.ForUser(level, email).Quota
+	usage := feature_policy.GetQuotaUsage(s.policy, email)
+	if usage == nil {
+		return quota, nil, newError("policy manager doesn't track quota usage")
+	}
+	usage.Refresh(quota, time.Now())
+	return quota, usage, nil
+}
+
+func toQuota(email string, quota feature_policy.Quota, usage *feature_policy.QuotaUsage) *Quota {
+	return &Quota{
+		Email:       email,
+		Limit:       quota.Bytes,
+		Used:        usage.Used(),
+		Remaining:   usage.Remaining(quota),
+		PeriodStart: usage.PeriodStart(),
+	}
+}
+
+func (s *quotaServer) GetQuota(ctx context.Context, request *GetQuotaRequest) (*GetQuotaResponse, error) {
+	quota, usage, err := s.getUsage(request.Email, request.Level)
+	if err != nil {
+		return nil, err
+	}
+	return &GetQuotaResponse{
+		Quota: toQuota(request.Email, quota, usage),
+	}, nil
+}
+
+func (s *quotaServer) ResetQuota(ctx context.Context, request *ResetQuotaRequest) (*ResetQuotaResponse, error) {
+	quota, usage, err := s.getUsage(request.Email, request.Level)
+	if err != nil {
+		return nil, err
+	}
+	usage.Reset(time.Now())
+	return &ResetQuotaResponse{
+		Quota: toQuota(request.Email, quota, usage),
+	}, nil
+}
+
+func (s *quotaServer) mustEmbedUnimplementedQuotaServiceServer() {}
+
+// policyServer is an implementation of PolicyService.
+type policyServer struct {
+	manager *policy.Instance
+}
+
+func NewPolicyServer(manager *policy.Instance) PolicyServiceServer {
+	return &policyServer{
+		manager: manager,
+	}
+}
+
+func (s *policyServer) GetPolicy(ctx context.Context, request *GetPolicyRequest) (*GetPolicyResponse, error) {
+	p, ok := s.manager.GetLevel(request.Level)
+	if !ok {
+		return nil, newError("policy of level ", request.Level, " not found")
+	}
+	return &GetPolicyResponse{
+		Policy: p,
+	}, nil
+}
+
+func (s *policyServer) SetPolicy(ctx context.Context, request *SetPolicyRequest) (*SetPolicyResponse, error) {
+	if request.Policy == nil {
+		return nil, newError("empty policy")
+	}
+	s.manager.SetLevel(request.Level, request.Policy)
+	newError("policy of level ", request.Level, " updated").AtInfo().WriteToLog()
+	return &SetPolicyResponse{}, nil
+}
+
+func (s *policyServer) DeletePolicy(ctx context.Context, request *DeletePolicyRequest) (*DeletePolicyResponse, error) {
+	if _, ok := s.manager.GetLevel(request.Level); !ok {
+		return nil, newError("policy of level ", request.Level, " not found")
+	}
+	s.manager.DeleteLevel(request.Level)
+	newError("policy of level ", request.Level, " deleted").AtInfo().WriteToLog()
+	return &DeletePolicyResponse{}, nil
+}
+
+func (s *policyServer) GetUserPolicy(ctx context.Context, request *GetUserPolicyRequest) (*GetUserPolicyResponse, error) {
+	p, ok := s.manager.GetUser(request.Email)
+	if !ok {
+		return nil, newError("policy of user ", request.Email, " not found")
+	}
+	return &GetUserPolicyResponse{
+		Policy: p,
+	}, nil
+}
+
+func (s *policyServer) SetUserPolicy(ctx context.Context, request *SetUserPolicyRequest) (*SetUserPolicyResponse, error) {
+	if len(request.Email) == 0 {
+		return nil, newError("empty email")
+	}
+	if request.Policy == nil {
+		return nil, newError("empty policy")
+	}
+	s.manager.SetUser(request.Email, request.Policy)
+	newError("policy of user ", request.Email, " updated").AtInfo().WriteToLog()
+	return &SetUserPolicyResponse{}, nil
+}
+
+func (s *policyServer) DeleteUserPolicy(ctx context.Context, request *DeleteUserPolicyRequest) (*DeleteUserPolicyResponse, error) {
+	if _, ok := s.manager.GetUser(request.Email); !ok {
+		return nil, newError("policy of user ", request.Email, " not found")
+	}
+	s.manager.DeleteUser(request.Email)
+	newError("policy of user ", request.Email, " deleted").AtInfo().WriteToLog()
+	return &DeleteUserPolicyResponse{}, nil
+}
+
+func (s *policyServer) GetSystemPolicy(ctx context.Context, request *GetSystemPolicyRequest) (*GetSystemPolicyResponse, error) {
+	return &GetSystemPolicyResponse{
+		Policy: s.manager.GetSystem(),
+	}, nil
+}
+
+func (s *policyServer) SetSystemPolicy(ctx context.Context, request *SetSystemPolicyRequest) (*SetSystemPolicyResponse, error) {
+	s.manager.SetSystem(request.Policy)
+	newError("system policy updated").AtInfo().WriteToLog()
+	return &SetSystemPolicyResponse{}, nil
+}
+
+func (s *policyServer) ListPolicies(ctx context.Context, request *ListPoliciesRequest) (*ListPoliciesResponse, error) {
+	return &ListPoliciesResponse{
+		Level:  s.manager.GetLevels(),
+		User:   s.manager.GetUsers(),
+		System: s.manager.GetSystem(),
+	}, nil
+}
+
+func (s *policyServer) mustEmbedUnimplementedPolicyServiceServer() {}
+
+type service struct {
+	policyManager feature_policy.Manager
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterQuotaServiceServer(server, NewQuotaServer(s.policyManager))
+}
+
+type policyService struct {
+	policyManager feature_policy.Manager
+}
+
+func (s *policyService) Register(server *grpc.Server) {
+	manager, ok := s.policyManager.(*policy.Instance)
+	if !ok {
+		newError("policy manager doesn't support runtime update, PolicyService is disabled").AtWarning().WriteToLog()
+		return
+	}
+	RegisterPolicyServiceServer(server, NewPolicyServer(manager))
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := new(service)
+
+		core.RequireFeatures(ctx, func(pm feature_policy.Manager) {
+			s.policyManager = pm
+		})
+
+		return s, nil
+	}))
+	common.Must(common.RegisterConfig((*PolicyServiceConfig)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := new(policyService)
+
+		core.RequireFeatures(ctx, func(pm feature_policy.Manager) {
+			s.policyManager = pm
+		})
+
+		return s, nil
+	}))
+}
@@ -0,0 +1,118 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app/policy"
+	. "v2ray.com/core/app/policy/command"
+	"v2ray.com/core/common"
+)
+
+func TestQuota(t *testing.T) {
+	pm, err := policy.New(context.Background(), &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			1: {
+				Quota: &policy.Policy_Quota{
+					Bytes:  1000,
+					Period: policy.Policy_Quota_Monthly,
+				},
+			},
+		},
+	})
+	common.Must(err)
+	s := NewQuotaServer(pm)
+
+	resp, err := s.GetQuota(context.Background(), &GetQuotaRequest{Email: "test", Level: 1})
+	common.Must(err)
+	if resp.Quota.Limit != 1000 || resp.Quota.Remaining != 1000 || resp.Quota.PeriodStart == 0 {
+		t.Error("unexpected quota: ", resp.Quota)
+	}
+
+	pm.QuotaUsage("test").Add(1500)
+	resp, err = s.GetQuota(context.Background(), &GetQuotaRequest{Email: "test", Level: 1})
+	common.Must(err)
+	if resp.Quota.Used != 1500 || resp.Quota.Remaining != -500 {
+		t.Error("unexpected quota: ", resp.Quota)
+	}
+
+	reset, err := s.ResetQuota(context.Background(), &ResetQuotaRequest{Email: "test", Level: 1})
+	common.Must(err)
+	if reset.Quota.Used != 0 || reset.Quota.Remaining != 1000 {
+		t.Error("unexpected quota after reset: ", reset.Quota)
+	}
+
+	if _, err := s.GetQuota(context.Background(), &GetQuotaRequest{}); err == nil {
+		t.Error("expected error on empty email")
+	}
+}
+
+func TestPolicyService(t *testing.T) {
+	pm, err := policy.New(context.Background(), &policy.Config{})
+	common.Must(err)
+
+	s := NewPolicyServer(pm)
+
+	if _, err := s.GetPolicy(context.Background(), &GetPolicyRequest{Level: 1}); err == nil {
+		t.Error("expected error on unknown level")
+	}
+
+	_, err = s.SetPolicy(context.Background(), &SetPolicyRequest{
+		Level: 1,
+		Policy: &policy.Policy{
+			Timeout: &policy.Policy_Timeout{
+				Handshake: &policy.Second{Value: 2},
+			},
+		},
+	})
+	common.Must(err)
+	if pm.ForLevel(1).Timeouts.Handshake != 2*time.Second {
+		t.Error("unexpected handshake timeout: ", pm.ForLevel(1).Timeouts.Handshake)
+	}
+	if pm.ForLevel(1).Timeouts.ConnectionIdle != 300*time.Second {
+		t.Error("unexpected idle timeout: ", pm.ForLevel(1).Timeouts.ConnectionIdle)
+	}
+
+	_, err = s.SetUserPolicy(context.Background(), &SetUserPolicyRequest{
+		Email: "test",
+		Policy: &policy.Policy{
+			Timeout: &policy.Policy_Timeout{
+				UplinkOnly: &policy.Second{Value: 5},
+			},
+		},
+	})
+	common.Must(err)
+	user := pm.ForUser(1, "test")
+	if user.Timeouts.Handshake != 2*time.Second || user.Timeouts.UplinkOnly != 5*time.Second {
+		t.Error("unexpected user timeouts: ", user.Timeouts)
+	}
+
+	list, err := s.ListPolicies(context.Background(), &ListPoliciesRequest{})
+	common.Must(err)
+	if len(list.Level) != 1 || len(list.User) != 1 {
+		t.Error("unexpected policies: ", list)
+	}
+
+	_, err = s.DeleteUserPolicy(context.Background(), &DeleteUserPolicyRequest{Email: "test"})
+	common.Must(err)
+	if pm.ForUser(1, "test").Timeouts.UplinkOnly != time.Second {
+		t.Error("user policy not deleted")
+	}
+
+	_, err = s.DeletePolicy(context.Background(), &DeletePolicyRequest{Level: 1})
+	common.Must(err)
+	if pm.ForLevel(1).Timeouts.Handshake != 60*time.Second {
+		t.Error("level policy not deleted")
+	}
+
+	_, err = s.SetSystemPolicy(context.Background(), &SetSystemPolicyRequest{
+		Policy: &policy.SystemPolicy{
+			Stats: &policy.SystemPolicy_Stats{InboundUplink: true},
+		},
+	})
+	common.Must(err)
+	if !pm.ForSystem().Stats.InboundUplink {
+		t.Error("system policy not updated")
+	}
+}
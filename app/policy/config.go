@@ -58,6 +58,30 @@ func (p *Policy) overrideWith(another *Policy) {
 			Connection: another.Buffer.Connection,
 		}
 	}
+	if another.Quota != nil {
+		p.Quota = &Policy_Quota{
+			Bytes:    another.Quota.Bytes,
+			Period:   another.Quota.Period,
+			ResetDay: another.Quota.ResetDay,
+		}
+	}
+	if another.Bandwidth != nil {
+		p.Bandwidth = &Policy_Bandwidth{
+			Uplink:        another.Bandwidth.Uplink,
+			Downlink:      another.Bandwidth.Downlink,
+			UplinkBurst:   another.Bandwidth.UplinkBurst,
+			DownlinkBurst: another.Bandwidth.DownlinkBurst,
+		}
+	}
+	if another.Concurrency != nil {
+		p.Concurrency = &Policy_Concurrency{
+			Connections: another.Concurrency.Connections,
+			Ips:         another.Concurrency.Ips,
+		}
+		if another.Concurrency.IpWindow != nil {
+			p.Concurrency.IpWindow = &Second{Value: another.Concurrency.IpWindow.Value}
+		}
+	}
 }
 
 // ToCorePolicy converts this Policy to policy.Session.
@@ -73,21 +97,46 @@ func (p *Policy) ToCorePolicy() policy.Session {
 	if p.Stats != nil {
 		cp.Stats.UserUplink = p.Stats.UserUplink
 		cp.Stats.UserDownlink = p.Stats.UserDownlink
+		cp.Stats.UserConnection = p.Stats.UserConnection
+		cp.Stats.UserRate = p.Stats.UserRate
 	}
 	if p.Buffer != nil {
 		cp.Buffer.PerConnection = p.Buffer.Connection
 	}
+	if p.Quota != nil {
+		cp.Quota.Bytes = int64(p.Quota.Bytes)
+		cp.Quota.Period = policy.QuotaPeriod(p.Quota.Period)
+		cp.Quota.ResetDay = p.Quota.ResetDay
+	}
+	if p.Bandwidth != nil {
+		cp.Bandwidth.Uplink = int64(p.Bandwidth.Uplink)
+		cp.Bandwidth.Downlink = int64(p.Bandwidth.Downlink)
+		cp.Bandwidth.UplinkBurst = int64(p.Bandwidth.UplinkBurst)
+		cp.Bandwidth.DownlinkBurst = int64(p.Bandwidth.DownlinkBurst)
+	}
+	if p.Concurrency != nil {
+		cp.Concurrency.Connections = int32(p.Concurrency.Connections)
+		cp.Concurrency.IPs = int32(p.Concurrency.Ips)
+		cp.Concurrency.IPWindow = p.Concurrency.IpWindow.Duration()
+	}
 	return cp
 }
 
 // ToCorePolicy converts this SystemPolicy to policy.System.
 func (p *SystemPolicy) ToCorePolicy() policy.System {
+	if p.Stats == nil {
+		return policy.System{}
+	}
 	return policy.System{
 		Stats: policy.SystemStats{
-			InboundUplink:    p.Stats.InboundUplink,
-			InboundDownlink:  p.Stats.InboundDownlink,
-			OutboundUplink:   p.Stats.OutboundUplink,
-			OutboundDownlink: p.Stats.OutboundDownlink,
+			InboundUplink:      p.Stats.InboundUplink,
+			InboundDownlink:    p.Stats.InboundDownlink,
+			OutboundUplink:     p.Stats.OutboundUplink,
+			OutboundDownlink:   p.Stats.OutboundDownlink,
+			InboundConnection:  p.Stats.InboundConnection,
+			InboundRate:        p.Stats.InboundRate,
+			OutboundConnection: p.Stats.OutboundConnection,
+			OutboundRate:       p.Stats.OutboundRate,
 		},
 	}
 }
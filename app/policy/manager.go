@@ -2,34 +2,89 @@ package policy
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/bytespool"
+	"v2ray.com/core/common/net"
 	"v2ray.com/core/features/policy"
 )
 
-// Instance is an instance of Policy manager.
-type Instance struct {
+// snapshot is an immutable set of policies. It is replaced as a whole when policies change.
+type snapshot struct {
 	levels map[uint32]*Policy
+	users  map[string]*Policy
 	system *SystemPolicy
 }
 
-// New creates new Policy manager instance.
-func New(ctx context.Context, config *Config) (*Instance, error) {
-	m := &Instance{
+func (s *snapshot) clone() *snapshot {
+	c := &snapshot{
+		levels: make(map[uint32]*Policy, len(s.levels)),
+		users:  make(map[string]*Policy, len(s.users)),
+		system: s.system,
+	}
+	for lv, p := range s.levels {
+		c.levels[lv] = p
+	}
+	for email, p := range s.users {
+		c.users[email] = p
+	}
+	return c
+}
+
+// Instance is an instance of Policy manager.
+type Instance struct {
+	access  sync.Mutex // for writers only
+	current atomic.Value
+	tracker *policy.ConcurrencyTracker
+	quotas  *policy.QuotaStore
+}
+
+func newSnapshot(config *Config) *snapshot {
+	s := &snapshot{
 		levels: make(map[uint32]*Policy),
+		users:  make(map[string]*Policy),
 		system: config.System,
 	}
 	if len(config.Level) > 0 {
 		for lv, p := range config.Level {
 			pp := defaultPolicy()
 			pp.overrideWith(p)
-			m.levels[lv] = pp
+			s.levels[lv] = pp
 		}
 	}
+	for email, p := range config.User {
+		s.users[email] = p
+	}
+	return s
+}
+
+// New creates new Policy manager instance.
+func New(ctx context.Context, config *Config) (*Instance, error) {
+	m := &Instance{}
+	m.current.Store(newSnapshot(config))
+	m.tracker = policy.NewConcurrencyTracker(m)
+	m.quotas = policy.NewQuotaStore()
+	applyMemoryBudget(config.System)
 
 	return m, nil
 }
 
+func (m *Instance) load() *snapshot {
+	return m.current.Load().(*snapshot)
+}
+
+// update applies f to a copy of current policies, and swaps the copy in.
+func (m *Instance) update(f func(*snapshot)) {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	s := m.load().clone()
+	f(s)
+	m.current.Store(s)
+}
+
 // Type implements common.HasType.
 func (*Instance) Type() interface{} {
 	return policy.ManagerType()
@@ -37,18 +92,139 @@ func (*Instance) Type() interface{} {
 
 // ForLevel implements policy.Manager.
 func (m *Instance) ForLevel(level uint32) policy.Session {
-	if p, ok := m.levels[level]; ok {
+	if p, ok := m.load().levels[level]; ok {
 		return p.ToCorePolicy()
 	}
 	return policy.SessionDefault()
 }
 
+// ForUser implements policy.Manager.
+func (m *Instance) ForUser(level uint32, email string) policy.Session {
+	s := m.load()
+	up, ok := s.users[email]
+	if !ok || len(email) == 0 {
+		if p, ok := s.levels[level]; ok {
+			return p.ToCorePolicy()
+		}
+		return policy.SessionDefault()
+	}
+	pp := defaultPolicy()
+	if p, ok := s.levels[level]; ok {
+		pp.overrideWith(p)
+	}
+	pp.overrideWith(up)
+	return pp.ToCorePolicy()
+}
+
+// Acquire implements policy.UserLimiter.
+func (m *Instance) Acquire(level uint32, email string, source net.Address) (func(), error) {
+	return m.tracker.Acquire(level, email, source)
+}
+
+// QuotaUsage implements policy.QuotaTracker. Usage is kept across reloads.
+func (m *Instance) QuotaUsage(email string) *policy.QuotaUsage {
+	return m.quotas.QuotaUsage(email)
+}
+
+// VisitQuotaUsage implements policy.QuotaTracker.
+func (m *Instance) VisitQuotaUsage(visitor func(string, *policy.QuotaUsage) bool) {
+	m.quotas.VisitQuotaUsage(visitor)
+}
+
 // ForSystem implements policy.Manager.
 func (m *Instance) ForSystem() policy.System {
-	if m.system == nil {
+	system := m.load().system
+	if system == nil {
 		return policy.System{}
 	}
-	return m.system.ToCorePolicy()
+	return system.ToCorePolicy()
+}
+
+// GetLevel returns the policy of the given level, with default values filled in.
+func (m *Instance) GetLevel(level uint32) (*Policy, bool) {
+	p, ok := m.load().levels[level]
+	return p, ok
+}
+
+// SetLevel sets the policy of the given level. Unspecified fields take default values.
+func (m *Instance) SetLevel(level uint32, p *Policy) {
+	pp := defaultPolicy()
+	pp.overrideWith(p)
+	m.update(func(s *snapshot) {
+		s.levels[level] = pp
+	})
+}
+
+// DeleteLevel removes the policy of the given level, so that the level takes default policy.
+func (m *Instance) DeleteLevel(level uint32) {
+	m.update(func(s *snapshot) {
+		delete(s.levels, level)
+	})
+}
+
+// GetLevels returns policies of all configured levels.
+func (m *Instance) GetLevels() map[uint32]*Policy {
+	return m.load().clone().levels
+}
+
+// GetUser returns the policy that overrides the level policy of the given user.
+func (m *Instance) GetUser(email string) (*Policy, bool) {
+	p, ok := m.load().users[email]
+	return p, ok
+}
+
+// SetUser sets the policy that overrides the level policy of the given user.
+func (m *Instance) SetUser(email string, p *Policy) {
+	m.update(func(s *snapshot) {
+		s.users[email] = p
+	})
+}
+
+// DeleteUser removes the policy of the given user, so that the user takes the policy of its level.
+func (m *Instance) DeleteUser(email string) {
+	m.update(func(s *snapshot) {
+		delete(s.users, email)
+	})
+}
+
+// GetUsers returns policies of all configured users.
+func (m *Instance) GetUsers() map[string]*Policy {
+	return m.load().clone().users
+}
+
+// GetSystem returns the system policy. It may be nil.
+func (m *Instance) GetSystem() *SystemPolicy {
+	return m.load().system
+}
+
+// SetSystem sets the system policy. A nil policy resets it to default.
+// Changes to stats settings only apply to handlers created afterwards, while the memory budget applies immediately.
+func (m *Instance) SetSystem(p *SystemPolicy) {
+	m.update(func(s *snapshot) {
+		s.system = p
+	})
+	applyMemoryBudget(p)
+}
+
+// applyMemoryBudget sets the global memory budget by the system policy.
+func applyMemoryBudget(p *SystemPolicy) {
+	memory := p.GetMemory()
+	bytespool.SetBudget(int64(memory.GetLimit()), memory.GetReduce(), memory.GetPause(), memory.GetReject())
+}
+
+// PrepareReload implements features.Reloadable. Policies set at runtime are replaced.
+func (m *Instance) PrepareReload(config interface{}) (func(), func(), error) {
+	c, ok := config.(*Config)
+	if !ok {
+		return nil, nil, newError("not a policy config")
+	}
+	s := newSnapshot(c)
+	return func() {
+		m.access.Lock()
+		defer m.access.Unlock()
+		m.current.Store(s)
+		applyMemoryBudget(s.system)
+	}, nil, nil
 }
 
 // Start implements common.Runnable.Start().
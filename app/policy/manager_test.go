@@ -7,6 +7,8 @@ import (
 
 	. "v2ray.com/core/app/policy"
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/features/policy"
 )
 
@@ -43,3 +45,114 @@ func TestPolicy(t *testing.T) {
 		}
 	}
 }
+
+func TestPolicyForUser(t *testing.T) {
+	manager, err := New(context.Background(), &Config{
+		Level: map[uint32]*Policy{
+			0: {
+				Timeout: &Policy_Timeout{
+					Handshake: &Second{
+						Value: 2,
+					},
+				},
+			},
+		},
+		User: map[string]*Policy{
+			"love@v2ray.com": {
+				Quota: &Policy_Quota{
+					Bytes:    1024,
+					Period:   Policy_Quota_Weekly,
+					ResetDay: 1,
+				},
+			},
+		},
+	})
+	common.Must(err)
+
+	p := manager.ForUser(0, "love@v2ray.com")
+	if p.Timeouts.Handshake != 2*time.Second {
+		t.Error("expect 2 sec timeout, but got ", p.Timeouts.Handshake)
+	}
+	if p.Quota.Bytes != 1024 || p.Quota.Period != policy.QuotaPeriodWeekly {
+		t.Error("unexpected quota: ", p.Quota)
+	}
+	if start := p.Quota.PeriodStart(time.Date(2020, 11, 5, 12, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected period start: ", start)
+	}
+
+	if p := manager.ForUser(0, "other@v2ray.com"); p.Quota.Enabled() {
+		t.Error("unexpected quota for other user: ", p.Quota)
+	}
+}
+
+func TestPolicyConcurrency(t *testing.T) {
+	manager, err := New(context.Background(), &Config{
+		Level: map[uint32]*Policy{
+			0: {
+				Concurrency: &Policy_Concurrency{
+					Connections: 2,
+					Ips:         1,
+					IpWindow:    &Second{Value: 60},
+				},
+			},
+		},
+	})
+	common.Must(err)
+
+	user := &protocol.MemoryUser{Email: "love@v2ray.com"}
+	ip1 := net.ParseAddress("1.1.1.1")
+	ip2 := net.ParseAddress("2.2.2.2")
+
+	release1, err := policy.AcquireUser(manager, user, ip1)
+	common.Must(err)
+	if _, err := policy.AcquireUser(manager, user, ip2); err == nil {
+		t.Error("expected error on second source IP")
+	}
+	release2, err := policy.AcquireUser(manager, user, ip1)
+	common.Must(err)
+	if _, err := policy.AcquireUser(manager, user, ip1); err == nil {
+		t.Error("expected error on third connection")
+	}
+
+	release1()
+	release1()
+	release3, err := policy.AcquireUser(manager, user, ip1)
+	common.Must(err)
+	release2()
+	release3()
+
+	// The IP is remembered within window even if all connections end.
+	if _, err := policy.AcquireUser(manager, user, ip2); err == nil {
+		t.Error("expected error on second source IP within window")
+	}
+
+	if _, err := policy.AcquireUser(manager, &protocol.MemoryUser{}, ip2); err != nil {
+		t.Error("unexpected limit on anonymous user: ", err)
+	}
+}
+
+func TestPolicyReload(t *testing.T) {
+	manager, err := New(context.Background(), &Config{})
+	common.Must(err)
+
+	apply, _, err := manager.PrepareReload(&Config{
+		Level: map[uint32]*Policy{
+			0: {
+				Timeout: &Policy_Timeout{
+					Handshake: &Second{
+						Value: 2,
+					},
+				},
+			},
+		},
+	})
+	common.Must(err)
+
+	if p := manager.ForLevel(0); p.Timeouts.Handshake == 2*time.Second {
+		t.Error("policy changed before applying")
+	}
+	apply()
+	if p := manager.ForLevel(0); p.Timeouts.Handshake != 2*time.Second {
+		t.Error("expect 2 sec timeout, but got ", p.Timeouts.Handshake)
+	}
+}
@@ -4,11 +4,14 @@ package command
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	grpc "google.golang.org/grpc"
 
 	"v2ray.com/core"
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/features/inbound"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/proxy"
@@ -26,6 +29,16 @@ type OutboundOperation interface {
 	ApplyOutbound(context.Context, outbound.Handler) error
 }
 
+// inboundConfig is implemented by inbound handlers that keep the config they are created from.
+type inboundConfig interface {
+	Config() *core.InboundHandlerConfig
+}
+
+// outboundConfig is implemented by outbound handlers that keep the config they are created from.
+type outboundConfig interface {
+	Config() *core.OutboundHandlerConfig
+}
+
 func getInbound(handler inbound.Handler) (proxy.Inbound, error) {
 	gi, ok := handler.(proxy.GetInbound)
 	if !ok {
@@ -34,15 +47,23 @@ func getInbound(handler inbound.Handler) (proxy.Inbound, error) {
 	return gi.GetInbound(), nil
 }
 
-// ApplyInbound implements InboundOperation.
-func (op *AddUserOperation) ApplyInbound(ctx context.Context, handler inbound.Handler) error {
+func getUserManager(handler inbound.Handler) (proxy.UserManager, error) {
 	p, err := getInbound(handler)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	um, ok := p.(proxy.UserManager)
 	if !ok {
-		return newError("proxy is not a UserManager")
+		return nil, newError("proxy is not a UserManager")
+	}
+	return um, nil
+}
+
+// ApplyInbound implements InboundOperation.
+func (op *AddUserOperation) ApplyInbound(ctx context.Context, handler inbound.Handler) error {
+	um, err := getUserManager(handler)
+	if err != nil {
+		return err
 	}
 	mUser, err := op.User.ToMemoryUser()
 	if err != nil {
@@ -53,14 +74,10 @@ func (op *AddUserOperation) ApplyInbound(ctx context.Context, handler inbound.Ha
 
 // ApplyInbound implements InboundOperation.
 func (op *RemoveUserOperation) ApplyInbound(ctx context.Context, handler inbound.Handler) error {
-	p, err := getInbound(handler)
+	um, err := getUserManager(handler)
 	if err != nil {
 		return err
 	}
-	um, ok := p.(proxy.UserManager)
-	if !ok {
-		return newError("proxy is not a UserManager")
-	}
 	return um.RemoveUser(ctx, op.Email)
 }
 
@@ -79,9 +96,30 @@ func (s *handlerServer) AddInbound(ctx context.Context, request *AddInboundReque
 }
 
 func (s *handlerServer) RemoveInbound(ctx context.Context, request *RemoveInboundRequest) (*RemoveInboundResponse, error) {
+	if request.DrainTimeout > 0 {
+		return &RemoveInboundResponse{}, s.ihm.DrainHandler(ctx, request.Tag, time.Duration(request.DrainTimeout)*time.Second)
+	}
 	return &RemoveInboundResponse{}, s.ihm.RemoveHandler(ctx, request.Tag)
 }
 
+func (s *handlerServer) ReplaceInbound(ctx context.Context, request *ReplaceInboundRequest) (*ReplaceInboundResponse, error) {
+	if request.Inbound == nil {
+		return nil, newError("inbound config not specified")
+	}
+	rawHandler, err := core.CreateObject(s.s, request.Inbound)
+	if err != nil {
+		return nil, err
+	}
+	handler, ok := rawHandler.(inbound.Handler)
+	if !ok {
+		return nil, newError("not an InboundHandler")
+	}
+	if err := s.ihm.ReplaceHandler(ctx, handler, time.Duration(request.DrainTimeout)*time.Second); err != nil {
+		return nil, err
+	}
+	return &ReplaceInboundResponse{}, nil
+}
+
 func (s *handlerServer) AlterInbound(ctx context.Context, request *AlterInboundRequest) (*AlterInboundResponse, error) {
 	rawOperation, err := request.Operation.GetInstance()
 	if err != nil {
@@ -108,9 +146,30 @@ func (s *handlerServer) AddOutbound(ctx context.Context, request *AddOutboundReq
 }
 
 func (s *handlerServer) RemoveOutbound(ctx context.Context, request *RemoveOutboundRequest) (*RemoveOutboundResponse, error) {
+	if request.DrainTimeout > 0 {
+		return &RemoveOutboundResponse{}, s.ohm.DrainHandler(ctx, request.Tag, time.Duration(request.DrainTimeout)*time.Second)
+	}
 	return &RemoveOutboundResponse{}, s.ohm.RemoveHandler(ctx, request.Tag)
 }
 
+func (s *handlerServer) ReplaceOutbound(ctx context.Context, request *ReplaceOutboundRequest) (*ReplaceOutboundResponse, error) {
+	if request.Outbound == nil {
+		return nil, newError("outbound config not specified")
+	}
+	rawHandler, err := core.CreateObject(s.s, request.Outbound)
+	if err != nil {
+		return nil, err
+	}
+	handler, ok := rawHandler.(outbound.Handler)
+	if !ok {
+		return nil, newError("not an OutboundHandler")
+	}
+	if err := s.ohm.ReplaceHandler(ctx, handler, time.Duration(request.DrainTimeout)*time.Second); err != nil {
+		return nil, err
+	}
+	return &ReplaceOutboundResponse{}, nil
+}
+
 func (s *handlerServer) AlterOutbound(ctx context.Context, request *AlterOutboundRequest) (*AlterOutboundResponse, error) {
 	rawOperation, err := request.Operation.GetInstance()
 	if err != nil {
@@ -125,6 +184,86 @@ func (s *handlerServer) AlterOutbound(ctx context.Context, request *AlterOutboun
 	return &AlterOutboundResponse{}, operation.ApplyOutbound(ctx, handler)
 }
 
+func (s *handlerServer) ListInbounds(ctx context.Context, request *ListInboundsRequest) (*ListInboundsResponse, error) {
+	handlers := s.ihm.ListHandlers(ctx)
+	response := &ListInboundsResponse{
+		Inbounds: make([]*core.InboundHandlerConfig, 0, len(handlers)),
+	}
+	for _, handler := range handlers {
+		var config *core.InboundHandlerConfig
+		if c, ok := handler.(inboundConfig); ok {
+			config = c.Config()
+		}
+		if config == nil {
+			config = &core.InboundHandlerConfig{Tag: handler.Tag()}
+		}
+		response.Inbounds = append(response.Inbounds, config)
+	}
+	return response, nil
+}
+
+func (s *handlerServer) ListOutbounds(ctx context.Context, request *ListOutboundsRequest) (*ListOutboundsResponse, error) {
+	handlers := s.ohm.ListHandlers(ctx)
+	response := &ListOutboundsResponse{
+		Outbounds: make([]*core.OutboundHandlerConfig, 0, len(handlers)),
+	}
+	for _, handler := range handlers {
+		var config *core.OutboundHandlerConfig
+		if c, ok := handler.(outboundConfig); ok {
+			config = c.Config()
+		}
+		if config == nil {
+			config = &core.OutboundHandlerConfig{Tag: handler.Tag()}
+		}
+		response.Outbounds = append(response.Outbounds, config)
+	}
+	return response, nil
+}
+
+func (s *handlerServer) getUsers(ctx context.Context, tag string) ([]*protocol.MemoryUser, error) {
+	handler, err := s.ihm.GetHandler(ctx, tag)
+	if err != nil {
+		return nil, newError("failed to get handler: ", tag).Base(err)
+	}
+	um, err := getUserManager(handler)
+	if err != nil {
+		return nil, err
+	}
+	return um.GetUsers(ctx), nil
+}
+
+func (s *handlerServer) ListUsers(ctx context.Context, request *ListUsersRequest) (*ListUsersResponse, error) {
+	users, err := s.getUsers(ctx, request.Tag)
+	if err != nil {
+		return nil, err
+	}
+	response := &ListUsersResponse{
+		Users: make([]*protocol.User, 0, len(users)),
+	}
+	for _, user := range users {
+		response.Users = append(response.Users, user.ToProto())
+	}
+	return response, nil
+}
+
+func (s *handlerServer) GetUser(ctx context.Context, request *GetUserRequest) (*GetUserResponse, error) {
+	if len(request.Email) == 0 {
+		return nil, newError("empty email")
+	}
+	users, err := s.getUsers(ctx, request.Tag)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if strings.EqualFold(user.Email, request.Email) {
+			return &GetUserResponse{
+				User: user.ToProto(),
+			}, nil
+		}
+	}
+	return nil, newError("user ", request.Email, " not found in handler ", request.Tag)
+}
+
 func (s *handlerServer) mustEmbedUnimplementedHandlerServiceServer() {}
 
 type service struct {
@@ -1,5 +1,13 @@
 package proxyman
 
+import (
+	"crypto/rand"
+	"crypto/sha256"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+)
+
 func (s *AllocationStrategy) GetConcurrencyValue() uint32 {
 	if s == nil || s.Concurrency == nil {
 		return 3
@@ -37,3 +45,24 @@ func (c *ReceiverConfig) GetEffectiveSniffingSettings() *SniffingConfig {
 
 	return nil
 }
+
+// IsValid returns true if the CIDR is a valid IPv4 or IPv6 CIDR.
+func (c *ViaCIDR) IsValid() bool {
+	return (len(c.Ip) == net.IPv4len || len(c.Ip) == net.IPv6len) && c.Prefix <= uint32(len(c.Ip)*8)
+}
+
+// Pick returns an address in the CIDR. The address is hashed from the key, or random if the key is empty.
+func (c *ViaCIDR) Pick(key string) net.Address {
+	ip := make(net.IP, len(c.Ip))
+	if len(key) > 0 {
+		hash := sha256.Sum256([]byte(key))
+		copy(ip, hash[:])
+	} else {
+		common.Must2(rand.Read(ip))
+	}
+	mask := net.CIDRMask(int(c.Prefix), len(c.Ip)*8)
+	for i := range ip {
+		ip[i] = c.Ip[i]&mask[i] | ip[i]&^mask[i]
+	}
+	return net.IPAddress(ip)
+}
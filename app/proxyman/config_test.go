@@ -0,0 +1,43 @@
+package proxyman_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/net"
+)
+
+func TestViaCIDRPick(t *testing.T) {
+	cidr := &ViaCIDR{
+		Ip:     []byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Prefix: 64,
+	}
+	if !cidr.IsValid() {
+		t.Fatal("expect valid CIDR")
+	}
+	_, ipNet, _ := net.ParseCIDR("2001:db8::/64")
+
+	a := cidr.Pick("")
+	b := cidr.Pick("")
+	for _, addr := range []net.Address{a, b} {
+		if !ipNet.Contains(addr.IP()) {
+			t.Error("address not in CIDR: ", addr)
+		}
+	}
+	if a == b {
+		t.Error("expect different random addresses, but got ", a)
+	}
+
+	if c, d := cidr.Pick("v2fly.org"), cidr.Pick("v2fly.org"); c != d || !ipNet.Contains(c.IP()) {
+		t.Error("expect the same address in CIDR for the same key, but got ", c, " and ", d)
+	}
+
+	full := &ViaCIDR{Ip: []byte{10, 0, 0, 1}, Prefix: 32}
+	if addr := full.Pick(""); addr.String() != "10.0.0.1" {
+		t.Error("unexpected address: ", addr)
+	}
+
+	if (&ViaCIDR{Ip: []byte{10, 0, 0, 0}, Prefix: 33}).IsValid() {
+		t.Error("expect invalid prefix")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"v2ray.com/core/common/errors"
 	"v2ray.com/core/common/mux"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/inbound"
 	"v2ray.com/core/features/policy"
 	"v2ray.com/core/features/stats"
 	"v2ray.com/core/proxy"
@@ -41,11 +42,45 @@ func getStatCounter(v *core.Instance, tag string) (stats.Counter, stats.Counter)
 	return uplinkCounter, downlinkCounter
 }
 
+func getStatGauge(v *core.Instance, tag string) stats.Gauge {
+	policy := v.GetFeature(policy.ManagerType()).(policy.Manager)
+	if len(tag) > 0 && policy.ForSystem().Stats.InboundConnection {
+		statsManager := v.GetFeature(stats.ManagerType()).(stats.Manager)
+		name := "inbound>>>" + tag + ">>>connection>>>active"
+		g, _ := stats.GetOrRegisterGauge(statsManager, name)
+		if g != nil {
+			return g
+		}
+	}
+	return nil
+}
+
+func getStatRate(v *core.Instance, tag string) (stats.Rate, stats.Rate) {
+	var uplinkRate stats.Rate
+	var downlinkRate stats.Rate
+
+	policy := v.GetFeature(policy.ManagerType()).(policy.Manager)
+	if len(tag) > 0 && policy.ForSystem().Stats.InboundRate {
+		statsManager := v.GetFeature(stats.ManagerType()).(stats.Manager)
+		name := "inbound>>>" + tag + ">>>rate>>>uplink"
+		if r, _ := stats.GetOrRegisterRate(statsManager, name); r != nil {
+			uplinkRate = r
+		}
+		name = "inbound>>>" + tag + ">>>rate>>>downlink"
+		if r, _ := stats.GetOrRegisterRate(statsManager, name); r != nil {
+			downlinkRate = r
+		}
+	}
+
+	return uplinkRate, downlinkRate
+}
+
 type AlwaysOnInboundHandler struct {
 	proxy   proxy.Inbound
 	workers []worker
 	mux     *mux.Server
 	tag     string
+	config  *core.InboundHandlerConfig
 }
 
 func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *proxyman.ReceiverConfig, proxyConfig interface{}) (*AlwaysOnInboundHandler, error) {
@@ -64,7 +99,10 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 		tag:   tag,
 	}
 
-	uplinkCounter, downlinkCounter := getStatCounter(core.MustFromContext(ctx), tag)
+	v := core.MustFromContext(ctx)
+	uplinkCounter, downlinkCounter := getStatCounter(v, tag)
+	uplinkRate, downlinkRate := getStatRate(v, tag)
+	connGauge := getStatGauge(v, tag)
 
 	nl := p.Network()
 	pr := receiverConfig.PortRange
@@ -103,8 +141,14 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 				sniffingConfig:  receiverConfig.GetEffectiveSniffingSettings(),
 				uplinkCounter:   uplinkCounter,
 				downlinkCounter: downlinkCounter,
+				uplinkRate:      uplinkRate,
+				downlinkRate:    downlinkRate,
+				connGauge:       connGauge,
 				ctx:             ctx,
 			}
+			if err := worker.redirect(); err != nil {
+				return nil, err
+			}
 			h.workers = append(h.workers, worker)
 		}
 
@@ -117,6 +161,9 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 				dispatcher:      h.mux,
 				uplinkCounter:   uplinkCounter,
 				downlinkCounter: downlinkCounter,
+				uplinkRate:      uplinkRate,
+				downlinkRate:    downlinkRate,
+				connGauge:       connGauge,
 				stream:          mss,
 			}
 			h.workers = append(h.workers, worker)
@@ -128,6 +175,11 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 
 // Start implements common.Runnable.
 func (h *AlwaysOnInboundHandler) Start() error {
+	if runnable, ok := h.proxy.(common.Runnable); ok {
+		if err := runnable.Start(); err != nil {
+			return err
+		}
+	}
 	for _, worker := range h.workers {
 		if err := worker.Start(); err != nil {
 			return err
@@ -136,6 +188,11 @@ func (h *AlwaysOnInboundHandler) Start() error {
 	return nil
 }
 
+// Drain implements common.Drainable.
+func (h *AlwaysOnInboundHandler) Drain(ctx context.Context) error {
+	return drainWorkers(ctx, h.workers)
+}
+
 // Close implements common.Closable.
 func (h *AlwaysOnInboundHandler) Close() error {
 	var errs []error
@@ -149,6 +206,26 @@ func (h *AlwaysOnInboundHandler) Close() error {
 	return nil
 }
 
+// handOver implements flowHandOver. UDP workers pass flows from new clients to the workers of successor that listen on the same address and port.
+func (h *AlwaysOnInboundHandler) handOver(successor inbound.Handler) {
+	s, ok := successor.(*AlwaysOnInboundHandler)
+	if !ok {
+		return
+	}
+	for _, w := range h.workers {
+		uw, ok := w.(*udpWorker)
+		if !ok {
+			continue
+		}
+		for _, sw := range s.workers {
+			if su, ok := sw.(*udpWorker); ok && su.address == uw.address && su.port == uw.port {
+				uw.handOver(su)
+				break
+			}
+		}
+	}
+}
+
 func (h *AlwaysOnInboundHandler) GetRandomInboundProxy() (interface{}, net.Port, int) {
 	if len(h.workers) == 0 {
 		return nil, 0, 0
@@ -161,6 +238,11 @@ func (h *AlwaysOnInboundHandler) Tag() string {
 	return h.tag
 }
 
+// Config returns the config that this handler is created from. It may be nil.
+func (h *AlwaysOnInboundHandler) Config() *core.InboundHandlerConfig {
+	return h.config
+}
+
 func (h *AlwaysOnInboundHandler) GetInbound() proxy.Inbound {
 	return h.proxy
 }
@@ -28,6 +28,7 @@ type DynamicInboundHandler struct {
 	lastRefresh    time.Time
 	mux            *mux.Server
 	task           *task.Periodic
+	config         *core.InboundHandlerConfig
 
 	ctx context.Context
 }
@@ -115,6 +116,8 @@ func (h *DynamicInboundHandler) refresh() error {
 	}
 
 	uplinkCounter, downlinkCounter := getStatCounter(h.v, h.tag)
+	uplinkRate, downlinkRate := getStatRate(h.v, h.tag)
+	connGauge := getStatGauge(h.v, h.tag)
 
 	for i := uint32(0); i < concurrency; i++ {
 		port := h.allocatePort()
@@ -137,8 +140,15 @@ func (h *DynamicInboundHandler) refresh() error {
 				sniffingConfig:  h.receiverConfig.GetEffectiveSniffingSettings(),
 				uplinkCounter:   uplinkCounter,
 				downlinkCounter: downlinkCounter,
+				uplinkRate:      uplinkRate,
+				downlinkRate:    downlinkRate,
+				connGauge:       connGauge,
 				ctx:             h.ctx,
 			}
+			if err := worker.redirect(); err != nil {
+				newError("failed to create TCP worker").Base(err).AtWarning().WriteToLog()
+				continue
+			}
 			if err := worker.Start(); err != nil {
 				newError("failed to create TCP worker").Base(err).AtWarning().WriteToLog()
 				continue
@@ -155,6 +165,9 @@ func (h *DynamicInboundHandler) refresh() error {
 				dispatcher:      h.mux,
 				uplinkCounter:   uplinkCounter,
 				downlinkCounter: downlinkCounter,
+				uplinkRate:      uplinkRate,
+				downlinkRate:    downlinkRate,
+				connGauge:       connGauge,
 				stream:          h.streamSettings,
 			}
 			if err := worker.Start(); err != nil {
@@ -184,6 +197,19 @@ func (h *DynamicInboundHandler) Close() error {
 	return h.task.Close()
 }
 
+// Drain implements common.Drainable. It stops allocating new ports, and drains the workers on current ports.
+func (h *DynamicInboundHandler) Drain(ctx context.Context) error {
+	if err := h.task.Close(); err != nil {
+		return err
+	}
+
+	h.workerMutex.RLock()
+	workers := h.worker
+	h.workerMutex.RUnlock()
+
+	return drainWorkers(ctx, workers)
+}
+
 func (h *DynamicInboundHandler) GetRandomInboundProxy() (interface{}, net.Port, int) {
 	h.workerMutex.RLock()
 	defer h.workerMutex.RUnlock()
@@ -199,3 +225,8 @@ func (h *DynamicInboundHandler) GetRandomInboundProxy() (interface{}, net.Port,
 func (h *DynamicInboundHandler) Tag() string {
 	return h.tag
 }
+
+// Config returns the config that this handler is created from. It may be nil.
+func (h *DynamicInboundHandler) Config() *core.InboundHandlerConfig {
+	return h.config
+}
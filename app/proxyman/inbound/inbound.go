@@ -4,13 +4,16 @@ package inbound
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"v2ray.com/core"
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/serial"
 	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/task"
 	"v2ray.com/core/features/inbound"
 )
 
@@ -20,12 +23,21 @@ type Manager struct {
 	untaggedHandler []inbound.Handler
 	taggedHandlers  map[string]inbound.Handler
 	running         bool
+
+	draining map[inbound.Handler]context.CancelFunc
+	drains   sync.WaitGroup
+}
+
+// flowHandOver is implemented by handlers that are able to pass new flows to the handler replacing them.
+type flowHandOver interface {
+	handOver(successor inbound.Handler)
 }
 
 // New returns a new Manager for inbound handlers.
 func New(ctx context.Context, config *proxyman.InboundConfig) (*Manager, error) {
 	m := &Manager{
 		taggedHandlers: make(map[string]inbound.Handler),
+		draining:       make(map[inbound.Handler]context.CancelFunc),
 	}
 	return m, nil
 }
@@ -86,6 +98,97 @@ func (m *Manager) RemoveHandler(ctx context.Context, tag string) error {
 	return common.ErrNoClue
 }
 
+// DrainHandler implements inbound.Manager.
+func (m *Manager) DrainHandler(ctx context.Context, tag string, timeout time.Duration) error {
+	if tag == "" {
+		return common.ErrNoClue
+	}
+
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	handler, found := m.taggedHandlers[tag]
+	if !found {
+		return common.ErrNoClue
+	}
+	delete(m.taggedHandlers, tag)
+	m.drain(ctx, handler, timeout)
+	return nil
+}
+
+// ReplaceHandler implements inbound.Manager.
+func (m *Manager) ReplaceHandler(ctx context.Context, handler inbound.Handler, timeout time.Duration) error {
+	tag := handler.Tag()
+	if tag == "" {
+		return newError("can't replace handler without tag")
+	}
+
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	old, found := m.taggedHandlers[tag]
+	if !found {
+		return newError("handler not found: ", tag)
+	}
+
+	// Listeners are created with SO_REUSEPORT, so the new handler is able to listen on the same port as the old one.
+	if m.running {
+		if err := handler.Start(); err != nil {
+			common.Close(handler) // nolint: errcheck
+			return newError("failed to start handler ", tag).Base(err)
+		}
+	}
+	m.taggedHandlers[tag] = handler
+	// UDP sockets of the old handler still receive packets from new clients, which are passed to the new handler.
+	if h, ok := old.(flowHandOver); ok {
+		h.handOver(handler)
+	}
+	m.drain(ctx, old, timeout)
+	return nil
+}
+
+// drain drains and closes the handler in background. It must be called with the lock held.
+func (m *Manager) drain(ctx context.Context, handler inbound.Handler, timeout time.Duration) {
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	m.draining[handler] = cancel
+	m.drains.Add(1)
+
+	go func() {
+		defer m.drains.Done()
+
+		if err := common.Drain(drainCtx, handler); err != nil {
+			newError("closing handler ", handler.Tag(), " with unfinished connections").Base(err).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+		}
+		cancel()
+		if err := handler.Close(); err != nil {
+			newError("failed to close handler ", handler.Tag()).Base(err).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+		}
+
+		m.access.Lock()
+		delete(m.draining, handler)
+		m.access.Unlock()
+	}()
+}
+
+// ListHandlers implements inbound.Manager.
+func (m *Manager) ListHandlers(ctx context.Context) []inbound.Handler {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	tags := make([]string, 0, len(m.taggedHandlers))
+	for tag := range m.taggedHandlers {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	handlers := make([]inbound.Handler, 0, len(m.taggedHandlers)+len(m.untaggedHandler))
+	for _, tag := range tags {
+		handlers = append(handlers, m.taggedHandlers[tag])
+	}
+	handlers = append(handlers, m.untaggedHandler...)
+	return handlers
+}
+
 // Start implements common.Runnable.
 func (m *Manager) Start() error {
 	m.access.Lock()
@@ -107,8 +210,29 @@ func (m *Manager) Start() error {
 	return nil
 }
 
+// Drain implements common.Drainable. All handlers stop accepting new connections, and wait for their existing connections to finish.
+func (m *Manager) Drain(ctx context.Context) error {
+	handlers := m.ListHandlers(ctx)
+	tasks := make([]func() error, 0, len(handlers))
+	for _, handler := range handlers {
+		handler := handler
+		tasks = append(tasks, func() error {
+			return common.Drain(ctx, handler)
+		})
+	}
+	return task.Run(ctx, tasks...)
+}
+
 // Close implements common.Closable.
 func (m *Manager) Close() error {
+	// Handlers being drained are closed right away.
+	m.access.Lock()
+	for _, cancel := range m.draining {
+		cancel()
+	}
+	m.access.Unlock()
+	m.drains.Wait()
+
 	m.access.Lock()
 	defer m.access.Unlock()
 
@@ -159,11 +283,21 @@ func NewHandler(ctx context.Context, config *core.InboundHandlerConfig) (inbound
 
 	allocStrategy := receiverSettings.AllocationStrategy
 	if allocStrategy == nil || allocStrategy.Type == proxyman.AllocationStrategy_Always {
-		return NewAlwaysOnInboundHandler(ctx, tag, receiverSettings, proxySettings)
+		h, err := NewAlwaysOnInboundHandler(ctx, tag, receiverSettings, proxySettings)
+		if err != nil {
+			return nil, err
+		}
+		h.config = config
+		return h, nil
 	}
 
 	if allocStrategy.Type == proxyman.AllocationStrategy_Random {
-		return NewDynamicInboundHandler(ctx, tag, receiverSettings, proxySettings)
+		h, err := NewDynamicInboundHandler(ctx, tag, receiverSettings, proxySettings)
+		if err != nil {
+			return nil, err
+		}
+		h.config = config
+		return h, nil
 	}
 	return nil, newError("unknown allocation strategy: ", receiverSettings.AllocationStrategy.Type).AtError()
 }
@@ -0,0 +1,143 @@
+package inbound
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/inbound"
+)
+
+// testHandler is an inbound.Handler whose Drain waits for release.
+type testHandler struct {
+	tag      string
+	started  bool
+	release  chan struct{}
+	closed   chan struct{}
+	handedTo inbound.Handler
+}
+
+func newTestHandler(tag string) *testHandler {
+	return &testHandler{
+		tag:     tag,
+		release: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (h *testHandler) Start() error {
+	h.started = true
+	return nil
+}
+
+func (h *testHandler) Close() error {
+	close(h.closed)
+	return nil
+}
+
+func (h *testHandler) Drain(ctx context.Context) error {
+	select {
+	case <-h.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *testHandler) handOver(successor inbound.Handler) {
+	h.handedTo = successor
+}
+
+func (h *testHandler) Tag() string {
+	return h.tag
+}
+
+func (*testHandler) GetRandomInboundProxy() (interface{}, net.Port, int) {
+	return nil, 0, 0
+}
+
+func waitClosed(t *testing.T, h *testHandler, timeout time.Duration) {
+	select {
+	case <-h.closed:
+	case <-time.After(timeout):
+		t.Error("handler ", h.tag, " is not closed")
+	}
+}
+
+func TestManagerDrainHandler(t *testing.T) {
+	m, err := New(context.Background(), &proxyman.InboundConfig{})
+	common.Must(err)
+	common.Must(m.Start())
+
+	h := newTestHandler("test")
+	common.Must(m.AddHandler(context.Background(), h))
+	common.Must(m.DrainHandler(context.Background(), "test", time.Minute))
+
+	if _, err := m.GetHandler(context.Background(), "test"); err == nil {
+		t.Error("draining handler is still registered")
+	}
+	if err := m.DrainHandler(context.Background(), "test", time.Minute); err == nil {
+		t.Error("expected error when draining a removed handler")
+	}
+
+	select {
+	case <-h.closed:
+		t.Error("handler is closed before it is drained")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(h.release)
+	waitClosed(t, h, 5*time.Second)
+
+	common.Must(m.Close())
+}
+
+func TestManagerReplaceHandler(t *testing.T) {
+	m, err := New(context.Background(), &proxyman.InboundConfig{})
+	common.Must(err)
+	common.Must(m.Start())
+
+	old := newTestHandler("test")
+	common.Must(m.AddHandler(context.Background(), old))
+
+	if err := m.ReplaceHandler(context.Background(), newTestHandler(""), time.Minute); err == nil {
+		t.Error("expected error when replacing a handler without tag")
+	}
+	if err := m.ReplaceHandler(context.Background(), newTestHandler("unknown"), time.Minute); err == nil {
+		t.Error("expected error when replacing a missing handler")
+	}
+
+	h := newTestHandler("test")
+	common.Must(m.ReplaceHandler(context.Background(), h, 100*time.Millisecond))
+
+	if !h.started {
+		t.Error("new handler is not started")
+	}
+	if r, err := m.GetHandler(context.Background(), "test"); err != nil || r != h {
+		t.Error("handler is not replaced: ", r, err)
+	}
+	if old.handedTo != h {
+		t.Error("old handler is not handed over to the new one")
+	}
+
+	// The old handler is closed when the timeout expires.
+	waitClosed(t, old, 5*time.Second)
+
+	common.Must(m.Close())
+	waitClosed(t, h, time.Second)
+}
+
+func TestManagerCloseDrainingHandler(t *testing.T) {
+	m, err := New(context.Background(), &proxyman.InboundConfig{})
+	common.Must(err)
+	common.Must(m.Start())
+
+	h := newTestHandler("test")
+	common.Must(m.AddHandler(context.Background(), h))
+	common.Must(m.DrainHandler(context.Background(), "test", time.Hour))
+
+	common.Must(m.Close())
+	waitClosed(t, h, time.Second)
+}
@@ -2,6 +2,7 @@ package inbound
 
 import (
 	"context"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/bytespool"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/serial"
 	"v2ray.com/core/common/session"
@@ -26,10 +28,24 @@ import (
 type worker interface {
 	Start() error
 	Close() error
+	// Drain stops accepting new connections, and waits for existing ones to finish.
+	Drain(ctx context.Context) error
 	Port() net.Port
 	Proxy() proxy.Inbound
 }
 
+// drainWorkers drains all the workers in parallel.
+func drainWorkers(ctx context.Context, workers []worker) error {
+	tasks := make([]func() error, 0, len(workers))
+	for _, w := range workers {
+		w := w
+		tasks = append(tasks, func() error {
+			return w.Drain(ctx)
+		})
+	}
+	return task.Run(ctx, tasks...)
+}
+
 type tcpWorker struct {
 	address         net.Address
 	port            net.Port
@@ -41,12 +57,86 @@ type tcpWorker struct {
 	sniffingConfig  *proxyman.SniffingConfig
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
+	uplinkRate      stats.Rate
+	downlinkRate    stats.Rate
+	connGauge       stats.Gauge
 
-	hub internet.Listener
+	// redirector receives connections on address and port, and forwards them to the worker listening on a free port of localAddress.
+	redirector   proxy.TCPListenRedirector
+	localAddress net.Address
+
+	hub       internet.Listener
+	hubClosed sync.Once
+	conns     connSet
 
 	ctx context.Context
 }
 
+// connSet tracks the live connections of a worker.
+type connSet struct {
+	sync.Mutex
+	conns map[io.Closer]struct{}
+	empty chan struct{}
+}
+
+func (s *connSet) add(c io.Closer) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.conns == nil {
+		s.conns = make(map[io.Closer]struct{})
+	}
+	s.conns[c] = struct{}{}
+}
+
+func (s *connSet) remove(c io.Closer) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.conns, c)
+	if len(s.conns) == 0 && s.empty != nil {
+		close(s.empty)
+		s.empty = nil
+	}
+}
+
+// wait blocks until there is no live connection, or ctx is done.
+func (s *connSet) wait(ctx context.Context) error {
+	s.Lock()
+	if len(s.conns) == 0 {
+		s.Unlock()
+		return nil
+	}
+	if s.empty == nil {
+		s.empty = make(chan struct{})
+	}
+	empty := s.empty
+	s.Unlock()
+
+	select {
+	case <-empty:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close closes all live connections.
+func (s *connSet) close() {
+	s.Lock()
+	conns := s.conns
+	s.conns = nil
+	if s.empty != nil {
+		close(s.empty)
+		s.empty = nil
+	}
+	s.Unlock()
+
+	for c := range conns {
+		c.Close() // nolint: errcheck
+	}
+}
+
 func getTProxyType(s *internet.MemoryStreamConfig) internet.SocketConfig_TProxyMode {
 	if s == nil || s.SocketSettings == nil {
 		return internet.SocketConfig_Off
@@ -54,7 +144,34 @@ func getTProxyType(s *internet.MemoryStreamConfig) internet.SocketConfig_TProxyM
 	return s.SocketSettings.Tproxy
 }
 
+// rejectingByMemory is 1 while new connections are rejected for memory pressure.
+var rejectingByMemory int32
+
+// rejectedByMemory returns true if new connections are rejected for memory pressure.
+// The reason is logged once when the rejection starts, instead of once per connection or packet.
+func rejectedByMemory(source net.Destination) bool {
+	if bytespool.CurrentPressure() < bytespool.PressureReject {
+		if atomic.LoadInt32(&rejectingByMemory) == 1 && atomic.CompareAndSwapInt32(&rejectingByMemory, 1, 0) {
+			newError("accepting new connections as memory pressure drops").AtInfo().WriteToLog()
+		}
+		return false
+	}
+	if atomic.CompareAndSwapInt32(&rejectingByMemory, 0, 1) {
+		usage, limit := bytespool.Usage()
+		newError("rejecting new connections, starting from ", source, ": memory usage ", usage, " bytes is close to the budget of ", limit, " bytes").AtWarning().WriteToLog()
+	}
+	return true
+}
+
 func (w *tcpWorker) callback(conn internet.Connection) {
+	if rejectedByMemory(net.DestinationFromAddr(conn.RemoteAddr())) {
+		conn.Close() // nolint: errcheck
+		return
+	}
+
+	w.conns.add(conn)
+	defer w.conns.remove(conn)
+
 	ctx, cancel := context.WithCancel(w.ctx)
 	sid := session.NewID()
 	ctx = session.ContextWithID(ctx, sid)
@@ -89,13 +206,19 @@ func (w *tcpWorker) callback(conn internet.Connection) {
 		content.SniffingRequest.OverrideDestinationForProtocol = w.sniffingConfig.DestinationOverride
 	}
 	ctx = session.ContextWithContent(ctx, content)
-	if w.uplinkCounter != nil || w.downlinkCounter != nil {
+	if w.uplinkCounter != nil || w.downlinkCounter != nil || w.uplinkRate != nil || w.downlinkRate != nil {
 		conn = &internet.StatCouterConnection{
 			Connection:   conn,
 			ReadCounter:  w.uplinkCounter,
 			WriteCounter: w.downlinkCounter,
+			ReadRate:     w.uplinkRate,
+			WriteRate:    w.downlinkRate,
 		}
 	}
+	if w.connGauge != nil {
+		w.connGauge.Add(1)
+		defer w.connGauge.Add(-1)
+	}
 	if err := w.proxy.Process(ctx, net.Network_TCP, conn, w.dispatcher); err != nil {
 		newError("connection ends").Base(err).WriteToLog(session.ExportIDToError(ctx))
 	}
@@ -109,28 +232,82 @@ func (w *tcpWorker) Proxy() proxy.Inbound {
 	return w.proxy
 }
 
+// redirect lets the proxy receive connections for the worker, if the proxy is a TCPListenRedirector.
+func (w *tcpWorker) redirect() error {
+	redirector, ok := w.proxy.(proxy.TCPListenRedirector)
+	if !ok {
+		return nil
+	}
+	address, port, err := redirector.RedirectTCPListen(w.address, w.port)
+	if err != nil {
+		return newError("failed to redirect listening address").Base(err)
+	}
+	if port == 0 {
+		w.redirector = redirector
+		w.localAddress = address
+	} else {
+		w.address, w.port = address, port
+	}
+	return nil
+}
+
 func (w *tcpWorker) Start() error {
-	ctx := context.Background()
-	hub, err := internet.ListenTCP(ctx, w.address, w.port, w.stream, func(conn internet.Connection) {
+	ctx := internet.ContextWithListenerTag(context.Background(), w.tag)
+	address, port := w.address, w.port
+	if w.redirector != nil {
+		// The local port is picked when listening, so that no other process takes it in between.
+		// It is not passed to a new process in an upgrade, as the redirector doesn't go along.
+		ctx = context.Background()
+		address, port = w.localAddress, 0
+	}
+	hub, err := internet.ListenTCP(ctx, address, port, w.stream, func(conn internet.Connection) {
 		go w.callback(conn)
 	})
 	if err != nil {
 		return newError("failed to listen TCP on ", w.port).AtWarning().Base(err)
 	}
 	w.hub = hub
+
+	if w.redirector != nil {
+		localPort := net.DestinationFromAddr(hub.Addr()).Port
+		newError("redirecting TCP on ", w.address, ":", w.port, " to local port ", localPort).AtDebug().WriteToLog()
+		if err := w.redirector.ServeTCPListen(w.address, w.port, localPort); err != nil {
+			w.closeHub() // nolint: errcheck
+			return newError("failed to serve TCP on ", w.port).Base(err)
+		}
+	}
 	return nil
 }
 
+func (w *tcpWorker) closeHub() error {
+	var err error
+	w.hubClosed.Do(func() {
+		err = common.Close(w.hub)
+	})
+	return err
+}
+
+func (w *tcpWorker) Drain(ctx context.Context) error {
+	if w.hub == nil {
+		return nil
+	}
+	if err := w.closeHub(); err != nil {
+		return newError("failed to close listener on ", w.port).Base(err)
+	}
+	return w.conns.wait(ctx)
+}
+
 func (w *tcpWorker) Close() error {
 	var errors []interface{}
 	if w.hub != nil {
-		if err := common.Close(w.hub); err != nil {
+		if err := w.closeHub(); err != nil {
 			errors = append(errors, err)
 		}
 		if err := common.Close(w.proxy); err != nil {
 			errors = append(errors, err)
 		}
 	}
+	w.conns.close()
 	if len(errors) > 0 {
 		return newError("failed to close all resources").Base(newError(serial.Concat(errors...)))
 	}
@@ -144,7 +321,7 @@ func (w *tcpWorker) Port() net.Port {
 
 type udpConn struct {
 	lastActivityTime int64 // in seconds
-	reader           buf.Reader
+	reader           *pipe.Reader
 	writer           buf.Writer
 	output           func([]byte) (int, error)
 	remote           net.Addr
@@ -152,6 +329,8 @@ type udpConn struct {
 	done             *done.Instance
 	uplink           stats.Counter
 	downlink         stats.Counter
+	uplinkRate       stats.Rate
+	downlinkRate     stats.Rate
 }
 
 func (c *udpConn) updateActivity() {
@@ -169,6 +348,9 @@ func (c *udpConn) ReadMultiBuffer() (buf.MultiBuffer, error) {
 	if c.uplink != nil {
 		c.uplink.Add(int64(mb.Len()))
 	}
+	if c.uplinkRate != nil {
+		c.uplinkRate.Add(int64(mb.Len()))
+	}
 
 	return mb, nil
 }
@@ -183,6 +365,9 @@ func (c *udpConn) Write(buf []byte) (int, error) {
 	if c.downlink != nil {
 		c.downlink.Add(int64(n))
 	}
+	if c.downlinkRate != nil {
+		c.downlinkRate.Add(int64(n))
+	}
 	if err == nil {
 		c.updateActivity()
 	}
@@ -192,6 +377,8 @@ func (c *udpConn) Write(buf []byte) (int, error) {
 func (c *udpConn) Close() error {
 	common.Must(c.done.Close())
 	common.Must(common.Close(c.writer))
+	// Packets that are not read are released.
+	c.reader.Discard()
 	return nil
 }
 
@@ -232,11 +419,38 @@ type udpWorker struct {
 	dispatcher      routing.Dispatcher
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
+	uplinkRate      stats.Rate
+	downlinkRate    stats.Rate
+	connGauge       stats.Gauge
 
 	checker    *task.Periodic
 	activeConn map[connID]*udpConn
+	draining   bool
+	drained    chan struct{}
+
+	// successor listens on the same port, and takes the flows from new clients while this worker is draining.
+	successor *udpWorker
+}
+
+// handOver sets the worker that takes the flows from new clients when this worker is draining.
+func (w *udpWorker) handOver(successor *udpWorker) {
+	w.Lock()
+	w.successor = successor
+	w.Unlock()
+}
+
+// drainingTo returns the successor if the worker is draining, or nil otherwise.
+func (w *udpWorker) drainingTo() *udpWorker {
+	w.RLock()
+	defer w.RUnlock()
+
+	if !w.draining {
+		return nil
+	}
+	return w.successor
 }
 
+// getConnection returns the connection for the given id, or creates a new one. It returns nil if a new connection is needed while the worker is draining.
 func (w *udpWorker) getConnection(id connID) (*udpConn, bool) {
 	w.Lock()
 	defer w.Unlock()
@@ -244,6 +458,12 @@ func (w *udpWorker) getConnection(id connID) (*udpConn, bool) {
 	if conn, found := w.activeConn[id]; found && !conn.done.Done() {
 		return conn, true
 	}
+	if w.draining {
+		return nil, false
+	}
+	if rejectedByMemory(id.src) {
+		return nil, false
+	}
 
 	pReader, pWriter := pipe.New(pipe.DiscardOverflow(), pipe.WithSizeLimit(16*1024))
 	conn := &udpConn{
@@ -260,9 +480,11 @@ func (w *udpWorker) getConnection(id connID) (*udpConn, bool) {
 			IP:   w.address.IP(),
 			Port: int(w.port),
 		},
-		done:     done.New(),
-		uplink:   w.uplinkCounter,
-		downlink: w.downlinkCounter,
+		done:         done.New(),
+		uplink:       w.uplinkCounter,
+		downlink:     w.downlinkCounter,
+		uplinkRate:   w.uplinkRate,
+		downlinkRate: w.downlinkRate,
 	}
 	w.activeConn[id] = conn
 
@@ -278,6 +500,15 @@ func (w *udpWorker) callback(b *buf.Buffer, source net.Destination, originalDest
 		id.dest = originalDest
 	}
 	conn, existing := w.getConnection(id)
+	if conn == nil {
+		// The socket of a draining worker keeps receiving packets of new clients, as it shares the port with its successor.
+		if successor := w.drainingTo(); successor != nil {
+			successor.callback(b, source, originalDest)
+			return
+		}
+		b.Release()
+		return
+	}
 
 	// payload will be discarded in pipe is full.
 	conn.writer.WriteMultiBuffer(buf.MultiBuffer{b}) // nolint: errcheck
@@ -286,6 +517,11 @@ func (w *udpWorker) callback(b *buf.Buffer, source net.Destination, originalDest
 		common.Must(w.checker.Start())
 
 		go func() {
+			if w.connGauge != nil {
+				w.connGauge.Add(1)
+				defer w.connGauge.Add(-1)
+			}
+
 			ctx := context.Background()
 			sid := session.NewID()
 			ctx = session.ContextWithID(ctx, sid)
@@ -312,9 +548,18 @@ func (w *udpWorker) callback(b *buf.Buffer, source net.Destination, originalDest
 func (w *udpWorker) removeConn(id connID) {
 	w.Lock()
 	delete(w.activeConn, id)
+	w.checkDrained()
 	w.Unlock()
 }
 
+// checkDrained notifies Drain when all connections are gone. It must be called with the lock held.
+func (w *udpWorker) checkDrained() {
+	if len(w.activeConn) == 0 && w.drained != nil {
+		close(w.drained)
+		w.drained = nil
+	}
+}
+
 func (w *udpWorker) handlePackets() {
 	receive := w.hub.Receive()
 	for payload := range receive {
@@ -340,6 +585,7 @@ func (w *udpWorker) clean() error {
 
 	if len(w.activeConn) == 0 {
 		w.activeConn = make(map[connID]*udpConn, 16)
+		w.checkDrained()
 	}
 
 	return nil
@@ -347,7 +593,7 @@ func (w *udpWorker) clean() error {
 
 func (w *udpWorker) Start() error {
 	w.activeConn = make(map[connID]*udpConn, 16)
-	ctx := context.Background()
+	ctx := internet.ContextWithListenerTag(context.Background(), w.tag)
 	h, err := udp.ListenUDP(ctx, w.address, w.port, w.stream, udp.HubCapacity(256))
 	if err != nil {
 		return err
@@ -363,12 +609,40 @@ func (w *udpWorker) Start() error {
 	return nil
 }
 
+// Drain stops creating connections for new clients, while the existing ones keep working until they finish or time out. Flows from new clients are passed to the successor, if any.
+func (w *udpWorker) Drain(ctx context.Context) error {
+	w.Lock()
+	w.draining = true
+	if len(w.activeConn) == 0 {
+		w.Unlock()
+		return nil
+	}
+	if w.drained == nil {
+		w.drained = make(chan struct{})
+	}
+	drained := w.drained
+	w.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (w *udpWorker) Close() error {
 	w.Lock()
 	defer w.Unlock()
 
 	var errors []interface{}
 
+	for id, conn := range w.activeConn {
+		delete(w.activeConn, id)
+		conn.Close() // nolint: errcheck
+	}
+	w.checkDrained()
+
 	if w.hub != nil {
 		if err := w.hub.Close(); err != nil {
 			errors = append(errors, err)
@@ -0,0 +1,418 @@
+package inbound
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/bytespool"
+	"v2ray.com/core/common/log"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/routing"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/testing/servers/udp"
+	"v2ray.com/core/transport/internet"
+	_ "v2ray.com/core/transport/internet/tcp"
+)
+
+// testInbound is a proxy.Inbound that hands connections to a function.
+type testInbound struct {
+	process func(context.Context, net.Network, internet.Connection) error
+}
+
+func (*testInbound) Network() []net.Network {
+	return []net.Network{net.Network_TCP, net.Network_UDP}
+}
+
+func (p *testInbound) Process(ctx context.Context, network net.Network, conn internet.Connection, dispatcher routing.Dispatcher) error {
+	return p.process(ctx, network, conn)
+}
+
+func greet(ctx context.Context, network net.Network, conn internet.Connection) error {
+	_, err := conn.Write([]byte("hello"))
+	return err
+}
+
+// redirectingInbound forwards connections from its listener to the local port of the worker, as a SIP003 plugin does.
+type redirectingInbound struct {
+	testInbound
+	listener  net.Listener
+	localPort net.Port
+}
+
+func (p *redirectingInbound) RedirectTCPListen(address net.Address, port net.Port) (net.Address, net.Port, error) {
+	return net.LocalHostIP, 0, nil
+}
+
+func (p *redirectingInbound) ServeTCPListen(address net.Address, port net.Port, localPort net.Port) error {
+	listener, err := net.Listen("tcp", net.TCPDestination(address, port).NetAddr())
+	if err != nil {
+		return err
+	}
+	p.listener = listener
+	p.localPort = localPort
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			target, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, localPort).NetAddr())
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			go func() {
+				io.Copy(conn, target) // nolint: errcheck
+				conn.Close()
+			}()
+		}
+	}()
+	return nil
+}
+
+func (p *redirectingInbound) Close() error {
+	return p.listener.Close()
+}
+
+func newTestStream() *internet.MemoryStreamConfig {
+	stream, err := internet.ToMemoryStreamConfig(nil)
+	common.Must(err)
+	return stream
+}
+
+func readGreeting(t *testing.T, port net.Port) {
+	conn, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, port).NetAddr())
+	common.Must(err)
+	defer conn.Close()
+
+	b := make([]byte, 5)
+	common.Must2(io.ReadFull(conn, b))
+	if string(b) != "hello" {
+		t.Error("unexpected greeting: ", string(b))
+	}
+}
+
+func TestTCPWorkerRedirect(t *testing.T) {
+	p := &redirectingInbound{testInbound: testInbound{process: greet}}
+	port := tcp.PickPort()
+	w := &tcpWorker{
+		address: net.LocalHostIP,
+		port:    port,
+		proxy:   p,
+		stream:  newTestStream(),
+		ctx:     context.Background(),
+	}
+	common.Must(w.redirect())
+	common.Must(w.Start())
+
+	if w.Port() != port {
+		t.Error("worker port: ", w.Port(), ", want ", port)
+	}
+	if p.localPort == 0 || p.localPort == port {
+		t.Error("unexpected local port: ", p.localPort)
+	}
+	readGreeting(t, port)
+
+	// Closing the worker closes the redirecting proxy.
+	common.Must(w.Close())
+	if _, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, port).NetAddr()); err == nil {
+		t.Error("redirecting listener is not closed")
+	}
+}
+
+// canceledContext returns a context that is done, so that Drain returns right after it stops accepting new connections.
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+type testCloser struct {
+	closed bool
+}
+
+func (c *testCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestConnSet(t *testing.T) {
+	var s connSet
+	common.Must(s.wait(canceledContext()))
+
+	c1, c2 := new(testCloser), new(testCloser)
+	s.add(c1)
+	s.add(c2)
+	if err := s.wait(canceledContext()); err == nil {
+		t.Error("expected error when waiting for live connections")
+	}
+
+	s.remove(c1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.wait(context.Background())
+	}()
+	s.remove(c2)
+	if err := <-done; err != nil {
+		t.Error("failed to wait for connections: ", err)
+	}
+
+	s.add(c1)
+	s.add(c2)
+	go func() {
+		done <- s.wait(context.Background())
+	}()
+	s.close()
+	if err := <-done; err != nil {
+		t.Error("failed to wait for closed connections: ", err)
+	}
+	if !c1.closed || !c2.closed {
+		t.Error("connections are not closed")
+	}
+}
+
+func TestTCPWorkerDrain(t *testing.T) {
+	release := make(chan struct{})
+	port := tcp.PickPort()
+	w := &tcpWorker{
+		address: net.LocalHostIP,
+		port:    port,
+		proxy: &testInbound{process: func(ctx context.Context, network net.Network, conn internet.Connection) error {
+			if err := greet(ctx, network, conn); err != nil {
+				return err
+			}
+			<-release
+			return nil
+		}},
+		stream: newTestStream(),
+		ctx:    context.Background(),
+	}
+	common.Must(w.Start())
+	defer w.Close()
+
+	readGreeting(t, port)
+
+	if err := w.Drain(canceledContext()); err == nil {
+		t.Error("expected error when draining with a live connection")
+	}
+	if _, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, port).NetAddr()); err == nil {
+		t.Error("listener is not closed on drain")
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Drain(ctx); err != nil {
+		t.Error("failed to drain: ", err)
+	}
+}
+
+func newTestUDPWorker(port net.Port, reply string, release chan struct{}) *udpWorker {
+	return &udpWorker{
+		address: net.LocalHostIP,
+		port:    port,
+		proxy: &testInbound{process: func(ctx context.Context, network net.Network, conn internet.Connection) error {
+			mb, err := conn.(buf.Reader).ReadMultiBuffer()
+			if err != nil {
+				return err
+			}
+			buf.ReleaseMulti(mb)
+			if _, err := conn.Write([]byte(reply)); err != nil {
+				return err
+			}
+			<-release
+			return nil
+		}},
+		stream: newTestStream(),
+	}
+}
+
+// exchangeUDP sends a packet from a new client to the port, and returns the reply.
+func exchangeUDP(port net.Port) (string, error) {
+	conn, err := net.Dial("udp", net.UDPDestination(net.LocalHostIP, port).NetAddr())
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		return "", err
+	}
+	common.Must(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	b := make([]byte, 16)
+	n, err := conn.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return string(b[:n]), nil
+}
+
+func TestUDPWorkerDrain(t *testing.T) {
+	release := make(chan struct{})
+	port := udp.PickPort()
+	w := newTestUDPWorker(port, "old", release)
+	common.Must(w.Start())
+	defer w.Close()
+
+	if reply, err := exchangeUDP(port); err != nil || reply != "old" {
+		t.Fatal("unexpected reply: ", reply, err)
+	}
+
+	// Existing flows keep the worker from being drained, and new clients are dropped.
+	if err := w.Drain(canceledContext()); err == nil {
+		t.Error("expected error when draining with a live flow")
+	}
+	if reply, err := exchangeUDP(port); err == nil {
+		t.Error("new client is served by a draining worker: ", reply)
+	}
+
+	// New clients are served by the successor, whichever socket receives their packets.
+	successor := newTestUDPWorker(port, "new", release)
+	common.Must(successor.Start())
+	defer successor.Close()
+	w.handOver(successor)
+	for i := 0; i < 3; i++ {
+		if reply, err := exchangeUDP(port); err != nil || reply != "new" {
+			t.Error("unexpected reply: ", reply, err)
+		}
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Drain(ctx); err != nil {
+		t.Error("failed to drain: ", err)
+	}
+	if err := successor.Drain(ctx); err != nil {
+		t.Error("failed to drain successor: ", err)
+	}
+}
+
+// logCounter counts log messages that contain a string.
+type logCounter struct {
+	sync.Mutex
+	content string
+	count   int
+}
+
+func (c *logCounter) Handle(msg log.Message) {
+	if strings.Contains(msg.String(), c.content) {
+		c.Lock()
+		c.count++
+		c.Unlock()
+	}
+}
+
+func (c *logCounter) value() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.count
+}
+
+// underMemoryPressure charges the memory budget up to the level of rejecting new connections, and returns a function to relieve it.
+func underMemoryPressure(t *testing.T) func() {
+	base, _ := bytespool.Usage()
+	bytespool.SetBudget(base+1000, 0, 0, 0)
+	bytespool.Charge(1000)
+	if p := bytespool.CurrentPressure(); p != bytespool.PressureReject {
+		t.Fatal("expect pressure ", bytespool.PressureReject, ", but got ", p)
+	}
+	return func() {
+		bytespool.Charge(-1000)
+	}
+}
+
+func TestRejectedByMemory(t *testing.T) {
+	logs := &logCounter{content: "rejecting new connections"}
+	log.RegisterHandler(logs)
+	defer log.RegisterHandler(log.NewLogger(log.CreateStdoutLogWriter()))
+	defer bytespool.SetBudget(0, 0, 0, 0)
+
+	source := net.UDPDestination(net.LocalHostIP, 1234)
+	if rejectedByMemory(source) {
+		t.Error("rejected without memory pressure")
+	}
+
+	relieve := underMemoryPressure(t)
+	for i := 0; i < 10; i++ {
+		if !rejectedByMemory(source) {
+			t.Error("not rejected under memory pressure")
+		}
+	}
+	if n := logs.value(); n != 1 {
+		t.Error("expect the rejection logged once, but got ", n)
+	}
+
+	relieve()
+	if rejectedByMemory(source) {
+		t.Error("rejected after memory pressure drops")
+	}
+
+	// Rejection is logged again when the pressure rises again.
+	relieve = underMemoryPressure(t)
+	defer relieve()
+	if !rejectedByMemory(source) {
+		t.Error("not rejected under memory pressure")
+	}
+	if n := logs.value(); n != 2 {
+		t.Error("expect the rejection logged twice, but got ", n)
+	}
+}
+
+func TestWorkersRejectByMemory(t *testing.T) {
+	defer bytespool.SetBudget(0, 0, 0, 0)
+
+	port := tcp.PickPort()
+	tw := &tcpWorker{
+		address: net.LocalHostIP,
+		port:    port,
+		proxy:   &testInbound{process: greet},
+		stream:  newTestStream(),
+		ctx:     context.Background(),
+	}
+	common.Must(tw.Start())
+	defer tw.Close()
+
+	uw := newTestUDPWorker(udp.PickPort(), "reply", make(chan struct{}))
+	common.Must(uw.Start())
+	defer uw.Close()
+
+	relieve := underMemoryPressure(t)
+	defer relieve()
+
+	conn, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, port).NetAddr())
+	common.Must(err)
+	defer conn.Close()
+	common.Must(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	if n, err := conn.Read(make([]byte, 5)); err != io.EOF {
+		t.Error("expect new connection closed, but got ", n, " bytes, ", err)
+	}
+
+	if conn, _ := uw.getConnection(connID{src: net.UDPDestination(net.LocalHostIP, 1234)}); conn != nil {
+		t.Error("expect no connection for new client")
+	}
+}
+
+func TestUDPConnCloseReleasesPackets(t *testing.T) {
+	defer bytespool.SetBudget(0, 0, 0, 0)
+	bytespool.SetBudget(1<<40, 0, 0, 0)
+	uw := newTestUDPWorker(udp.PickPort(), "reply", make(chan struct{}))
+	uw.activeConn = make(map[connID]*udpConn)
+
+	base, _ := bytespool.Usage()
+	conn, _ := uw.getConnection(connID{src: net.UDPDestination(net.LocalHostIP, 1234)})
+	b := buf.New()
+	common.Must2(b.WriteString("ping"))
+	common.Must(conn.writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+
+	common.Must(conn.Close())
+	if usage, _ := bytespool.Usage(); usage != base {
+		t.Error("unexpected usage after closing connection: ", usage-base)
+	}
+}
@@ -2,6 +2,9 @@ package outbound
 
 import (
 	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
 
 	"v2ray.com/core"
 	"v2ray.com/core/app/proxyman"
@@ -9,6 +12,7 @@ import (
 	"v2ray.com/core/common/mux"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/session"
+	"v2ray.com/core/features/dns"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/features/policy"
 	"v2ray.com/core/features/stats"
@@ -44,27 +48,143 @@ func getStatCounter(v *core.Instance, tag string) (stats.Counter, stats.Counter)
 	return uplinkCounter, downlinkCounter
 }
 
+func getStatGauge(v *core.Instance, tag string) stats.Gauge {
+	policy := v.GetFeature(policy.ManagerType()).(policy.Manager)
+	if len(tag) > 0 && policy.ForSystem().Stats.OutboundConnection {
+		statsManager := v.GetFeature(stats.ManagerType()).(stats.Manager)
+		name := "outbound>>>" + tag + ">>>connection>>>active"
+		g, _ := stats.GetOrRegisterGauge(statsManager, name)
+		if g != nil {
+			return g
+		}
+	}
+	return nil
+}
+
+func getStatRate(v *core.Instance, tag string) (stats.Rate, stats.Rate) {
+	var uplinkRate stats.Rate
+	var downlinkRate stats.Rate
+
+	policy := v.GetFeature(policy.ManagerType()).(policy.Manager)
+	if len(tag) > 0 && policy.ForSystem().Stats.OutboundRate {
+		statsManager := v.GetFeature(stats.ManagerType()).(stats.Manager)
+		name := "outbound>>>" + tag + ">>>rate>>>uplink"
+		if r, _ := stats.GetOrRegisterRate(statsManager, name); r != nil {
+			uplinkRate = r
+		}
+		name = "outbound>>>" + tag + ">>>rate>>>downlink"
+		if r, _ := stats.GetOrRegisterRate(statsManager, name); r != nil {
+			downlinkRate = r
+		}
+	}
+
+	return uplinkRate, downlinkRate
+}
+
 // Handler is an implements of outbound.Handler.
 type Handler struct {
 	tag             string
+	config          *core.OutboundHandlerConfig
 	senderSettings  *proxyman.SenderConfig
 	streamSettings  *internet.MemoryStreamConfig
 	proxy           proxy.Outbound
 	outboundManager outbound.Manager
+	dns             dns.Client
 	mux             *mux.ClientManager
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
+	uplinkRate      stats.Rate
+	downlinkRate    stats.Rate
+	connGauge       stats.Gauge
+	links           linkSet
+}
+
+// linkSet tracks the links being dispatched by a Handler.
+type linkSet struct {
+	sync.Mutex
+	links map[*transport.Link]struct{}
+	empty chan struct{}
+}
+
+func (s *linkSet) add(link *transport.Link) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.links == nil {
+		s.links = make(map[*transport.Link]struct{})
+	}
+	s.links[link] = struct{}{}
+}
+
+func (s *linkSet) remove(link *transport.Link) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.links, link)
+	if len(s.links) == 0 && s.empty != nil {
+		close(s.empty)
+		s.empty = nil
+	}
+}
+
+// wait blocks until there is no link being dispatched, or ctx is done.
+func (s *linkSet) wait(ctx context.Context) error {
+	s.Lock()
+	if len(s.links) == 0 {
+		s.Unlock()
+		return nil
+	}
+	if s.empty == nil {
+		s.empty = make(chan struct{})
+	}
+	empty := s.empty
+	s.Unlock()
+
+	select {
+	case <-empty:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// interrupt interrupts all links being dispatched.
+func (s *linkSet) interrupt() {
+	s.Lock()
+	links := s.links
+	s.links = nil
+	if s.empty != nil {
+		close(s.empty)
+		s.empty = nil
+	}
+	s.Unlock()
+
+	for link := range links {
+		common.Interrupt(link.Reader)
+		common.Interrupt(link.Writer)
+	}
 }
 
 // NewHandler create a new Handler based on the given configuration.
 func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbound.Handler, error) {
 	v := core.MustFromContext(ctx)
 	uplinkCounter, downlinkCounter := getStatCounter(v, config.Tag)
+	uplinkRate, downlinkRate := getStatRate(v, config.Tag)
 	h := &Handler{
 		tag:             config.Tag,
+		config:          config,
 		outboundManager: v.GetFeature(outbound.ManagerType()).(outbound.Manager),
 		uplinkCounter:   uplinkCounter,
 		downlinkCounter: downlinkCounter,
+		uplinkRate:      uplinkRate,
+		downlinkRate:    downlinkRate,
+		connGauge:       getStatGauge(v, config.Tag),
+	}
+	if err := core.RequireFeatures(ctx, func(d dns.Client) error {
+		h.dns = d
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	if config.SenderSettings != nil {
@@ -79,6 +199,18 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 			if err != nil {
 				return nil, newError("failed to parse stream settings").Base(err).AtWarning()
 			}
+			if s.ViaCidr != nil {
+				if !s.ViaCidr.IsValid() {
+					return nil, newError("invalid CIDR to send through").AtWarning()
+				}
+				// Addresses in the CIDR are not necessarily assigned to local interfaces.
+				sockopt := new(internet.SocketConfig)
+				if mss.SocketSettings != nil {
+					sockopt = proto.Clone(mss.SocketSettings).(*internet.SocketConfig)
+				}
+				sockopt.FreeBind = true
+				mss.SocketSettings = sockopt
+			}
 			h.streamSettings = mss
 		default:
 			return nil, newError("settings is not SenderConfig")
@@ -137,6 +269,13 @@ func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 			common.Interrupt(link.Writer)
 		}
 	} else {
+		h.links.add(link)
+		defer h.links.remove(link)
+
+		if h.connGauge != nil {
+			h.connGauge.Add(1)
+			defer h.connGauge.Add(-1)
+		}
 		if err := h.proxy.Process(ctx, link, h); err != nil {
 			// Ensure outbound ray is properly closed.
 			newError("failed to process outbound traffic").Base(err).WriteToLog(session.ExportIDToError(ctx))
@@ -150,12 +289,44 @@ func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 
 // Address implements internet.Dialer.
 func (h *Handler) Address() net.Address {
-	if h.senderSettings == nil || h.senderSettings.Via == nil {
+	if h.senderSettings == nil {
+		return nil
+	}
+	if cidr := h.senderSettings.ViaCidr; cidr != nil {
+		// The address varies by connection, but is always in the same family as the CIDR.
+		return net.IPAddress(cidr.Ip)
+	}
+	if h.senderSettings.Via == nil {
 		return nil
 	}
 	return h.senderSettings.Via.AsAddress()
 }
 
+// via returns the address to send traffic of the connection through, or nil if not specified.
+func (h *Handler) via(ctx context.Context, dest net.Destination) net.Address {
+	cidr := h.senderSettings.ViaCidr
+	if cidr == nil {
+		if h.senderSettings.Via == nil {
+			return nil
+		}
+		return h.senderSettings.Via.AsAddress()
+	}
+
+	var key string
+	switch cidr.Strategy {
+	case proxyman.ViaCIDR_Destination:
+		if outbound := session.OutboundFromContext(ctx); outbound != nil && outbound.Target.IsValid() {
+			dest = outbound.Target
+		}
+		key = dest.Address.String()
+	case proxyman.ViaCIDR_User:
+		if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil {
+			key = inbound.User.Email
+		}
+	}
+	return cidr.Pick(key)
+}
+
 // Dial implements internet.Dialer.
 func (h *Handler) Dial(ctx context.Context, dest net.Destination) (internet.Connection, error) {
 	if h.senderSettings != nil {
@@ -164,9 +335,16 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (internet.Conn
 			handler := h.outboundManager.GetHandler(tag)
 			if handler != nil {
 				newError("proxying to ", tag, " for dest ", dest).AtDebug().WriteToLog(session.ExportIDToError(ctx))
-				ctx = session.ContextWithOutbound(ctx, &session.Outbound{
+				outbound := &session.Outbound{
 					Target: dest,
-				})
+				}
+				if o := session.OutboundFromContext(ctx); o != nil {
+					// Socket settings from routing apply to the connections of the proxying outbound.
+					outbound.Sockopt = o.Sockopt
+				}
+				ctx = session.ContextWithOutbound(ctx, outbound)
+				// Data from the inbound is wrapped by this outbound, so the proxying outbound must not splice the inbound connection.
+				ctx = proxy.ContextWithoutSplice(ctx)
 
 				opts := pipe.OptionsFromContext(ctx)
 				uplinkReader, uplinkWriter := pipe.New(opts...)
@@ -186,31 +364,41 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (internet.Conn
 			newError("failed to get outbound handler with tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
 		}
 
-		if h.senderSettings.Via != nil {
+		if via := h.via(ctx, dest); via != nil {
 			outbound := session.OutboundFromContext(ctx)
 			if outbound == nil {
 				outbound = new(session.Outbound)
 				ctx = session.ContextWithOutbound(ctx, outbound)
 			}
-			outbound.Gateway = h.senderSettings.Via.AsAddress()
+			outbound.Gateway = via
 		}
 	}
 
+	if h.dns != nil {
+		ctx = internet.ContextWithDNSClient(ctx, h.dns)
+	}
 	conn, err := internet.Dial(ctx, dest, h.streamSettings)
 	return h.getStatCouterConnection(conn), err
 }
 
 func (h *Handler) getStatCouterConnection(conn internet.Connection) internet.Connection {
-	if h.uplinkCounter != nil || h.downlinkCounter != nil {
+	if h.uplinkCounter != nil || h.downlinkCounter != nil || h.uplinkRate != nil || h.downlinkRate != nil {
 		return &internet.StatCouterConnection{
 			Connection:   conn,
 			ReadCounter:  h.downlinkCounter,
 			WriteCounter: h.uplinkCounter,
+			ReadRate:     h.downlinkRate,
+			WriteRate:    h.uplinkRate,
 		}
 	}
 	return conn
 }
 
+// Config returns the config that this Handler is created from.
+func (h *Handler) Config() *core.OutboundHandlerConfig {
+	return h.config
+}
+
 // GetOutbound implements proxy.GetOutbound.
 func (h *Handler) GetOutbound() proxy.Outbound {
 	return h.proxy
@@ -218,11 +406,20 @@ func (h *Handler) GetOutbound() proxy.Outbound {
 
 // Start implements common.Runnable.
 func (h *Handler) Start() error {
+	if runnable, ok := h.proxy.(common.Runnable); ok {
+		return runnable.Start()
+	}
 	return nil
 }
 
+// Drain implements common.Drainable. Connections over mux are not waited for.
+func (h *Handler) Drain(ctx context.Context) error {
+	return h.links.wait(ctx)
+}
+
 // Close implements common.Closable.
 func (h *Handler) Close() error {
+	h.links.interrupt()
 	common.Close(h.mux)
-	return nil
+	return common.Close(h.proxy)
 }
@@ -2,17 +2,31 @@ package outbound_test
 
 import (
 	"context"
+	"crypto/rand"
+	"io"
 	"testing"
+	"time"
 
 	"v2ray.com/core"
 	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
 	. "v2ray.com/core/app/proxyman/outbound"
 	"v2ray.com/core/app/stats"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/serial"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal"
 	"v2ray.com/core/features/outbound"
+	feature_stats "v2ray.com/core/features/stats"
+	"v2ray.com/core/proxy"
 	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/transport"
 	"v2ray.com/core/transport/internet"
+	_ "v2ray.com/core/transport/internet/tcp"
+	"v2ray.com/core/transport/pipe"
 )
 
 func TestInterfaces(t *testing.T) {
@@ -78,3 +92,138 @@ func TestOutboundWithStatCounter(t *testing.T) {
 		t.Errorf("Expected conn to be StatCouterConnection")
 	}
 }
+
+func TestOutboundViaCIDR(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	v, _ := core.New(&core.Config{})
+	v.AddFeature((outbound.Manager)(new(Manager)))
+	ctx := context.WithValue(context.Background(), v2rayKey, v)
+	h, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag: "tag",
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			ViaCidr: &proxyman.ViaCIDR{
+				Ip:       []byte{127, 0, 0, 0},
+				Prefix:   8,
+				Strategy: proxyman.ViaCIDR_Destination,
+			},
+		}),
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+	common.Must(err)
+
+	var sources []string
+	for i := 0; i < 2; i++ {
+		conn, err := h.(*Handler).Dial(ctx, dest)
+		common.Must(err)
+		source := conn.LocalAddr().(*net.TCPAddr).IP
+		if source[0] != 127 {
+			t.Error("source address not in CIDR: ", source)
+		}
+		sources = append(sources, source.String())
+		common.Must(conn.Close())
+	}
+	if sources[0] != sources[1] {
+		t.Error("expect the same source address for the same destination, but got ", sources)
+	}
+}
+
+// tcpPair returns both ends of a loopback TCP connection.
+func tcpPair() (*net.TCPConn, *net.TCPConn) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})
+	common.Must(err)
+	defer l.Close()
+
+	client, err := net.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+	common.Must(err)
+	server, err := l.AcceptTCP()
+	common.Must(err)
+	return client, server
+}
+
+func TestOutboundProxySettingsWithSplice(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&policy.Config{
+				System: &policy.SystemPolicy{
+					Stats: &policy.SystemPolicy_Stats{
+						OutboundUplink: true,
+					},
+				},
+			}),
+		},
+	}
+	v, err := core.New(config)
+	common.Must(err)
+	ctx := context.WithValue(context.Background(), v2rayKey, v)
+	m, err := New(ctx, &proxyman.OutboundConfig{})
+	common.Must(err)
+	common.Must(v.AddFeature(m))
+
+	outer, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag: "outer",
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			ProxySettings: &internet.ProxyConfig{Tag: "inner"},
+		}),
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+	common.Must(err)
+	inner, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag:           "inner",
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+	common.Must(err)
+	common.Must(m.AddHandler(ctx, outer))
+	common.Must(m.AddHandler(ctx, inner))
+
+	// The connection comes from an inbound that offers splicing, as dokodemo-door does.
+	client, in := tcpPair()
+	defer client.Close()
+	defer in.Close()
+	ctx = proxy.ContextWithSplice(session.ContextWithOutbound(ctx, &session.Outbound{Target: dest}))
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	timer := signal.CancelAfterInactivity(ctx, cancel, time.Minute)
+
+	uplinkReader, uplinkWriter := pipe.New(pipe.WithSizeLimit(512 * 1024))
+	downlinkReader, downlinkWriter := pipe.New(pipe.WithSizeLimit(512 * 1024))
+	go outer.Dispatch(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter})
+	go func() {
+		proxy.CopyFromConn(ctx, proxy.SpliceUplink, in, buf.NewReader(in), uplinkWriter, timer) // nolint: errcheck
+		common.Close(uplinkWriter)                                                              // nolint: errcheck
+	}()
+
+	payload := make([]byte, 1024*1024)
+	common.Must2(rand.Read(payload))
+	go func() {
+		common.Must2(client.Write(payload))
+		common.Must(client.CloseWrite())
+	}()
+	received := make([]byte, len(payload))
+	if _, err := io.ReadFull(&buf.BufferedReader{Reader: downlinkReader}, received); err != nil {
+		t.Fatal("failed to read echo: ", err)
+	}
+
+	// All data goes through the outer outbound, instead of being spliced to the connection of the inner one.
+	sm := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	if n := sm.GetCounter("outbound>>>outer>>>traffic>>>uplink").Value(); n != int64(len(payload)) {
+		t.Error("outer outbound uplink: ", n, ", want ", len(payload))
+	}
+}
@@ -4,13 +4,17 @@ package outbound
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"v2ray.com/core"
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/task"
 	"v2ray.com/core/features/outbound"
 )
 
@@ -21,12 +25,16 @@ type Manager struct {
 	taggedHandler    map[string]outbound.Handler
 	untaggedHandlers []outbound.Handler
 	running          bool
+
+	draining map[outbound.Handler]context.CancelFunc
+	drains   sync.WaitGroup
 }
 
 // New creates a new Manager.
 func New(ctx context.Context, config *proxyman.OutboundConfig) (*Manager, error) {
 	m := &Manager{
 		taggedHandler: make(map[string]outbound.Handler),
+		draining:      make(map[outbound.Handler]context.CancelFunc),
 	}
 	return m, nil
 }
@@ -58,8 +66,29 @@ func (m *Manager) Start() error {
 	return nil
 }
 
+// Drain implements common.Drainable. It waits for ongoing connections of all handlers to finish.
+func (m *Manager) Drain(ctx context.Context) error {
+	handlers := m.ListHandlers(ctx)
+	tasks := make([]func() error, 0, len(handlers))
+	for _, handler := range handlers {
+		handler := handler
+		tasks = append(tasks, func() error {
+			return common.Drain(ctx, handler)
+		})
+	}
+	return task.Run(ctx, tasks...)
+}
+
 // Close implements core.Feature
 func (m *Manager) Close() error {
+	// Handlers being drained are closed right away.
+	m.access.Lock()
+	for _, cancel := range m.draining {
+		cancel()
+	}
+	m.access.Unlock()
+	m.drains.Wait()
+
 	m.access.Lock()
 	defer m.access.Unlock()
 
@@ -137,6 +166,96 @@ func (m *Manager) RemoveHandler(ctx context.Context, tag string) error {
 	return nil
 }
 
+// DrainHandler implements outbound.Manager.
+func (m *Manager) DrainHandler(ctx context.Context, tag string, timeout time.Duration) error {
+	if tag == "" {
+		return common.ErrNoClue
+	}
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	handler, found := m.taggedHandler[tag]
+	if !found {
+		return common.ErrNoClue
+	}
+	delete(m.taggedHandler, tag)
+	if m.defaultHandler == handler {
+		m.defaultHandler = nil
+	}
+	m.drain(ctx, handler, timeout)
+	return nil
+}
+
+// ReplaceHandler implements outbound.Manager.
+func (m *Manager) ReplaceHandler(ctx context.Context, handler outbound.Handler, timeout time.Duration) error {
+	tag := handler.Tag()
+	if tag == "" {
+		return newError("can't replace handler without tag")
+	}
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	old, found := m.taggedHandler[tag]
+	if !found {
+		return newError("handler not found: ", tag)
+	}
+
+	if m.running {
+		if err := handler.Start(); err != nil {
+			common.Close(handler) // nolint: errcheck
+			return newError("failed to start handler ", tag).Base(err)
+		}
+	}
+	m.taggedHandler[tag] = handler
+	if m.defaultHandler == old {
+		m.defaultHandler = handler
+	}
+	m.drain(ctx, old, timeout)
+	return nil
+}
+
+// drain drains and closes the handler in background. It must be called with the lock held.
+func (m *Manager) drain(ctx context.Context, handler outbound.Handler, timeout time.Duration) {
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	m.draining[handler] = cancel
+	m.drains.Add(1)
+
+	go func() {
+		defer m.drains.Done()
+
+		if err := common.Drain(drainCtx, handler); err != nil {
+			newError("closing handler ", handler.Tag(), " with unfinished connections").Base(err).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+		}
+		cancel()
+		if err := handler.Close(); err != nil {
+			newError("failed to close handler ", handler.Tag()).Base(err).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+		}
+
+		m.access.Lock()
+		delete(m.draining, handler)
+		m.access.Unlock()
+	}()
+}
+
+// ListHandlers implements outbound.Manager.
+func (m *Manager) ListHandlers(ctx context.Context) []outbound.Handler {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	tags := make([]string, 0, len(m.taggedHandler))
+	for tag := range m.taggedHandler {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	handlers := make([]outbound.Handler, 0, len(m.taggedHandler)+len(m.untaggedHandlers))
+	for _, tag := range tags {
+		handlers = append(handlers, m.taggedHandler[tag])
+	}
+	handlers = append(handlers, m.untaggedHandlers...)
+	return handlers
+}
+
 // Select implements outbound.HandlerSelector.
 func (m *Manager) Select(selectors []string) []string {
 	m.access.RLock()
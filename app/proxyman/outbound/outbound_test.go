@@ -0,0 +1,138 @@
+package outbound_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common"
+	"v2ray.com/core/features/outbound"
+	"v2ray.com/core/transport"
+)
+
+type drainableHandler struct {
+	tag     string
+	drained chan struct{}
+	closed  chan struct{}
+}
+
+func newDrainableHandler(tag string) *drainableHandler {
+	return &drainableHandler{
+		tag:     tag,
+		drained: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (h *drainableHandler) Tag() string                                        { return h.tag }
+func (h *drainableHandler) Dispatch(ctx context.Context, link *transport.Link) {}
+func (h *drainableHandler) Start() error                                       { return nil }
+
+func (h *drainableHandler) Drain(ctx context.Context) error {
+	select {
+	case <-h.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *drainableHandler) Close() error {
+	close(h.closed)
+	return nil
+}
+
+func isClosed(h *drainableHandler, timeout time.Duration) bool {
+	select {
+	case <-h.closed:
+		return true
+	default:
+	}
+	select {
+	case <-h.closed:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestManagerReplaceHandler(t *testing.T) {
+	m, err := New(context.Background(), nil)
+	common.Must(err)
+	common.Must(m.Start())
+
+	old := newDrainableHandler("out")
+	common.Must(m.AddHandler(context.Background(), old))
+
+	h := newDrainableHandler("out")
+	common.Must(m.ReplaceHandler(context.Background(), h, time.Minute))
+
+	if m.GetHandler("out") != outbound.Handler(h) {
+		t.Error("handler is not replaced")
+	}
+	if m.GetDefaultHandler() != outbound.Handler(h) {
+		t.Error("default handler is not replaced")
+	}
+	if isClosed(old, 100*time.Millisecond) {
+		t.Fatal("old handler is closed before it is drained")
+	}
+
+	close(old.drained)
+	if !isClosed(old, time.Second) {
+		t.Fatal("old handler is not closed after it is drained")
+	}
+
+	if err := m.ReplaceHandler(context.Background(), newDrainableHandler("none"), time.Minute); err == nil {
+		t.Error("expect error when replacing a non-existing handler")
+	}
+
+	common.Must(m.Close())
+}
+
+func TestManagerDrainHandler(t *testing.T) {
+	m, err := New(context.Background(), nil)
+	common.Must(err)
+	common.Must(m.Start())
+
+	h := newDrainableHandler("out")
+	common.Must(m.AddHandler(context.Background(), h))
+	common.Must(m.DrainHandler(context.Background(), "out", 100*time.Millisecond))
+
+	if m.GetHandler("out") != nil {
+		t.Error("handler is not removed")
+	}
+	if !isClosed(h, time.Second) {
+		t.Fatal("handler is not closed after drain timeout")
+	}
+
+	slow := newDrainableHandler("slow")
+	common.Must(m.AddHandler(context.Background(), slow))
+	common.Must(m.DrainHandler(context.Background(), "slow", time.Hour))
+	common.Must(m.Close())
+	if !isClosed(slow, 0) {
+		t.Error("draining handler is not closed with manager")
+	}
+}
+
+func TestManagerDrain(t *testing.T) {
+	m, err := New(context.Background(), nil)
+	common.Must(err)
+	common.Must(m.Start())
+
+	h1 := newDrainableHandler("h1")
+	h2 := newDrainableHandler("h2")
+	common.Must(m.AddHandler(context.Background(), h1))
+	common.Must(m.AddHandler(context.Background(), h2))
+
+	close(h1.drained)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := m.Drain(ctx); err == nil {
+		t.Error("expect drain to time out")
+	}
+
+	close(h2.drained)
+	common.Must(m.Drain(context.Background()))
+	common.Must(m.Close())
+}
@@ -0,0 +1,46 @@
+// +build !confonly
+
+package command
+
+//go:generate go run v2ray.com/core/common/errors/errorgen
+
+import (
+	"context"
+	"time"
+
+	grpc "google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/common"
+)
+
+type ReloadServer struct {
+	V *core.Instance
+}
+
+// Reload implements ReloadService.
+func (s *ReloadServer) Reload(ctx context.Context, request *ReloadRequest) (*ReloadResponse, error) {
+	if err := s.V.Reload(request.Config, time.Duration(request.DrainTimeout)*time.Second); err != nil {
+		return nil, newError("failed to reload config").Base(err)
+	}
+	return &ReloadResponse{}, nil
+}
+
+func (s *ReloadServer) mustEmbedUnimplementedReloadServiceServer() {}
+
+type service struct {
+	v *core.Instance
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterReloadServiceServer(server, &ReloadServer{
+		V: s.v,
+	})
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := core.MustFromContext(ctx)
+		return &service{v: s}, nil
+	}))
+}
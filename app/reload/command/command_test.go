@@ -0,0 +1,132 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/inbound"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	. "v2ray.com/core/app/reload/command"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/features/inbound"
+	"v2ray.com/core/features/outbound"
+	feature_policy "v2ray.com/core/features/policy"
+	"v2ray.com/core/proxy/blackhole"
+	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+)
+
+func config(handshake uint32, port net.Port, outboundTags ...string) *core.Config {
+	c := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					0: {
+						Timeout: &policy.Policy_Timeout{
+							Handshake: &policy.Second{Value: handshake},
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				Tag: "in",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(port),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(net.LocalHostIP),
+					Port:     80,
+					Networks: []net.Network{net.Network_TCP},
+				}),
+			},
+		},
+	}
+	for _, tag := range outboundTags {
+		c.Outbound = append(c.Outbound, &core.OutboundHandlerConfig{
+			Tag:           tag,
+			ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		})
+	}
+	return c
+}
+
+func TestReload(t *testing.T) {
+	port := tcp.PickPort()
+	v, err := core.New(config(2, port, "direct", "old"))
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	ihm := v.GetFeature(inbound.ManagerType()).(inbound.Manager)
+	ohm := v.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	pm := v.GetFeature(feature_policy.ManagerType()).(feature_policy.Manager)
+	direct := ohm.GetHandler("direct")
+	in, err := ihm.GetHandler(context.Background(), "in")
+	common.Must(err)
+
+	server := &ReloadServer{V: v}
+	common.Must2(server.Reload(context.Background(), &ReloadRequest{
+		Config: config(3, port, "direct", "new"),
+	}))
+
+	if h := ohm.GetHandler("direct"); h != direct {
+		t.Error("unchanged outbound is replaced")
+	}
+	if h, _ := ihm.GetHandler(context.Background(), "in"); h != in {
+		t.Error("unchanged inbound is replaced")
+	}
+	if ohm.GetHandler("old") != nil {
+		t.Error("removed outbound still exists")
+	}
+	if ohm.GetHandler("new") == nil {
+		t.Error("added outbound not found")
+	}
+	if p := pm.ForLevel(0); p.Timeouts.Handshake != 3*time.Second {
+		t.Error("expect 3 sec handshake timeout, but got ", p.Timeouts.Handshake)
+	}
+
+	invalid := config(4, port, "direct", "new")
+	invalid.Outbound = append(invalid.Outbound, &core.OutboundHandlerConfig{
+		Tag:           "invalid",
+		ProxySettings: serial.ToTypedMessage(&dokodemo.Config{}),
+	})
+	invalid.Inbound[0].ProxySettings = serial.ToTypedMessage(&blackhole.Config{})
+	if _, err := server.Reload(context.Background(), &ReloadRequest{Config: invalid}); err == nil {
+		t.Fatal("expect error when reloading invalid config")
+	}
+
+	if ohm.GetHandler("invalid") != nil {
+		t.Error("invalid outbound is added")
+	}
+	if h, _ := ihm.GetHandler(context.Background(), "in"); h != in {
+		t.Error("inbound is replaced by invalid config")
+	}
+	if p := pm.ForLevel(0); p.Timeouts.Handshake != 3*time.Second {
+		t.Error("policy is changed by invalid config")
+	}
+
+	changed := config(3, port, "direct", "new")
+	changed.Inbound[0].ProxySettings = serial.ToTypedMessage(&dokodemo.Config{
+		Address:  net.NewIPOrDomain(net.LocalHostIP),
+		Port:     443,
+		Networks: []net.Network{net.Network_TCP},
+	})
+	common.Must2(server.Reload(context.Background(), &ReloadRequest{Config: changed}))
+	if h, _ := ihm.GetHandler(context.Background(), "in"); h == in {
+		t.Error("changed inbound is not replaced")
+	}
+}
@@ -0,0 +1,97 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package command
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion7
+
+// ReloadServiceClient is the client API for ReloadService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ReloadServiceClient interface {
+	Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error)
+}
+
+type reloadServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReloadServiceClient(cc grpc.ClientConnInterface) ReloadServiceClient {
+	return &reloadServiceClient{cc}
+}
+
+func (c *reloadServiceClient) Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error) {
+	out := new(ReloadResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.reload.command.ReloadService/Reload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReloadServiceServer is the server API for ReloadService service.
+// All implementations must embed UnimplementedReloadServiceServer
+// for forward compatibility
+type ReloadServiceServer interface {
+	Reload(context.Context, *ReloadRequest) (*ReloadResponse, error)
+	mustEmbedUnimplementedReloadServiceServer()
+}
+
+// UnimplementedReloadServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedReloadServiceServer struct {
+}
+
+func (UnimplementedReloadServiceServer) Reload(context.Context, *ReloadRequest) (*ReloadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reload not implemented")
+}
+func (UnimplementedReloadServiceServer) mustEmbedUnimplementedReloadServiceServer() {}
+
+// UnsafeReloadServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReloadServiceServer will
+// result in compilation errors.
+type UnsafeReloadServiceServer interface {
+	mustEmbedUnimplementedReloadServiceServer()
+}
+
+func RegisterReloadServiceServer(s *grpc.Server, srv ReloadServiceServer) {
+	s.RegisterService(&_ReloadService_serviceDesc, srv)
+}
+
+func _ReloadService_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReloadServiceServer).Reload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.reload.command.ReloadService/Reload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReloadServiceServer).Reload(ctx, req.(*ReloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ReloadService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "v2ray.core.app.reload.command.ReloadService",
+	HandlerType: (*ReloadServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Reload",
+			Handler:    _ReloadService_Reload_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "app/reload/command/config.proto",
+}
@@ -4,6 +4,7 @@ package router
 
 import (
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/features/routing"
 )
@@ -51,6 +52,7 @@ type Rule struct {
 	Tag       string
 	Balancer  *Balancer
 	Condition Condition
+	Sockopt   *session.Sockopt
 }
 
 func (r *Rule) GetTag() (string, error) {
@@ -65,6 +67,22 @@ func (r *Rule) Apply(ctx routing.Context) bool {
 	return r.Condition.Apply(ctx)
 }
 
+// Build builds the socket attributes into the socket settings of sessions.
+func (a *SocketAttributes) Build() (*session.Sockopt, error) {
+	sockopt := &session.Sockopt{
+		Mark:      a.Mark,
+		Interface: a.Interface,
+	}
+	if a.Source != nil {
+		source := a.Source.AsAddress()
+		if !source.Family().IsIP() {
+			return nil, newError("source address must be an IP: ", source)
+		}
+		sockopt.Source = source
+	}
+	return sockopt, nil
+}
+
 func (rr *RoutingRule) BuildCondition() (Condition, error) {
 	conds := NewConditionChan()
 
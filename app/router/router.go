@@ -6,9 +6,11 @@ package router
 
 import (
 	"context"
+	"sync/atomic"
 
 	"v2ray.com/core"
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/session"
 	"v2ray.com/core/features/dns"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/features/routing"
@@ -21,6 +23,10 @@ type Router struct {
 	rules          []*Rule
 	balancers      map[string]*Balancer
 	dns            dns.Client
+	ohm            outbound.Manager
+
+	// reloaded holds the Router built from the latest reloaded config.
+	reloaded atomic.Value
 }
 
 // Route is an implementation of routing.Route.
@@ -28,12 +34,14 @@ type Route struct {
 	routing.Context
 	outboundGroupTags []string
 	outboundTag       string
+	sockopt           *session.Sockopt
 }
 
 // Init initializes the Router.
 func (r *Router) Init(config *Config, d dns.Client, ohm outbound.Manager) error {
 	r.domainStrategy = config.DomainStrategy
 	r.dns = d
+	r.ohm = ohm
 
 	r.balancers = make(map[string]*Balancer, len(config.BalancingRule))
 	for _, rule := range config.BalancingRule {
@@ -54,6 +62,13 @@ func (r *Router) Init(config *Config, d dns.Client, ohm outbound.Manager) error
 			Condition: cond,
 			Tag:       rule.GetTag(),
 		}
+		if rule.SocketAttributes != nil {
+			sockopt, err := rule.SocketAttributes.Build()
+			if err != nil {
+				return err
+			}
+			rr.Sockopt = sockopt
+		}
 		btag := rule.GetBalancingTag()
 		if len(btag) > 0 {
 			brule, found := r.balancers[btag]
@@ -68,9 +83,32 @@ func (r *Router) Init(config *Config, d dns.Client, ohm outbound.Manager) error
 	return nil
 }
 
+// current returns the Router that takes effect.
+func (r *Router) current() *Router {
+	if reloaded, ok := r.reloaded.Load().(*Router); ok {
+		return reloaded
+	}
+	return r
+}
+
+// PrepareReload implements features.Reloadable.
+func (r *Router) PrepareReload(config interface{}) (func(), func(), error) {
+	c, ok := config.(*Config)
+	if !ok {
+		return nil, nil, newError("not a router config")
+	}
+	reloaded := new(Router)
+	if err := reloaded.Init(c, r.dns, r.ohm); err != nil {
+		return nil, nil, err
+	}
+	return func() {
+		r.reloaded.Store(reloaded)
+	}, nil, nil
+}
+
 // PickRoute implements routing.Router.
 func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
-	rule, ctx, err := r.pickRouteInternal(ctx)
+	rule, ctx, err := r.current().pickRouteInternal(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +116,7 @@ func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Route{Context: ctx, outboundTag: tag}, nil
+	return &Route{Context: ctx, outboundTag: tag, sockopt: rule.Sockopt}, nil
 }
 
 func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context, error) {
@@ -133,6 +171,11 @@ func (r *Route) GetOutboundTag() string {
 	return r.outboundTag
 }
 
+// GetSockopt implements routing.SockoptRoute.
+func (r *Route) GetSockopt() *session.Sockopt {
+	return r.sockopt
+}
+
 func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		r := new(Router)
@@ -14,6 +14,7 @@ import (
 	"v2ray.com/core"
 	"v2ray.com/core/app/stats"
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/bytespool"
 	"v2ray.com/core/common/strmatcher"
 	feature_stats "v2ray.com/core/features/stats"
 )
@@ -82,11 +83,96 @@ func (s *statsServer) QueryStats(ctx context.Context, request *QueryStatsRequest
 	return response, nil
 }
 
+func (s *statsServer) QueryGauges(ctx context.Context, request *QueryStatsRequest) (*QueryStatsResponse, error) {
+	matcher, err := strmatcher.Substr.New(request.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, ok := s.stats.(*stats.Manager)
+	if !ok {
+		return nil, newError("QueryGauges only works its own stats.Manager.")
+	}
+
+	response := &QueryStatsResponse{}
+	manager.VisitGauges(func(name string, g feature_stats.Gauge) bool {
+		if matcher.Match(name) {
+			response.Stat = append(response.Stat, &Stat{
+				Name:  name,
+				Value: g.Value(),
+			})
+		}
+		return true
+	})
+
+	return response, nil
+}
+
+func (s *statsServer) QueryRates(ctx context.Context, request *QueryStatsRequest) (*QueryStatsResponse, error) {
+	matcher, err := strmatcher.Substr.New(request.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, ok := s.stats.(*stats.Manager)
+	if !ok {
+		return nil, newError("QueryRates only works its own stats.Manager.")
+	}
+
+	response := &QueryStatsResponse{}
+	manager.VisitRates(func(name string, r feature_stats.Rate) bool {
+		if matcher.Match(name) {
+			response.Stat = append(response.Stat, &Stat{
+				Name:  name,
+				Value: r.Value(),
+			})
+		}
+		return true
+	})
+
+	return response, nil
+}
+
+func (s *statsServer) QueryHistograms(ctx context.Context, request *QueryStatsRequest) (*QueryHistogramsResponse, error) {
+	matcher, err := strmatcher.Substr.New(request.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, ok := s.stats.(*stats.Manager)
+	if !ok {
+		return nil, newError("QueryHistograms only works its own stats.Manager.")
+	}
+
+	response := &QueryHistogramsResponse{}
+	manager.VisitHistograms(func(name string, h feature_stats.Histogram) bool {
+		if matcher.Match(name) {
+			var snapshot feature_stats.HistogramSnapshot
+			if request.Reset_ {
+				snapshot = h.Reset()
+			} else {
+				snapshot = h.Snapshot()
+			}
+			response.Histogram = append(response.Histogram, &Histogram{
+				Name:    name,
+				Bounds:  snapshot.Bounds,
+				Buckets: snapshot.Buckets,
+				Count:   snapshot.Count,
+				Sum:     snapshot.Sum,
+			})
+		}
+		return true
+	})
+
+	return response, nil
+}
+
 func (s *statsServer) GetSysStats(ctx context.Context, request *SysStatsRequest) (*SysStatsResponse, error) {
 	var rtm runtime.MemStats
 	runtime.ReadMemStats(&rtm)
 
 	uptime := time.Since(s.startTime)
+	memoryUsage, memoryLimit := bytespool.Usage()
 
 	response := &SysStatsResponse{
 		Uptime:       uint32(uptime.Seconds()),
@@ -99,6 +185,10 @@ func (s *statsServer) GetSysStats(ctx context.Context, request *SysStatsRequest)
 		LiveObjects:  rtm.Mallocs - rtm.Frees,
 		NumGC:        rtm.NumGC,
 		PauseTotalNs: rtm.PauseTotalNs,
+
+		MemoryUsage:    uint64(memoryUsage),
+		MemoryLimit:    uint64(memoryLimit),
+		MemoryPressure: uint32(bytespool.CurrentPressure()),
 	}
 
 	return response, nil
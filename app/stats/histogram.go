@@ -0,0 +1,84 @@
+// +build !confonly
+
+package stats
+
+import (
+	"sort"
+	"sync"
+
+	"v2ray.com/core/features/stats"
+)
+
+// Histogram is an implementation of stats.Histogram.
+type Histogram struct {
+	access  sync.Mutex
+	bounds  []int64
+	buckets []int64
+	count   int64
+	sum     int64
+}
+
+// NewHistogram creates a Histogram with the given bucket bounds. Bounds are sorted and deduplicated.
+func NewHistogram(bounds []int64) *Histogram {
+	b := make([]int64, 0, len(bounds))
+	for _, v := range bounds {
+		b = append(b, v)
+	}
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	uniq := b[:0]
+	for i, v := range b {
+		if i == 0 || v != b[i-1] {
+			uniq = append(uniq, v)
+		}
+	}
+	return &Histogram{
+		bounds:  uniq,
+		buckets: make([]int64, len(uniq)+1),
+	}
+}
+
+// Observe implements stats.Histogram.
+func (h *Histogram) Observe(value int64) {
+	idx := sort.Search(len(h.bounds), func(i int) bool { return value <= h.bounds[i] })
+
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	h.buckets[idx]++
+	h.count++
+	h.sum += value
+}
+
+func (h *Histogram) snapshot() stats.HistogramSnapshot {
+	s := stats.HistogramSnapshot{
+		Bounds:  make([]int64, len(h.bounds)),
+		Buckets: make([]int64, len(h.buckets)),
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+	copy(s.Bounds, h.bounds)
+	copy(s.Buckets, h.buckets)
+	return s
+}
+
+// Snapshot implements stats.Histogram.
+func (h *Histogram) Snapshot() stats.HistogramSnapshot {
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	return h.snapshot()
+}
+
+// Reset implements stats.Histogram.
+func (h *Histogram) Reset() stats.HistogramSnapshot {
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	s := h.snapshot()
+	for i := range h.buckets {
+		h.buckets[i] = 0
+	}
+	h.count = 0
+	h.sum = 0
+	return s
+}
@@ -0,0 +1,65 @@
+package stats_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "v2ray.com/core/app/stats"
+	"v2ray.com/core/common"
+	"v2ray.com/core/features/stats"
+)
+
+func TestStatsHistogram(t *testing.T) {
+	raw, err := common.CreateObject(context.Background(), &Config{})
+	common.Must(err)
+
+	m := raw.(stats.Manager)
+	h, err := m.RegisterHistogram("test.histogram", []int64{100, 10, 10})
+	common.Must(err)
+
+	for _, v := range []int64{1, 10, 11, 100, 1000} {
+		h.Observe(v)
+	}
+
+	expected := stats.HistogramSnapshot{
+		Bounds:  []int64{10, 100},
+		Buckets: []int64{2, 2, 1},
+		Count:   5,
+		Sum:     1122,
+	}
+	if r := cmp.Diff(h.Reset(), expected); r != "" {
+		t.Error(r)
+	}
+
+	if s := h.Snapshot(); s.Count != 0 || s.Sum != 0 || s.Buckets[2] != 0 {
+		t.Error("unexpected snapshot after reset: ", s)
+	}
+}
+
+func TestStatsGaugeAndRate(t *testing.T) {
+	raw, err := common.CreateObject(context.Background(), &Config{RateWindow: 2})
+	common.Must(err)
+
+	m := raw.(stats.Manager)
+	g, err := m.RegisterGauge("test.gauge")
+	common.Must(err)
+
+	g.Add(2)
+	if v := g.Add(-1); v != 1 {
+		t.Error("unexpected Add(-1) return: ", v, ", wanted ", 1)
+	}
+
+	r, err := m.RegisterRate("test.rate")
+	common.Must(err)
+
+	r.Add(1000)
+	if v := r.Value(); v != 500 {
+		t.Error("unexpected rate value: ", v, ", wanted ", 500)
+	}
+
+	if _, err := m.RegisterRate("test.rate"); err == nil {
+		t.Error("expected error on duplicated rate")
+	}
+}
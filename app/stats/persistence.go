@@ -0,0 +1,193 @@
+// +build !confonly
+
+package stats
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/strmatcher"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/policy"
+	"v2ray.com/core/features/stats"
+)
+
+const defaultPersistenceInterval = 300
+
+// snapshot is the content of a persistence file.
+type snapshot struct {
+	Counters map[string]int64         `json:"counters"`
+	Quotas   map[string]quotaSnapshot `json:"quotas,omitempty"`
+}
+
+// quotaSnapshot is the saved state of a policy.QuotaUsage.
+type quotaSnapshot struct {
+	Used   int64 `json:"used"`
+	Period int64 `json:"period"`
+}
+
+// persistence periodically stores counter values of a Manager into a file, and restores them on start.
+// Quota usage of users is stored along with counters, so that quotas survive restarts too.
+type persistence struct {
+	file     string
+	matchers []strmatcher.Matcher
+	task     *task.Periodic
+
+	access  sync.Mutex
+	tracker policy.QuotaTracker
+	quotas  map[string]quotaSnapshot // restored quota usage not yet handed to tracker
+}
+
+func newPersistence(m *Manager, config *PersistenceConfig) (*persistence, error) {
+	if len(config.File) == 0 {
+		return nil, newError("persistence file not specified")
+	}
+
+	p := &persistence{
+		file: config.File,
+	}
+	for _, pattern := range config.Pattern {
+		matcher, err := strmatcher.Substr.New(pattern)
+		if err != nil {
+			return nil, newError("invalid persistence pattern: ", pattern).Base(err)
+		}
+		p.matchers = append(p.matchers, matcher)
+	}
+
+	interval := config.Interval
+	if interval == 0 {
+		interval = defaultPersistenceInterval
+	}
+	p.task = &task.Periodic{
+		Interval: time.Second * time.Duration(interval),
+		Execute: func() error {
+			if err := p.save(m); err != nil {
+				newError("failed to save stats snapshot").Base(err).AtWarning().WriteToLog()
+			}
+			return nil
+		},
+	}
+	return p, nil
+}
+
+func (p *persistence) match(name string) bool {
+	if len(p.matchers) == 0 {
+		return true
+	}
+	for _, matcher := range p.matchers {
+		if matcher.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the snapshot file and registers all counters in it. A missing file is not an error.
+func (p *persistence) load(m *Manager) error {
+	content, err := ioutil.ReadFile(p.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var values snapshot
+	if err := json.Unmarshal(content, &values); err != nil {
+		return newError("invalid stats snapshot ", p.file).Base(err)
+	}
+
+	p.access.Lock()
+	p.quotas = values.Quotas
+	p.access.Unlock()
+
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	for name, value := range values.Counters {
+		if !p.match(name) {
+			continue
+		}
+		c, found := m.counters[name]
+		if !found {
+			c = new(Counter)
+			m.counters[name] = c
+		}
+		c.Set(value)
+	}
+	newError("restored ", len(values.Counters), " counters and ", len(values.Quotas), " quotas from ", p.file).AtInfo().WriteToLog()
+	return nil
+}
+
+// setQuotaTracker restores saved quota usage into tracker, and saves usage from tracker afterwards.
+func (p *persistence) setQuotaTracker(tracker policy.QuotaTracker) {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	for email, q := range p.quotas {
+		tracker.QuotaUsage(email).Restore(q.Used, q.Period)
+	}
+	p.quotas = nil
+	p.tracker = tracker
+}
+
+// quotaSnapshots returns quota usage to be saved. Restored usage is kept as is if there is no tracker.
+func (p *persistence) quotaSnapshots() map[string]quotaSnapshot {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	if p.tracker == nil {
+		return p.quotas
+	}
+	quotas := make(map[string]quotaSnapshot)
+	p.tracker.VisitQuotaUsage(func(email string, u *policy.QuotaUsage) bool {
+		quotas[email] = quotaSnapshot{
+			Used:   u.Used(),
+			Period: u.PeriodStart(),
+		}
+		return true
+	})
+	return quotas
+}
+
+// save writes matched counters into the snapshot file atomically, by writing to a temporary file in the same directory and renaming it.
+func (p *persistence) save(m *Manager) error {
+	values := snapshot{
+		Counters: make(map[string]int64),
+		Quotas:   p.quotaSnapshots(),
+	}
+	m.VisitCounters(func(name string, c stats.Counter) bool {
+		if p.match(name) {
+			values.Counters[name] = c.Value()
+		}
+		return true
+	})
+
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p.file), filepath.Base(p.file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.file)
+}
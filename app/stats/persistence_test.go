@@ -0,0 +1,114 @@
+package stats_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/inbound"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	. "v2ray.com/core/app/stats"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/serial"
+	feature_policy "v2ray.com/core/features/policy"
+	"v2ray.com/core/features/stats"
+)
+
+func TestStatsPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-stats")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		Persistence: &PersistenceConfig{
+			File:    filepath.Join(dir, "stats.json"),
+			Pattern: []string{"user>>>"},
+		},
+	}
+
+	raw, err := common.CreateObject(context.Background(), config)
+	common.Must(err)
+	m := raw.(stats.Manager)
+	common.Must(m.Start())
+
+	c1, err := m.RegisterCounter("user>>>test>>>traffic>>>uplink")
+	common.Must(err)
+	c1.Add(100)
+	c2, err := m.RegisterCounter("inbound>>>test>>>traffic>>>uplink")
+	common.Must(err)
+	c2.Add(200)
+
+	common.Must(m.Close())
+
+	raw, err = common.CreateObject(context.Background(), config)
+	common.Must(err)
+	m = raw.(stats.Manager)
+
+	c := m.GetCounter("user>>>test>>>traffic>>>uplink")
+	if c == nil || c.Value() != 100 {
+		t.Error("counter not restored: ", c)
+	}
+	if m.GetCounter("inbound>>>test>>>traffic>>>uplink") != nil {
+		t.Error("unexpected restored counter not matching pattern")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	common.Must(err)
+	if len(files) != 1 {
+		t.Error("unexpected files in snapshot directory: ", len(files))
+	}
+}
+
+func TestQuotaPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-stats")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+			serial.ToTypedMessage(&Config{
+				Persistence: &PersistenceConfig{
+					File:    filepath.Join(dir, "stats.json"),
+					Pattern: []string{"user>>>"},
+				},
+			}),
+		},
+	}
+
+	quotaUsage := func(v *core.Instance, email string) *feature_policy.QuotaUsage {
+		return feature_policy.GetQuotaUsage(v.GetFeature(feature_policy.ManagerType()).(feature_policy.Manager), email)
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	quotaUsage(v, "test").Reset(time.Unix(1000, 0))
+	quotaUsage(v, "test").Add(1500)
+	common.Must(v.Close())
+
+	v, err = core.New(config)
+	common.Must(err)
+	usage := quotaUsage(v, "test")
+	if usage.Used() != 1500 || usage.PeriodStart() != 1000 {
+		t.Error("quota usage not restored: ", usage.Used(), " ", usage.PeriodStart())
+	}
+	usage.Add(500)
+	common.Must(v.Close())
+
+	v, err = core.New(config)
+	common.Must(err)
+	if used := quotaUsage(v, "test").Used(); used != 2000 {
+		t.Error("quota usage not saved on close: ", used)
+	}
+}
@@ -0,0 +1,70 @@
+// +build !confonly
+
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// Rate is an implementation of stats.Rate.
+// It keeps one slot per second for a fixed window, and reports the average over the window.
+type Rate struct {
+	access sync.Mutex
+	slots  []int64
+	last   int64 // unix second of the latest recorded slot
+}
+
+// NewRate creates a Rate over a window of the given seconds.
+func NewRate(window uint32) *Rate {
+	if window == 0 {
+		window = 1
+	}
+	return &Rate{
+		slots: make([]int64, window),
+	}
+}
+
+// advance clears all slots between the last recorded second and sec. Caller must hold the lock.
+func (r *Rate) advance(sec int64) {
+	if sec <= r.last {
+		return
+	}
+	window := int64(len(r.slots))
+	if sec-r.last >= window {
+		for i := range r.slots {
+			r.slots[i] = 0
+		}
+	} else {
+		for s := r.last + 1; s <= sec; s++ {
+			r.slots[s%window] = 0
+		}
+	}
+	r.last = sec
+}
+
+// Add implements stats.Rate.
+func (r *Rate) Add(delta int64) {
+	sec := time.Now().Unix()
+
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	r.advance(sec)
+	r.slots[sec%int64(len(r.slots))] += delta
+}
+
+// Value implements stats.Rate.
+func (r *Rate) Value() int64 {
+	sec := time.Now().Unix()
+
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	r.advance(sec)
+	var sum int64
+	for _, v := range r.slots {
+		sum += v
+	}
+	return sum / int64(len(r.slots))
+}
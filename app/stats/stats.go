@@ -8,24 +8,60 @@ import (
 	"context"
 	"sync"
 
+	"v2ray.com/core"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/errors"
+	"v2ray.com/core/features/policy"
 	"v2ray.com/core/features/stats"
 )
 
 // Manager is an implementation of stats.Manager.
 type Manager struct {
-	access   sync.RWMutex
-	counters map[string]*Counter
-	channels map[string]*Channel
-	running  bool
+	access     sync.RWMutex
+	counters   map[string]*Counter
+	gauges     map[string]*Gauge
+	rates      map[string]*Rate
+	histograms map[string]*Histogram
+	channels   map[string]*Channel
+	rateWindow uint32
+	persist    *persistence
+	running    bool
 }
 
+const defaultRateWindow = 10
+
 // NewManager creates an instance of Statistics Manager.
 func NewManager(ctx context.Context, config *Config) (*Manager, error) {
 	m := &Manager{
-		counters: make(map[string]*Counter),
-		channels: make(map[string]*Channel),
+		counters:   make(map[string]*Counter),
+		gauges:     make(map[string]*Gauge),
+		rates:      make(map[string]*Rate),
+		histograms: make(map[string]*Histogram),
+		channels:   make(map[string]*Channel),
+		rateWindow: defaultRateWindow,
+	}
+	if config.RateWindow > 0 {
+		m.rateWindow = config.RateWindow
+	}
+	if config.Persistence != nil {
+		p, err := newPersistence(m, config.Persistence)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.load(m); err != nil {
+			return nil, newError("failed to restore stats snapshot").Base(err)
+		}
+		m.persist = p
+
+		if v := core.FromContext(ctx); v != nil {
+			if err := v.RequireFeatures(func(pm policy.Manager) {
+				if tracker, ok := pm.(policy.QuotaTracker); ok {
+					p.setQuotaTracker(tracker)
+				}
+			}); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return m, nil
@@ -85,6 +121,153 @@ func (m *Manager) VisitCounters(visitor func(string, stats.Counter) bool) {
 	}
 }
 
+// RegisterGauge implements stats.Manager.
+func (m *Manager) RegisterGauge(name string) (stats.Gauge, error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	if _, found := m.gauges[name]; found {
+		return nil, newError("Gauge ", name, " already registered.")
+	}
+	newError("create new gauge ", name).AtDebug().WriteToLog()
+	g := new(Gauge)
+	m.gauges[name] = g
+	return g, nil
+}
+
+// UnregisterGauge implements stats.Manager.
+func (m *Manager) UnregisterGauge(name string) error {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	if _, found := m.gauges[name]; found {
+		newError("remove gauge ", name).AtDebug().WriteToLog()
+		delete(m.gauges, name)
+	}
+	return nil
+}
+
+// GetGauge implements stats.Manager.
+func (m *Manager) GetGauge(name string) stats.Gauge {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	if g, found := m.gauges[name]; found {
+		return g
+	}
+	return nil
+}
+
+// VisitGauges calls visitor function on all managed gauges.
+func (m *Manager) VisitGauges(visitor func(string, stats.Gauge) bool) {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	for name, g := range m.gauges {
+		if !visitor(name, g) {
+			break
+		}
+	}
+}
+
+// RegisterRate implements stats.Manager.
+func (m *Manager) RegisterRate(name string) (stats.Rate, error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	if _, found := m.rates[name]; found {
+		return nil, newError("Rate ", name, " already registered.")
+	}
+	newError("create new rate ", name).AtDebug().WriteToLog()
+	r := NewRate(m.rateWindow)
+	m.rates[name] = r
+	return r, nil
+}
+
+// UnregisterRate implements stats.Manager.
+func (m *Manager) UnregisterRate(name string) error {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	if _, found := m.rates[name]; found {
+		newError("remove rate ", name).AtDebug().WriteToLog()
+		delete(m.rates, name)
+	}
+	return nil
+}
+
+// GetRate implements stats.Manager.
+func (m *Manager) GetRate(name string) stats.Rate {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	if r, found := m.rates[name]; found {
+		return r
+	}
+	return nil
+}
+
+// VisitRates calls visitor function on all managed rates.
+func (m *Manager) VisitRates(visitor func(string, stats.Rate) bool) {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	for name, r := range m.rates {
+		if !visitor(name, r) {
+			break
+		}
+	}
+}
+
+// RegisterHistogram implements stats.Manager.
+func (m *Manager) RegisterHistogram(name string, bounds []int64) (stats.Histogram, error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	if _, found := m.histograms[name]; found {
+		return nil, newError("Histogram ", name, " already registered.")
+	}
+	newError("create new histogram ", name).AtDebug().WriteToLog()
+	h := NewHistogram(bounds)
+	m.histograms[name] = h
+	return h, nil
+}
+
+// UnregisterHistogram implements stats.Manager.
+func (m *Manager) UnregisterHistogram(name string) error {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	if _, found := m.histograms[name]; found {
+		newError("remove histogram ", name).AtDebug().WriteToLog()
+		delete(m.histograms, name)
+	}
+	return nil
+}
+
+// GetHistogram implements stats.Manager.
+func (m *Manager) GetHistogram(name string) stats.Histogram {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	if h, found := m.histograms[name]; found {
+		return h
+	}
+	return nil
+}
+
+// VisitHistograms calls visitor function on all managed histograms.
+func (m *Manager) VisitHistograms(visitor func(string, stats.Histogram) bool) {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	for name, h := range m.histograms {
+		if !visitor(name, h) {
+			break
+		}
+	}
+}
+
 // RegisterChannel implements stats.Manager.
 func (m *Manager) RegisterChannel(name string) (stats.Channel, error) {
 	m.access.Lock()
@@ -128,6 +311,12 @@ func (m *Manager) GetChannel(name string) stats.Channel {
 
 // Start implements common.Runnable.
 func (m *Manager) Start() error {
+	if m.persist != nil {
+		if err := m.persist.task.Start(); err != nil {
+			return err
+		}
+	}
+
 	m.access.Lock()
 	defer m.access.Unlock()
 	m.running = true
@@ -145,10 +334,19 @@ func (m *Manager) Start() error {
 
 // Close implement common.Closable.
 func (m *Manager) Close() error {
+	errs := []error{}
+	if m.persist != nil {
+		if err := m.persist.task.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := m.persist.save(m); err != nil {
+			errs = append(errs, newError("failed to save stats snapshot").Base(err))
+		}
+	}
+
 	m.access.Lock()
 	defer m.access.Unlock()
 	m.running = false
-	errs := []error{}
 	for name, channel := range m.channels {
 		newError("remove channel ", name).AtDebug().WriteToLog()
 		delete(m.channels, name)
@@ -166,4 +364,4 @@ func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		return NewManager(ctx, config.(*Config))
 	}))
-}
\ No newline at end of file
+}
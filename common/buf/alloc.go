@@ -0,0 +1,70 @@
+package buf
+
+import (
+	"v2ray.com/core/common/bytespool"
+)
+
+// maxReadSize is the largest amount of data for a single read.
+const maxReadSize = 4 * MaxSize
+
+// allocStrategy decides the buffers for the next read of a connection, by the amount of data in previous reads.
+// Connections with high throughput fill the buffers in each read, and get larger buffers for following reads.
+// The zero value starts with a single buffer of Size.
+type allocStrategy struct {
+	// capacity is the total size of buffers for the next read. It is always Size times a power of 4.
+	capacity int32
+	// average is the moving average of bytes in previous reads.
+	average int32
+}
+
+// Capacity returns the total size of buffers for the next read. It is always Size under memory pressure.
+func (s *allocStrategy) Capacity() int32 {
+	if s.capacity == 0 || bytespool.CurrentPressure() >= bytespool.PressureReduce {
+		return Size
+	}
+	return s.capacity
+}
+
+// BufferSize returns the size of each buffer for the next read.
+func (s *allocStrategy) BufferSize() int32 {
+	if c := s.Capacity(); c < MaxSize {
+		return c
+	}
+	return MaxSize
+}
+
+// Count returns the number of buffers for the next read.
+func (s *allocStrategy) Count() int32 {
+	return s.Capacity() / s.BufferSize()
+}
+
+// Adjust updates the strategy with the number of bytes in the last read.
+func (s *allocStrategy) Adjust(n int32) {
+	c := s.Capacity()
+	if n >= c {
+		// All buffers are filled, and there may be more data pending.
+		if c < maxReadSize {
+			c *= 4
+		}
+		s.capacity = c
+		s.average = c
+		return
+	}
+
+	// Shrink slowly, so that a few short reads in bulk transfer don't reset the buffers.
+	s.average = (s.average*3 + n) / 4
+	for c > Size && c/4 >= s.average {
+		c /= 4
+	}
+	s.capacity = c
+}
+
+// Alloc allocates buffers for the next read.
+func (s *allocStrategy) Alloc() []*Buffer {
+	size := s.BufferSize()
+	bs := make([]*Buffer, s.Count())
+	for i := range bs {
+		bs[i] = NewWithSize(size)
+	}
+	return bs
+}
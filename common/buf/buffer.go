@@ -2,6 +2,7 @@ package buf
 
 import (
 	"io"
+	"sync"
 
 	"v2ray.com/core/common/bytespool"
 )
@@ -9,18 +10,24 @@ import (
 const (
 	// Size of a regular buffer.
 	Size = 2048
+	// MaxSize is the size of the largest pooled buffer, which is used for connections with high throughput.
+	MaxSize = 32 * 1024
 )
 
+// sizeClasses are the sizes of pooled buffers, in ascending order.
+var sizeClasses = [...]int32{Size, 8 * 1024, MaxSize}
+
 // Buffer is a recyclable allocation of a byte array. Buffer.Release() recycles
 // the buffer into an internal buffer pool, in order to recreate a buffer more
 // quickly.
 type Buffer struct {
-	v     []byte
-	start int32
-	end   int32
+	v       []byte
+	start   int32
+	end     int32
+	charged bool // whether v is charged to the memory budget
 }
 
-// Release recycles the buffer into an internal buffer pool.
+// Release recycles the buffer into an internal buffer pool. The buffer is no longer charged to the memory budget.
 func (b *Buffer) Release() {
 	if b == nil || b.v == nil {
 		return
@@ -29,7 +36,17 @@ func (b *Buffer) Release() {
 	p := b.v
 	b.v = nil
 	b.Clear()
-	pool.Put(p)
+	if b.charged {
+		b.charged = false
+		bytespool.Charge(-int64(cap(p)))
+	}
+	if len(p) == Size {
+		pool.Put(p)
+		return
+	}
+	if pool := classPool(int32(len(p))); pool != nil {
+		pool.Put(p)
+	}
 }
 
 // Clear clears the content of the buffer, results an empty buffer with
@@ -55,7 +72,7 @@ func (b *Buffer) Bytes() []byte {
 }
 
 // Extend increases the buffer size by n bytes, and returns the extended part.
-// It panics if result size is larger than buf.Size.
+// It panics if result size is larger than the capacity of the buffer.
 func (b *Buffer) Extend(n int32) []byte {
 	end := b.end + n
 	if end > int32(len(b.v)) {
@@ -124,6 +141,14 @@ func (b *Buffer) Len() int32 {
 	return b.end - b.start
 }
 
+// Cap returns the capacity of the buffer, which is Size for buffers from New().
+func (b *Buffer) Cap() int32 {
+	if b == nil {
+		return 0
+	}
+	return int32(len(b.v))
+}
+
 // IsEmpty returns true if the buffer is empty.
 func (b *Buffer) IsEmpty() bool {
 	return b.Len() == 0
@@ -194,19 +219,71 @@ func (b *Buffer) String() string {
 	return string(b.Bytes())
 }
 
-var pool = bytespool.GetPool(Size)
+var (
+	pool  = bytespool.GetPool(Size)
+	pools [len(sizeClasses)]*sync.Pool
+)
+
+func init() {
+	for i, size := range sizeClasses {
+		pools[i] = bytespool.GetPool(size)
+	}
+}
+
+// classPool returns the pool of buffers in exactly the given size, or nil if the size is not one of the size classes.
+func classPool(size int32) *sync.Pool {
+	for i, s := range sizeClasses {
+		if s == size {
+			return pools[i]
+		}
+	}
+	return nil
+}
+
+// charge charges the buffer to the memory budget until it is released. Buffers are not charged when there is no budget.
+func (b *Buffer) charge() {
+	if bytespool.BudgetEnabled() {
+		b.charged = true
+		bytespool.Charge(int64(cap(b.v)))
+	}
+}
 
 // New creates a Buffer with 0 length and 2K capacity.
 func New() *Buffer {
-	return &Buffer{
+	b := &Buffer{
 		v: pool.Get().([]byte),
 	}
+	b.charge()
+	return b
+}
+
+// NewWithSize creates a Buffer with 0 length and the capacity of the smallest size class that fits the given size.
+// Buffers larger than MaxSize are allocated as is, and not recycled.
+func NewWithSize(size int32) *Buffer {
+	var b *Buffer
+	for i, s := range sizeClasses {
+		if size <= s {
+			b = &Buffer{
+				v: pools[i].Get().([]byte),
+			}
+			break
+		}
+	}
+	if b == nil {
+		b = &Buffer{
+			v: make([]byte, size),
+		}
+	}
+	b.charge()
+	return b
 }
 
 // StackNew creates a new Buffer object on stack.
 // This method is for buffers that is released in the same function.
 func StackNew() Buffer {
-	return Buffer{
+	b := Buffer{
 		v: pool.Get().([]byte),
 	}
+	b.charge()
+	return b
 }
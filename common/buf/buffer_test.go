@@ -8,6 +8,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"v2ray.com/core/common"
 	. "v2ray.com/core/common/buf"
+	"v2ray.com/core/common/bytespool"
 )
 
 func TestBufferClear(t *testing.T) {
@@ -148,6 +149,29 @@ func TestBufferReadFullFrom(t *testing.T) {
 	}
 }
 
+func TestNewWithSize(t *testing.T) {
+	for _, c := range []struct {
+		size int32
+		cap  int32
+	}{
+		{size: 1, cap: Size},
+		{size: Size, cap: Size},
+		{size: Size + 1, cap: 8 * 1024},
+		{size: MaxSize, cap: MaxSize},
+		{size: MaxSize + 1, cap: MaxSize + 1},
+	} {
+		b := NewWithSize(c.size)
+		if b.Cap() != c.cap {
+			t.Error("size ", c.size, ": expect capacity ", c.cap, ", but got ", b.Cap())
+		}
+		common.Must2(b.ReadFullFrom(rand.Reader, c.size))
+		if b.Len() != c.size || !b.IsFull() && c.size == c.cap {
+			t.Error("size ", c.size, ": unexpected length ", b.Len())
+		}
+		b.Release()
+	}
+}
+
 func BenchmarkNewBuffer(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		buffer := New()
@@ -221,3 +245,54 @@ func BenchmarkWriteByte8(b *testing.B) {
 		buffer.Clear()
 	}
 }
+
+func TestBufferCharge(t *testing.T) {
+	base, _ := bytespool.Usage()
+
+	// Without budget, buffers are not charged.
+	b := New()
+	if usage, _ := bytespool.Usage(); usage != base {
+		t.Error("unexpected usage without budget: ", usage-base)
+	}
+
+	defer bytespool.SetBudget(0, 0, 0, 0)
+	bytespool.SetBudget(1<<40, 0, 0, 0)
+	b.Release()
+	if usage, _ := bytespool.Usage(); usage != base {
+		t.Error("uncharged buffer is released from the budget: ", usage-base)
+	}
+
+	b1 := New()
+	b2 := NewWithSize(MaxSize + 1)
+	b3 := StackNew()
+	if usage, _ := bytespool.Usage(); usage != base+2*Size+MaxSize+1 {
+		t.Error("unexpected usage of new buffers: ", usage-base)
+	}
+
+	b1.Release()
+	b2.Release()
+	b3.Release()
+	b1.Release()
+	if usage, _ := bytespool.Usage(); usage != base {
+		t.Error("unexpected usage after release: ", usage-base)
+	}
+}
+
+func benchmarkNewBufferParallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buffer := New()
+			buffer.Release()
+		}
+	})
+}
+
+func BenchmarkNewBufferParallel(b *testing.B) {
+	benchmarkNewBufferParallel(b)
+}
+
+func BenchmarkNewBufferParallelWithBudget(b *testing.B) {
+	defer bytespool.SetBudget(0, 0, 0, 0)
+	bytespool.SetBudget(1<<40, 0, 0, 0)
+	benchmarkNewBufferParallel(b)
+}
@@ -144,7 +144,7 @@ func Compact(mb MultiBuffer) MultiBuffer {
 
 	for i := 1; i < len(mb); i++ {
 		curr := mb[i]
-		if last.Len()+curr.Len() > Size {
+		if last.end+curr.Len() > last.Cap() {
 			mb2 = append(mb2, last)
 			last = curr
 		} else {
@@ -176,7 +176,7 @@ func SplitSize(mb MultiBuffer, size int32) (MultiBuffer, MultiBuffer) {
 	}
 
 	if mb[0].Len() > size {
-		b := New()
+		b := NewWithSize(size)
 		copy(b.Extend(size), mb[0].BytesTo(size))
 		mb[0].Advance(size)
 		return mb, MultiBuffer{b}
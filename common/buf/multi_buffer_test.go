@@ -178,6 +178,54 @@ func TestCompact(t *testing.T) {
 	}
 }
 
+func TestCompactAcrossSizes(t *testing.T) {
+	large := NewWithSize(MaxSize)
+	large.Extend(Size)
+	large.Advance(Size - 2)
+	small := New()
+	small.Extend(Size)
+	small2 := New()
+	common.Must2(small2.WriteString("cd"))
+
+	cmb := Compact(MultiBuffer{large, small, small2})
+	if len(cmb) != 1 {
+		t.Error("expect all merged into the large buffer, but got ", len(cmb), " buffers")
+	}
+	if l := cmb.Len(); l != Size+4 {
+		t.Error("unexpected length after Compact: ", l)
+	}
+
+	// Data after the beginning of a buffer, which is moved by Advance(), doesn't fit in the rest of the buffer.
+	small = New()
+	small.Extend(Size)
+	small.Advance(Size - 2)
+	small2 = New()
+	small2.Extend(100)
+	cmb = Compact(MultiBuffer{small, small2})
+	if l := cmb.Len(); l != 102 {
+		t.Error("unexpected length after Compact: ", l)
+	}
+	ReleaseMulti(cmb)
+}
+
+func TestSplitSizeLargeBuffer(t *testing.T) {
+	b := NewWithSize(MaxSize)
+	common.Must2(b.ReadFullFrom(rand.Reader, MaxSize))
+	data := make([]byte, MaxSize)
+	copy(data, b.Bytes())
+
+	mb, chunk := SplitSize(MultiBuffer{b}, 8*1024)
+	if chunk.Len() != 8*1024 || mb.Len() != MaxSize-8*1024 {
+		t.Error("unexpected split: ", chunk.Len(), " and ", mb.Len())
+	}
+	rdata := make([]byte, MaxSize)
+	chunk, n := SplitBytes(chunk, rdata)
+	mb, _ = SplitBytes(mb, rdata[n:])
+	if r := cmp.Diff(data, rdata); r != "" {
+		t.Error(r)
+	}
+}
+
 func BenchmarkSplitBytes(b *testing.B) {
 	var mb MultiBuffer
 	raw := make([]byte, Size)
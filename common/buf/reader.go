@@ -26,7 +26,11 @@ func readOneUDP(r io.Reader) (*Buffer, error) {
 
 // ReadBuffer reads a Buffer from the given reader.
 func ReadBuffer(r io.Reader) (*Buffer, error) {
-	b := New()
+	return readBuffer(r, Size)
+}
+
+func readBuffer(r io.Reader, size int32) (*Buffer, error) {
+	b := NewWithSize(size)
 	n, err := b.ReadFrom(r)
 	if n > 0 {
 		return b, err
@@ -148,14 +152,16 @@ func (r *BufferedReader) Close() error {
 	return common.Close(r.Reader)
 }
 
-// SingleReader is a Reader that read one Buffer every time.
+// SingleReader is a Reader that read one Buffer every time. The size of the Buffer grows with the throughput of the reader.
 type SingleReader struct {
 	io.Reader
+	alloc allocStrategy
 }
 
 // ReadMultiBuffer implements Reader.
 func (r *SingleReader) ReadMultiBuffer() (MultiBuffer, error) {
-	b, err := ReadBuffer(r.Reader)
+	b, err := readBuffer(r.Reader, r.alloc.BufferSize())
+	r.alloc.Adjust(b.Len())
 	return MultiBuffer{b}, err
 }
 
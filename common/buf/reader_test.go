@@ -130,3 +130,79 @@ func TestReaderInterface(t *testing.T) {
 	_ = (io.ByteReader)(new(BufferedReader))
 	_ = (io.WriterTo)(new(BufferedReader))
 }
+
+// fullReader fills the given slice in each read, up to the limit.
+type fullReader struct {
+	limit int
+}
+
+func (r *fullReader) Read(b []byte) (int, error) {
+	if len(b) > r.limit {
+		b = b[:r.limit]
+	}
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+func TestSingleReaderAdaptiveSize(t *testing.T) {
+	source := &fullReader{limit: MaxSize}
+	reader := &SingleReader{Reader: source}
+
+	readCap := func() int32 {
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		defer ReleaseMulti(mb)
+		return mb[0].Cap()
+	}
+
+	for i, expected := range []int32{Size, 8 * 1024, MaxSize, MaxSize} {
+		if c := readCap(); c != expected {
+			t.Error("read ", i, ": expect buffer size ", expected, ", but got ", c)
+		}
+	}
+
+	// Buffers shrink after a few short reads.
+	source.limit = 100
+	for i := 0; i < 16; i++ {
+		readCap()
+	}
+	if c := readCap(); c != Size {
+		t.Error("expect buffer size ", Size, " after short reads, but got ", c)
+	}
+}
+
+func benchmarkReader(b *testing.B, read func(io.Reader) (MultiBuffer, error)) {
+	const total = 1024 * 1024
+	source := &fullReader{limit: 64 * 1024}
+
+	b.SetBytes(total)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for n := int32(0); n < total; {
+			mb, err := read(source)
+			common.Must(err)
+			n += mb.Len()
+			ReleaseMulti(mb)
+		}
+	}
+}
+
+func BenchmarkReadBuffer(b *testing.B) {
+	benchmarkReader(b, func(r io.Reader) (MultiBuffer, error) {
+		buffer, err := ReadBuffer(r)
+		return MultiBuffer{buffer}, err
+	})
+}
+
+func BenchmarkSingleReader(b *testing.B) {
+	var reader *SingleReader
+	benchmarkReader(b, func(r io.Reader) (MultiBuffer, error) {
+		if reader == nil {
+			reader = &SingleReader{Reader: r}
+		}
+		return reader.ReadMultiBuffer()
+	})
+}
@@ -22,7 +22,7 @@ func (r *posixReader) Init(bs []*Buffer) {
 		iovecs = append(iovecs, syscall.Iovec{
 			Base: &(b.v[0]),
 		})
-		iovecs[idx].SetLen(int(Size))
+		iovecs[idx].SetLen(len(b.v))
 	}
 	r.iovecs = iovecs
 }
@@ -10,38 +10,6 @@ import (
 	"v2ray.com/core/common/platform"
 )
 
-type allocStrategy struct {
-	current uint32
-}
-
-func (s *allocStrategy) Current() uint32 {
-	return s.current
-}
-
-func (s *allocStrategy) Adjust(n uint32) {
-	if n >= s.current {
-		s.current *= 4
-	} else {
-		s.current = n
-	}
-
-	if s.current > 32 {
-		s.current = 32
-	}
-
-	if s.current == 0 {
-		s.current = 1
-	}
-}
-
-func (s *allocStrategy) Alloc() []*Buffer {
-	bs := make([]*Buffer, s.current)
-	for i := range bs {
-		bs[i] = New()
-	}
-	return bs
-}
-
 type multiReader interface {
 	Init([]*Buffer)
 	Read(fd uintptr) int32
@@ -61,15 +29,13 @@ func NewReadVReader(reader io.Reader, rawConn syscall.RawConn) *ReadVReader {
 	return &ReadVReader{
 		Reader:  reader,
 		rawConn: rawConn,
-		alloc: allocStrategy{
-			current: 1,
-		},
-		mr: newMultiReader(),
+		mr:      newMultiReader(),
 	}
 }
 
 func (r *ReadVReader) readMulti() (MultiBuffer, error) {
 	bs := r.alloc.Alloc()
+	size := r.alloc.BufferSize()
 
 	r.mr.Init(bs)
 	var nBytes int32
@@ -100,8 +66,8 @@ func (r *ReadVReader) readMulti() (MultiBuffer, error) {
 			break
 		}
 		end := nBytes
-		if end > Size {
-			end = Size
+		if end > size {
+			end = size
 		}
 		bs[nBuf].end = end
 		nBytes -= end
@@ -118,11 +84,9 @@ func (r *ReadVReader) readMulti() (MultiBuffer, error) {
 
 // ReadMultiBuffer implements Reader.
 func (r *ReadVReader) ReadMultiBuffer() (MultiBuffer, error) {
-	if r.alloc.Current() == 1 {
-		b, err := ReadBuffer(r.Reader)
-		if b.IsFull() {
-			r.alloc.Adjust(1)
-		}
+	if r.alloc.Count() == 1 {
+		b, err := readBuffer(r.Reader, r.alloc.BufferSize())
+		r.alloc.Adjust(b.Len())
 		return MultiBuffer{b}, err
 	}
 
@@ -130,7 +94,7 @@ func (r *ReadVReader) ReadMultiBuffer() (MultiBuffer, error) {
 	if err != nil {
 		return nil, err
 	}
-	r.alloc.Adjust(uint32(len(mb)))
+	r.alloc.Adjust(mb.Len())
 	return mb, nil
 }
 
@@ -70,3 +70,40 @@ func TestReadvReader(t *testing.T) {
 		t.Fatal(r)
 	}
 }
+
+func BenchmarkReadVReader(b *testing.B) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})
+	common.Must(err)
+	defer l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	common.Must(err)
+	defer conn.Close()
+	server, err := l.Accept()
+	common.Must(err)
+	defer server.Close()
+
+	const total = 1024 * 1024
+	go func() {
+		data := make([]byte, 64*1024)
+		for n := 0; n < b.N*total; n += len(data) {
+			if _, err := conn.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	rawConn, err := server.(*net.TCPConn).SyscallConn()
+	common.Must(err)
+	reader := NewReadVReader(server, rawConn)
+
+	b.SetBytes(total)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := int64(0); n < int64(b.N)*total; {
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		n += int64(mb.Len())
+		ReleaseMulti(mb)
+	}
+}
@@ -13,7 +13,7 @@ func (r *windowsReader) Init(bs []*Buffer) {
 		r.bufs = make([]syscall.WSABuf, 0, len(bs))
 	}
 	for _, b := range bs {
-		r.bufs = append(r.bufs, syscall.WSABuf{Len: uint32(Size), Buf: &b.v[0]})
+		r.bufs = append(r.bufs, syscall.WSABuf{Len: uint32(len(b.v)), Buf: &b.v[0]})
 	}
 }
 
@@ -0,0 +1,142 @@
+package bytespool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Pressure is the level of memory pressure, by the usage of the memory budget.
+type Pressure int32
+
+const (
+	// PressureNone is the level when the usage is below all thresholds.
+	PressureNone Pressure = iota
+	// PressureReduce is the level to reduce buffers per connection.
+	PressureReduce
+	// PressurePause is the level to pause reading from inbounds.
+	PressurePause
+	// PressureReject is the level to reject new connections.
+	PressureReject
+)
+
+// Default thresholds of pressure levels, in percentage of the budget.
+const (
+	DefaultReduceThreshold = 50
+	DefaultPauseThreshold  = 75
+	DefaultRejectThreshold = 90
+)
+
+func (p Pressure) String() string {
+	switch p {
+	case PressureReduce:
+		return "reduce"
+	case PressurePause:
+		return "pause"
+	case PressureReject:
+		return "reject"
+	default:
+		return "none"
+	}
+}
+
+type budget struct {
+	sync.Mutex
+	usage      int64
+	limit      int64
+	thresholds [3]int64 // usage in bytes where PressureReduce, PressurePause and PressureReject start
+	level      int32
+	relief     chan struct{}
+}
+
+var globalBudget = &budget{
+	relief: make(chan struct{}),
+}
+
+// SetBudget sets the memory budget in bytes, and the thresholds of pressure levels in percentage of the budget.
+// Thresholds of 0 take default values. A limit of 0 disables the budget, and the pressure is always PressureNone.
+//
+// v2ray:api:beta
+func SetBudget(limit int64, reduce, pause, reject uint32) {
+	b := globalBudget
+	b.Lock()
+	defer b.Unlock()
+
+	for i, p := range []uint32{reduce, pause, reject} {
+		if p == 0 {
+			p = []uint32{DefaultReduceThreshold, DefaultPauseThreshold, DefaultRejectThreshold}[i]
+		}
+		atomic.StoreInt64(&b.thresholds[i], limit*int64(p)/100)
+	}
+	atomic.StoreInt64(&b.limit, limit)
+	b.updateLocked()
+}
+
+// Charge adds n bytes to the usage of the memory budget. A negative n is for bytes that are released.
+//
+// v2ray:api:beta
+func Charge(n int64) {
+	b := globalBudget
+	usage := atomic.AddInt64(&b.usage, n)
+	if atomic.LoadInt64(&b.limit) == 0 {
+		return
+	}
+	if b.levelOf(usage) != Pressure(atomic.LoadInt32(&b.level)) {
+		b.Lock()
+		b.updateLocked()
+		b.Unlock()
+	}
+}
+
+// BudgetEnabled returns true if a memory budget is set. Allocations on hot paths may skip charging when there is no budget.
+//
+// v2ray:api:beta
+func BudgetEnabled() bool {
+	return atomic.LoadInt64(&globalBudget.limit) != 0
+}
+
+// Usage returns the bytes charged to the memory budget, and the limit of the budget.
+//
+// v2ray:api:beta
+func Usage() (usage int64, limit int64) {
+	return atomic.LoadInt64(&globalBudget.usage), atomic.LoadInt64(&globalBudget.limit)
+}
+
+// CurrentPressure returns the current level of memory pressure.
+//
+// v2ray:api:beta
+func CurrentPressure() Pressure {
+	return Pressure(atomic.LoadInt32(&globalBudget.level))
+}
+
+// Relief returns a channel that is closed when the memory pressure drops.
+// Callers waiting for lower pressure get the channel before checking CurrentPressure(), so that no drop is missed.
+//
+// v2ray:api:beta
+func Relief() <-chan struct{} {
+	b := globalBudget
+	b.Lock()
+	defer b.Unlock()
+	return b.relief
+}
+
+func (b *budget) levelOf(usage int64) Pressure {
+	if atomic.LoadInt64(&b.limit) == 0 {
+		return PressureNone
+	}
+	level := PressureNone
+	for i := range b.thresholds {
+		if usage >= atomic.LoadInt64(&b.thresholds[i]) {
+			level = Pressure(i + 1)
+		}
+	}
+	return level
+}
+
+func (b *budget) updateLocked() {
+	level := b.levelOf(atomic.LoadInt64(&b.usage))
+	old := Pressure(atomic.SwapInt32(&b.level, int32(level)))
+	if level < old {
+		close(b.relief)
+		b.relief = make(chan struct{})
+	}
+}
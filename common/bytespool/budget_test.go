@@ -0,0 +1,75 @@
+package bytespool_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/common/bytespool"
+)
+
+func TestBudgetPressure(t *testing.T) {
+	base, _ := Usage()
+	defer SetBudget(0, 0, 0, 0)
+	SetBudget(base+1000, 0, 0, 0)
+
+	for _, c := range []struct {
+		charge   int64
+		pressure Pressure
+	}{
+		{charge: 499, pressure: PressureNone},
+		{charge: 1, pressure: PressureReduce},
+		{charge: 250, pressure: PressurePause},
+		{charge: 150, pressure: PressureReject},
+		{charge: -200, pressure: PressureReduce},
+		{charge: -700, pressure: PressureNone},
+	} {
+		relief := Relief()
+		before := CurrentPressure()
+		Charge(c.charge)
+		if p := CurrentPressure(); p != c.pressure {
+			t.Error("charge ", c.charge, ": expect pressure ", c.pressure, ", but got ", p)
+		}
+
+		select {
+		case <-relief:
+			if c.pressure >= before {
+				t.Error("charge ", c.charge, ": unexpected relief")
+			}
+		default:
+			if c.pressure < before {
+				t.Error("charge ", c.charge, ": expect relief from ", before, " to ", c.pressure)
+			}
+		}
+	}
+
+	if usage, limit := Usage(); usage != base || limit != base+1000 {
+		t.Error("unexpected usage ", usage, " of limit ", limit)
+	}
+}
+
+func TestBudgetDisabled(t *testing.T) {
+	SetBudget(1000, 0, 0, 0)
+	Charge(1000)
+	defer Charge(-1000)
+	if p := CurrentPressure(); p != PressureReject {
+		t.Error("expect pressure ", PressureReject, ", but got ", p)
+	}
+
+	relief := Relief()
+	SetBudget(0, 0, 0, 0)
+	if p := CurrentPressure(); p != PressureNone {
+		t.Error("expect no pressure without budget, but got ", p)
+	}
+	<-relief
+}
+
+func TestAllocCharge(t *testing.T) {
+	base, _ := Usage()
+	b := Alloc(4096)
+	if usage, _ := Usage(); usage != base+int64(cap(b)) {
+		t.Error("unexpected usage after Alloc: ", usage-base)
+	}
+	Free(b)
+	if usage, _ := Usage(); usage != base {
+		t.Error("unexpected usage after Free: ", usage-base)
+	}
+}
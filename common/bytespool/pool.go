@@ -47,20 +47,25 @@ func GetPool(size int32) *sync.Pool {
 }
 
 // Alloc returns a byte slice with at least the given size. Minimum size of returned slice is 2048.
+// The slice is charged to the memory budget until it is freed.
 //
 // v2ray:api:stable
 func Alloc(size int32) []byte {
-	pool := GetPool(size)
-	if pool != nil {
-		return pool.Get().([]byte)
+	var b []byte
+	if pool := GetPool(size); pool != nil {
+		b = pool.Get().([]byte)
+	} else {
+		b = make([]byte, size)
 	}
-	return make([]byte, size)
+	Charge(int64(cap(b)))
+	return b
 }
 
-// Free puts a byte slice into the internal pool.
+// Free puts a byte slice from Alloc into the internal pool.
 //
 // v2ray:api:stable
 func Free(b []byte) {
+	Charge(-int64(cap(b)))
 	size := int32(cap(b))
 	b = b[0:cap(b)]
 	for i := numPools - 1; i >= 0; i-- {
@@ -1,6 +1,10 @@
 package common
 
-import "v2ray.com/core/common/errors"
+import (
+	"context"
+
+	"v2ray.com/core/common/errors"
+)
 
 // Closable is the interface for objects that can release its resources.
 //
@@ -38,6 +42,21 @@ func Interrupt(obj interface{}) error {
 	return Close(obj)
 }
 
+// Drainable is the interface for objects that can finish their ongoing work before being closed.
+type Drainable interface {
+	// Drain stops accepting new work, and blocks until all ongoing work finishes or ctx is done.
+	// The object still needs to be closed afterwards.
+	Drain(ctx context.Context) error
+}
+
+// Drain calls Drain() if the object implements Drainable interface.
+func Drain(ctx context.Context, obj interface{}) error {
+	if d, ok := obj.(Drainable); ok {
+		return d.Drain(ctx)
+	}
+	return nil
+}
+
 // Runnable is the interface for objects that can start to work and stop on demand.
 type Runnable interface {
 	// Start starts the runnable object. Upon the method returning nil, the object begins to function properly.
@@ -109,6 +109,64 @@ func (*IPOrDomain_Ip) isIPOrDomain_Address() {}
 
 func (*IPOrDomain_Domain) isIPOrDomain_Address() {}
 
+// IPv6 prefix of IPv4-embedded IPv6 addresses for NAT64, as in RFC 6052.
+type NAT64Prefix struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// IPv6 address of the prefix. Must be 16 bytes.
+	Ip []byte `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	// Length of the prefix. Must be one of 32, 40, 48, 56, 64 and 96.
+	Prefix uint32 `protobuf:"varint,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+}
+
+func (x *NAT64Prefix) Reset() {
+	*x = NAT64Prefix{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_common_net_address_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NAT64Prefix) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NAT64Prefix) ProtoMessage() {}
+
+func (x *NAT64Prefix) ProtoReflect() protoreflect.Message {
+	mi := &file_common_net_address_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NAT64Prefix.ProtoReflect.Descriptor instead.
+func (*NAT64Prefix) Descriptor() ([]byte, []int) {
+	return file_common_net_address_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *NAT64Prefix) GetIp() []byte {
+	if x != nil {
+		return x.Ip
+	}
+	return nil
+}
+
+func (x *NAT64Prefix) GetPrefix() uint32 {
+	if x != nil {
+		return x.Prefix
+	}
+	return 0
+}
+
 var File_common_net_address_proto protoreflect.FileDescriptor
 
 var file_common_net_address_proto_rawDesc = []byte{
@@ -119,12 +177,16 @@ var file_common_net_address_proto_rawDesc = []byte{
 	0x10, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x02, 0x69,
 	0x70, 0x12, 0x18, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
 	0x09, 0x48, 0x00, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x42, 0x09, 0x0a, 0x07, 0x61,
-	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x50, 0x0a, 0x19, 0x63, 0x6f, 0x6d, 0x2e, 0x76, 0x32,
-	0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e,
-	0x6e, 0x65, 0x74, 0x50, 0x01, 0x5a, 0x19, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x6d,
-	0x2f, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2f, 0x6e, 0x65, 0x74,
-	0xaa, 0x02, 0x15, 0x56, 0x32, 0x52, 0x61, 0x79, 0x2e, 0x43, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x6f,
-	0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65, 0x74, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x35, 0x0a, 0x0b, 0x4e, 0x41, 0x54, 0x36, 0x34, 0x50,
+	0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x02, 0x69, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x42, 0x50, 0x0a,
+	0x19, 0x63, 0x6f, 0x6d, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e,
+	0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x6e, 0x65, 0x74, 0x50, 0x01, 0x5a, 0x19, 0x76, 0x32,
+	0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x63, 0x6f, 0x6d,
+	0x6d, 0x6f, 0x6e, 0x2f, 0x6e, 0x65, 0x74, 0xaa, 0x02, 0x15, 0x56, 0x32, 0x52, 0x61, 0x79, 0x2e,
+	0x43, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65, 0x74, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -139,9 +201,10 @@ func file_common_net_address_proto_rawDescGZIP() []byte {
 	return file_common_net_address_proto_rawDescData
 }
 
-var file_common_net_address_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_common_net_address_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_common_net_address_proto_goTypes = []interface{}{
-	(*IPOrDomain)(nil), // 0: v2ray.core.common.net.IPOrDomain
+	(*IPOrDomain)(nil),  // 0: v2ray.core.common.net.IPOrDomain
+	(*NAT64Prefix)(nil), // 1: v2ray.core.common.net.NAT64Prefix
 }
 var file_common_net_address_proto_depIdxs = []int32{
 	0, // [0:0] is the sub-list for method output_type
@@ -169,6 +232,18 @@ func file_common_net_address_proto_init() {
 				return nil
 			}
 		}
+		file_common_net_address_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NAT64Prefix); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_common_net_address_proto_msgTypes[0].OneofWrappers = []interface{}{
 		(*IPOrDomain_Ip)(nil),
@@ -180,7 +255,7 @@ func file_common_net_address_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_common_net_address_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
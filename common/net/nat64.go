@@ -0,0 +1,45 @@
+package net
+
+// IsValid returns true if the prefix is an IPv6 prefix of a length allowed by RFC 6052.
+func (p *NAT64Prefix) IsValid() bool {
+	if len(p.Ip) != IPv6len {
+		return false
+	}
+	switch p.Prefix {
+	case 32, 40, 48, 56, 64, 96:
+		return true
+	default:
+		return false
+	}
+}
+
+// Synthesize returns the IPv4-embedded IPv6 address of the given IPv4 address in the prefix.
+// It returns nil if the given address is not an IPv4 address.
+func (p *NAT64Prefix) Synthesize(ip IP) IP {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil
+	}
+
+	synthesized := make(IP, IPv6len)
+	offset := int(p.Prefix / 8)
+	copy(synthesized, p.Ip[:offset])
+	for _, b := range ip4 {
+		if offset == 8 {
+			// Bits 64 to 71 of the address are reserved.
+			offset++
+		}
+		synthesized[offset] = b
+		offset++
+	}
+	return synthesized
+}
+
+// SynthesizeAddress returns the IPv4-embedded IPv6 address of the address if it is an IPv4 address,
+// or the address itself otherwise.
+func (p *NAT64Prefix) SynthesizeAddress(addr Address) Address {
+	if !addr.Family().IsIPv4() {
+		return addr
+	}
+	return IPAddress(p.Synthesize(addr.IP()))
+}
@@ -0,0 +1,45 @@
+package net_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/common/net"
+)
+
+func TestNAT64Synthesize(t *testing.T) {
+	// Examples from RFC 6052, section 2.4.
+	testCases := []struct {
+		prefix string
+		length uint32
+		result string
+	}{
+		{"2001:db8::", 32, "2001:db8:c000:221::"},
+		{"2001:db8:100::", 40, "2001:db8:1c0:2:21::"},
+		{"2001:db8:122::", 48, "2001:db8:122:c000:2:2100::"},
+		{"2001:db8:122:300::", 56, "2001:db8:122:3c0:0:221::"},
+		{"2001:db8:122:344::", 64, "2001:db8:122:344:c0:2:2100:0"},
+		{"2001:db8:122:344::", 96, "2001:db8:122:344::192.0.2.33"},
+	}
+
+	for _, tc := range testCases {
+		prefix := &NAT64Prefix{
+			Ip:     ParseIP(tc.prefix),
+			Prefix: tc.length,
+		}
+		if !prefix.IsValid() {
+			t.Error("expect valid prefix ", tc.prefix, "/", tc.length)
+		}
+		result := prefix.SynthesizeAddress(ParseAddress("192.0.2.33"))
+		if !result.IP().Equal(ParseIP(tc.result)) {
+			t.Error("unexpected result for ", tc.prefix, "/", tc.length, ": ", result, ", want ", tc.result)
+		}
+	}
+
+	prefix := &NAT64Prefix{Ip: ParseIP("64:ff9b::"), Prefix: 96}
+	if addr := prefix.SynthesizeAddress(ParseAddress("2001:db8::1")); addr.String() != "[2001:db8::1]" {
+		t.Error("expect IPv6 address unchanged, but got ", addr)
+	}
+	if (&NAT64Prefix{Ip: ParseIP("64:ff9b::"), Prefix: 80}).IsValid() {
+		t.Error("expect invalid prefix length")
+	}
+}
@@ -18,6 +18,8 @@ var ListenUnix = net.ListenUnix
 var LookupIP = net.LookupIP
 
 var FileConn = net.FileConn
+var FileListener = net.FileListener
+var FilePacketConn = net.FilePacketConn
 
 // ParseIP is an alias of net.ParseIP
 var ParseIP = net.ParseIP
@@ -25,6 +27,7 @@ var ParseIP = net.ParseIP
 var SplitHostPort = net.SplitHostPort
 
 var CIDRMask = net.CIDRMask
+var ParseCIDR = net.ParseCIDR
 
 type Addr = net.Addr
 type Conn = net.Conn
@@ -1,5 +1,9 @@
 package protocol
 
+import (
+	"github.com/golang/protobuf/proto"
+)
+
 // Account is a user identity used for authentication.
 type Account interface {
 	Equals(Account) bool
@@ -9,3 +13,8 @@ type Account interface {
 type AsAccount interface {
 	AsAccount() (Account, error)
 }
+
+// ToProtoAccount is an account that can be converted back into its proto form.
+type ToProtoAccount interface {
+	ToProto() proto.Message
+}
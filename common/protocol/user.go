@@ -1,5 +1,9 @@
 package protocol
 
+import (
+	"v2ray.com/core/common/serial"
+)
+
 func (u *User) GetTypedAccount() (Account, error) {
 	if u.GetAccount() == nil {
 		return nil, newError("Account missing").AtWarning()
@@ -30,6 +34,18 @@ func (u *User) ToMemoryUser() (*MemoryUser, error) {
 	}, nil
 }
 
+// ToProto converts this MemoryUser back into User. Account is left empty if it can't be converted.
+func (u *MemoryUser) ToProto() *User {
+	user := &User{
+		Email: u.Email,
+		Level: u.Level,
+	}
+	if account, ok := u.Account.(ToProtoAccount); ok {
+		user.Account = serial.ToTypedMessage(account.ToProto())
+	}
+	return user
+}
+
 // MemoryUser is a parsed form of User, to reduce number of parsing of Account proto.
 type MemoryUser struct {
 	// Account is the parsed account of the protocol.
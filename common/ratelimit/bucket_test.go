@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketRefillAfterLongIdle(t *testing.T) {
+	for _, rate := range []int64{125 * 1000 * 1000, 1000 * 1000 * 1000, 1 << 40} {
+		b := New(rate, rate)
+		b.Take(rate * 2)
+
+		for _, idle := range []time.Duration{time.Second * 10, time.Minute * 2, time.Hour * 24 * 365} {
+			b.tokens = -rate
+			b.refill(b.last.Add(idle))
+			if b.tokens != b.burst {
+				t.Error("tokens after idle of ", idle, " at rate ", rate, ": ", b.tokens, ", want ", b.burst)
+			}
+		}
+	}
+}
+
+func TestBucketRefillPartial(t *testing.T) {
+	b := New(1000, 1000)
+	b.tokens = -1000
+	b.refill(b.last.Add(time.Millisecond * 500))
+	if b.tokens != -500 {
+		t.Error("tokens after 500ms: ", b.tokens, ", want -500")
+	}
+}
@@ -0,0 +1,143 @@
+// Package ratelimit provides token buckets for limiting throughput.
+package ratelimit
+
+//go:generate go run v2ray.com/core/common/errors/errorgen
+
+import (
+	"sync"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/signal/done"
+)
+
+// Bucket is a token bucket whose rate and burst may be changed at any time. It is safe for concurrent use.
+type Bucket struct {
+	access sync.Mutex
+	rate   int64 // tokens per second, 0 for unlimited
+	burst  int64
+	tokens int64
+	last   time.Time
+}
+
+// New creates a Bucket which refills rate tokens per second and holds at most burst tokens.
+// Burst defaults to rate if not positive. A rate of 0 means unlimited.
+func New(rate int64, burst int64) *Bucket {
+	b := &Bucket{
+		last: time.Now(),
+	}
+	b.SetLimit(rate, burst)
+	b.tokens = b.burst
+	return b
+}
+
+// SetLimit changes the rate and burst of the bucket.
+func (b *Bucket) SetLimit(rate int64, burst int64) {
+	if burst <= 0 {
+		burst = rate
+	}
+
+	b.access.Lock()
+	defer b.access.Unlock()
+
+	b.refill(time.Now())
+	b.rate = rate
+	b.burst = burst
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+}
+
+// Limit returns the rate and burst of the bucket.
+func (b *Bucket) Limit() (int64, int64) {
+	b.access.Lock()
+	defer b.access.Unlock()
+
+	return b.rate, b.burst
+}
+
+// Idle returns how long the bucket has not been taken from.
+func (b *Bucket) Idle() time.Duration {
+	b.access.Lock()
+	defer b.access.Unlock()
+
+	return time.Since(b.last)
+}
+
+func (b *Bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+	b.last = now
+	if b.rate <= 0 {
+		return
+	}
+	// Tokens are computed in float, as elapsed nanoseconds times rate overflows int64 after a long idle period.
+	if tokens := elapsed.Seconds() * float64(b.rate); tokens < float64(b.burst-b.tokens) {
+		b.tokens += int64(tokens)
+	} else {
+		b.tokens = b.burst
+	}
+}
+
+// Take takes n tokens from the bucket, and returns how long the caller has to wait before the tokens are actually available.
+// The bucket goes into debt if there are not enough tokens, so that a single take may be larger than burst.
+func (b *Bucket) Take(n int64) time.Duration {
+	b.access.Lock()
+	defer b.access.Unlock()
+
+	b.refill(time.Now())
+	if b.rate <= 0 {
+		return 0
+	}
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(float64(-b.tokens) / float64(b.rate) * float64(time.Second))
+}
+
+// Writer is a buf.Writer that writes no faster than its Bucket allows.
+type Writer struct {
+	bucket *Bucket
+	writer buf.Writer
+	done   *done.Instance
+}
+
+// NewWriter creates a Writer limited by the given Bucket.
+func NewWriter(bucket *Bucket, writer buf.Writer) *Writer {
+	return &Writer{
+		bucket: bucket,
+		writer: writer,
+		done:   done.New(),
+	}
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *Writer) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if d := w.bucket.Take(int64(mb.Len())); d > 0 {
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-w.done.Wait():
+			timer.Stop()
+			buf.ReleaseMulti(mb)
+			return newError("writer closed")
+		}
+	}
+	return w.writer.WriteMultiBuffer(mb)
+}
+
+// Close implements common.Closable.
+func (w *Writer) Close() error {
+	common.Must(w.done.Close())
+	return common.Close(w.writer)
+}
+
+// Interrupt implements common.Interruptible.
+func (w *Writer) Interrupt() {
+	common.Must(w.done.Close())
+	common.Interrupt(w.writer)
+}
@@ -0,0 +1,52 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	. "v2ray.com/core/common/ratelimit"
+)
+
+func TestBucketTake(t *testing.T) {
+	b := New(1000, 500)
+
+	if d := b.Take(500); d != 0 {
+		t.Error("unexpected wait within burst: ", d)
+	}
+	if d := b.Take(1000); d < 900*time.Millisecond || d > time.Second {
+		t.Error("unexpected wait in debt: ", d)
+	}
+
+	b.SetLimit(0, 0)
+	if d := b.Take(1 << 20); d != 0 {
+		t.Error("unexpected wait when unlimited: ", d)
+	}
+	if rate, burst := b.Limit(); rate != 0 || burst != 0 {
+		t.Error("unexpected limit: ", rate, burst)
+	}
+}
+
+func TestWriterInterrupt(t *testing.T) {
+	b := New(1, 1)
+	w := NewWriter(b, buf.Discard)
+
+	mb := buf.MultiBuffer{buf.New()}
+	mb[0].Extend(2048)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		w.Interrupt()
+	}()
+
+	start := time.Now()
+	if err := w.WriteMultiBuffer(mb); err == nil {
+		t.Error("expected error on interrupted writer")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("interrupt didn't stop waiting")
+	}
+
+	common.Must(w.Close())
+}
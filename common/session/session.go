@@ -51,6 +51,8 @@ type Outbound struct {
 	Target net.Destination
 	// Gateway address
 	Gateway net.Address
+	// Sockopt is the socket settings attached by routing, which override the settings of the outbound handler.
+	Sockopt *Sockopt
 }
 
 // SniffingRequest controls the behavior of content sniffing.
@@ -75,6 +77,10 @@ type Content struct {
 type Sockopt struct {
 	// Mark of the socket connection.
 	Mark int32
+	// Interface to bind the socket connection to.
+	Interface string
+	// Source address of the socket connection.
+	Source net.Address
 }
 
 // SetAttribute attachs additional string attributes to content.
@@ -11,6 +11,14 @@ type Feature interface {
 	common.Runnable
 }
 
+// Reloadable is the interface for features that can take a new config without restarting.
+type Reloadable interface {
+	// PrepareReload validates the config, which is of the same type as the one that the feature is created from,
+	// and returns a function that applies it, and a function that releases the resources created for it if it is not applied.
+	// The feature stays unchanged until the apply function is called. The discard function may be nil.
+	PrepareReload(config interface{}) (apply func(), discard func(), err error)
+}
+
 // PrintDeprecatedFeatureWarning prints a warning for deprecated feature.
 func PrintDeprecatedFeatureWarning(feature string) {
 	newError("You are using a deprecated feature: " + feature + ". Please update your config file with latest configuration format, or update your client software.").WriteToLog()
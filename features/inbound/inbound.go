@@ -2,6 +2,7 @@ package inbound
 
 import (
 	"context"
+	"time"
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
@@ -32,6 +33,12 @@ type Manager interface {
 
 	// RemoveHandler removes a handler from Manager.
 	RemoveHandler(ctx context.Context, tag string) error
+	// DrainHandler removes a handler from Manager. The handler stops accepting new connections, and is closed after its existing connections finish or the timeout expires.
+	DrainHandler(ctx context.Context, tag string, timeout time.Duration) error
+	// ReplaceHandler replaces the handler of the same tag with the given one. The new handler is started before the old one stops accepting new connections. The old one is then drained as in DrainHandler.
+	ReplaceHandler(ctx context.Context, handler Handler, timeout time.Duration) error
+	// ListHandlers returns all InboundHandlers in this Manager.
+	ListHandlers(ctx context.Context) []Handler
 }
 
 // ManagerType returns the type of Manager interface. Can be used for implementing common.HasType.
@@ -2,6 +2,7 @@ package outbound
 
 import (
 	"context"
+	"time"
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/features"
@@ -35,6 +36,12 @@ type Manager interface {
 
 	// RemoveHandler removes a handler from outbound.Manager.
 	RemoveHandler(ctx context.Context, tag string) error
+	// DrainHandler removes a handler from outbound.Manager. The handler is closed after its ongoing connections finish or the timeout expires.
+	DrainHandler(ctx context.Context, tag string, timeout time.Duration) error
+	// ReplaceHandler replaces the handler of the same tag with the given one. New connections go to the new handler immediately, and the old one is drained as in DrainHandler.
+	ReplaceHandler(ctx context.Context, handler Handler, timeout time.Duration) error
+	// ListHandlers returns all outbound.Handlers in this outbound.Manager.
+	ListHandlers(ctx context.Context) []Handler
 }
 
 // ManagerType returns the type of Manager interface. Can be used to implement common.HasType.
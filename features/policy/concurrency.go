@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+)
+
+// Concurrency contains limits of concurrent usage of a user, across all inbounds.
+type Concurrency struct {
+	// Maximum number of concurrent connections. 0 for unlimited.
+	Connections int32
+	// Maximum number of distinct source IPs, either connected or seen within IPWindow. 0 for unlimited.
+	IPs int32
+	// Time for a source IP to be remembered after its last connection ends.
+	IPWindow time.Duration
+}
+
+// UserLimiter is the interface for enforcing Concurrency limits. A Manager may implement it.
+type UserLimiter interface {
+	// Acquire registers a new connection of a user from the source. It returns a function to be called when the connection ends, or an error if a limit is exceeded.
+	Acquire(level uint32, email string, source net.Address) (func(), error)
+}
+
+// AcquireUser registers a new connection of the user in m, if m implements UserLimiter. Users without email are not limited.
+func AcquireUser(m Manager, user *protocol.MemoryUser, source net.Address) (func(), error) {
+	limiter, ok := m.(UserLimiter)
+	if !ok || user == nil || len(user.Email) == 0 {
+		return func() {}, nil
+	}
+	return limiter.Acquire(user.Level, user.Email, source)
+}
+
+type sourceUsage struct {
+	active   int32
+	lastSeen time.Time
+}
+
+type userUsage struct {
+	active  int32
+	sources map[string]*sourceUsage
+}
+
+// ConcurrencyTracker is an implementation of UserLimiter, which reads limits of users from a Manager.
+type ConcurrencyTracker struct {
+	access  sync.Mutex
+	manager Manager
+	users   map[string]*userUsage
+}
+
+// NewConcurrencyTracker creates a ConcurrencyTracker which reads limits from the given Manager.
+func NewConcurrencyTracker(m Manager) *ConcurrencyTracker {
+	return &ConcurrencyTracker{
+		manager: m,
+		users:   make(map[string]*userUsage),
+	}
+}
+
+// countSources returns the number of sources of a user within window, and removes the expired ones. Caller must hold the lock.
+func (u *userUsage) countSources(now time.Time, window time.Duration) int32 {
+	var count int32
+	for ip, s := range u.sources {
+		if s.active > 0 || now.Sub(s.lastSeen) < window {
+			count++
+		} else {
+			delete(u.sources, ip)
+		}
+	}
+	return count
+}
+
+// Acquire implements UserLimiter.
+func (t *ConcurrencyTracker) Acquire(level uint32, email string, source net.Address) (func(), error) {
+	limit := t.manager.ForUser(level, email).Concurrency
+	now := time.Now()
+
+	t.access.Lock()
+	defer t.access.Unlock()
+
+	u, found := t.users[email]
+	if !found {
+		u = &userUsage{
+			sources: make(map[string]*sourceUsage),
+		}
+	}
+
+	if limit.Connections > 0 && u.active >= limit.Connections {
+		return nil, newError("user ", email, " has reached the limit of ", limit.Connections, " concurrent connections")
+	}
+
+	ip := ""
+	if source != nil {
+		ip = source.String()
+	}
+	s, knownSource := u.sources[ip]
+	if limit.IPs > 0 && !knownSource && u.countSources(now, limit.IPWindow) >= limit.IPs {
+		return nil, newError("user ", email, " has reached the limit of ", limit.IPs, " source IPs")
+	}
+	if !knownSource {
+		s = new(sourceUsage)
+		u.sources[ip] = s
+	}
+
+	s.active++
+	s.lastSeen = now
+	u.active++
+	t.users[email] = u
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.release(email, u, s, limit.IPWindow)
+		})
+	}, nil
+}
+
+func (t *ConcurrencyTracker) release(email string, u *userUsage, s *sourceUsage, window time.Duration) {
+	t.access.Lock()
+	defer t.access.Unlock()
+
+	now := time.Now()
+	s.active--
+	s.lastSeen = now
+	u.active--
+	if u.active == 0 && u.countSources(now, window) == 0 {
+		delete(t.users, email)
+	}
+}
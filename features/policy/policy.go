@@ -1,5 +1,7 @@
 package policy
 
+//go:generate go run v2ray.com/core/common/errors/errorgen
+
 import (
 	"context"
 	"runtime"
@@ -27,6 +29,10 @@ type Stats struct {
 	UserUplink bool
 	// Whether or not to enable stat counter for user downlink traffic.
 	UserDownlink bool
+	// Whether or not to enable active connection gauge and connection duration and traffic histograms for users.
+	UserConnection bool
+	// Whether or not to enable stat rate for user uplink and downlink traffic.
+	UserRate bool
 }
 
 // Buffer contains settings for internal buffer.
@@ -35,6 +41,18 @@ type Buffer struct {
 	PerConnection int32
 }
 
+// Bandwidth contains limits for traffic throughput. Limits apply to all connections of a user together.
+type Bandwidth struct {
+	// Maximum uplink bytes per second. 0 for unlimited.
+	Uplink int64
+	// Maximum downlink bytes per second. 0 for unlimited.
+	Downlink int64
+	// Maximum uplink bytes in a burst. Default to Uplink.
+	UplinkBurst int64
+	// Maximum downlink bytes in a burst. Default to Downlink.
+	DownlinkBurst int64
+}
+
 // SystemStats contains stat policy settings on system level.
 type SystemStats struct {
 	// Whether or not to enable stat counter for uplink traffic in inbound handlers.
@@ -45,6 +63,14 @@ type SystemStats struct {
 	OutboundUplink bool
 	// Whether or not to enable stat counter for downlink traffic in outbound handlers.
 	OutboundDownlink bool
+	// Whether or not to enable active connection gauge in inbound handlers.
+	InboundConnection bool
+	// Whether or not to enable stat rate for uplink and downlink traffic in inbound handlers.
+	InboundRate bool
+	// Whether or not to enable active connection gauge in outbound handlers.
+	OutboundConnection bool
+	// Whether or not to enable stat rate for uplink and downlink traffic in outbound handlers.
+	OutboundRate bool
 }
 
 // System contains policy settings at system level.
@@ -55,9 +81,12 @@ type System struct {
 
 // Session is session based settings for controlling V2Ray requests. It contains various settings (or limits) that may differ for different users in the context.
 type Session struct {
-	Timeouts Timeout // Timeout settings
-	Stats    Stats
-	Buffer   Buffer
+	Timeouts    Timeout // Timeout settings
+	Stats       Stats
+	Buffer      Buffer
+	Quota       Quota
+	Bandwidth   Bandwidth
+	Concurrency Concurrency
 }
 
 // Manager is a feature that provides Policy for the given user by its id or level.
@@ -69,6 +98,9 @@ type Manager interface {
 	// ForLevel returns the Session policy for the given user level.
 	ForLevel(level uint32) Session
 
+	// ForUser returns the Session policy for the given user, which may override the policy of the user's level.
+	ForUser(level uint32, email string) Session
+
 	// ForSystem returns the System policy for V2Ray system.
 	ForSystem() System
 }
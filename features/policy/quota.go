@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QuotaPeriod is the schedule on which a traffic quota resets.
+type QuotaPeriod int
+
+const (
+	// QuotaPeriodNone means the quota never resets by itself.
+	QuotaPeriodNone QuotaPeriod = iota
+	// QuotaPeriodDaily resets the quota at midnight every day.
+	QuotaPeriodDaily
+	// QuotaPeriodWeekly resets the quota at midnight on ResetDay of every week.
+	QuotaPeriodWeekly
+	// QuotaPeriodMonthly resets the quota at midnight on ResetDay of every month.
+	QuotaPeriodMonthly
+)
+
+// Quota contains settings for traffic quota.
+type Quota struct {
+	// Maximum bytes of uplink plus downlink traffic in a period. 0 for unlimited.
+	Bytes int64
+	// Period on which the quota resets.
+	Period QuotaPeriod
+	// Day of week (0 for Sunday) for weekly period, or day of month (1 to 28) for monthly period.
+	ResetDay uint32
+}
+
+// Enabled returns true if the quota limits traffic.
+func (q Quota) Enabled() bool {
+	return q.Bytes > 0
+}
+
+// PeriodStart returns the beginning of the quota period that t falls in, in the location of t.
+// It returns zero time if the quota never resets.
+func (q Quota) PeriodStart(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch q.Period {
+	case QuotaPeriodDaily:
+		return midnight
+	case QuotaPeriodWeekly:
+		day := int(q.ResetDay % 7)
+		offset := (int(midnight.Weekday()) - day + 7) % 7
+		return midnight.AddDate(0, 0, -offset)
+	case QuotaPeriodMonthly:
+		day := int(q.ResetDay)
+		if day < 1 {
+			day = 1
+		} else if day > 28 {
+			day = 28
+		}
+		start := time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, t.Location())
+		if start.After(t) {
+			start = start.AddDate(0, -1, 0)
+		}
+		return start
+	default:
+		return time.Time{}
+	}
+}
+
+// QuotaUsage is the traffic usage of a user against its quota.
+type QuotaUsage struct {
+	used   int64
+	period int64
+}
+
+// Used returns the bytes of traffic used in current period.
+func (u *QuotaUsage) Used() int64 {
+	return atomic.LoadInt64(&u.used)
+}
+
+// PeriodStart returns the unix time when current period begins.
+func (u *QuotaUsage) PeriodStart() int64 {
+	return atomic.LoadInt64(&u.period)
+}
+
+// Add counts n bytes of traffic against the quota.
+func (u *QuotaUsage) Add(n int64) {
+	atomic.AddInt64(&u.used, n)
+}
+
+// Refresh clears the usage if a new period of the quota has begun at the given time.
+func (u *QuotaUsage) Refresh(q Quota, now time.Time) {
+	if q.Period == QuotaPeriodNone {
+		return
+	}
+	start := q.PeriodStart(now).Unix()
+	for {
+		period := atomic.LoadInt64(&u.period)
+		if period >= start {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&u.period, period, start) {
+			atomic.StoreInt64(&u.used, 0)
+			return
+		}
+	}
+}
+
+// Reset clears the usage immediately.
+func (u *QuotaUsage) Reset(now time.Time) {
+	atomic.StoreInt64(&u.period, now.Unix())
+	atomic.StoreInt64(&u.used, 0)
+}
+
+// Restore sets the usage to a saved state, with period as the unix time when the saved period begins.
+func (u *QuotaUsage) Restore(used int64, period int64) {
+	atomic.StoreInt64(&u.period, period)
+	atomic.StoreInt64(&u.used, used)
+}
+
+// Remaining returns the bytes left in the quota, which is negative when the quota is exceeded.
+func (u *QuotaUsage) Remaining(q Quota) int64 {
+	return q.Bytes - u.Used()
+}
+
+// QuotaTracker is the interface for keeping QuotaUsage of users. A Manager may implement it.
+type QuotaTracker interface {
+	// QuotaUsage returns the QuotaUsage of the user, which is created on first call.
+	QuotaUsage(email string) *QuotaUsage
+	// VisitQuotaUsage calls visitor on the QuotaUsage of each user, until visitor returns false.
+	VisitQuotaUsage(visitor func(email string, usage *QuotaUsage) bool)
+}
+
+// GetQuotaUsage returns the QuotaUsage of the user in m, or nil if m doesn't implement QuotaTracker.
+func GetQuotaUsage(m Manager, email string) *QuotaUsage {
+	tracker, ok := m.(QuotaTracker)
+	if !ok || len(email) == 0 {
+		return nil
+	}
+	return tracker.QuotaUsage(email)
+}
+
+// QuotaStore is an implementation of QuotaTracker, which keeps QuotaUsage of users in memory.
+// Unlike stats counters, the usage can't be changed by anything other than traffic and Reset.
+type QuotaStore struct {
+	access sync.Mutex
+	users  map[string]*QuotaUsage
+}
+
+// NewQuotaStore creates an empty QuotaStore.
+func NewQuotaStore() *QuotaStore {
+	return &QuotaStore{
+		users: make(map[string]*QuotaUsage),
+	}
+}
+
+// QuotaUsage implements QuotaTracker.
+func (s *QuotaStore) QuotaUsage(email string) *QuotaUsage {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	u, found := s.users[email]
+	if !found {
+		u = new(QuotaUsage)
+		s.users[email] = u
+	}
+	return u
+}
+
+// VisitQuotaUsage implements QuotaTracker.
+func (s *QuotaStore) VisitQuotaUsage(visitor func(string, *QuotaUsage) bool) {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	for email, u := range s.users {
+		if !visitor(email, u) {
+			break
+		}
+	}
+}
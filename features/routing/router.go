@@ -2,6 +2,7 @@ package routing
 
 import (
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/session"
 	"v2ray.com/core/features"
 )
 
@@ -29,6 +30,14 @@ type Route interface {
 	GetOutboundTag() string
 }
 
+// SockoptRoute is an optional interface of Route, for routes that override the socket settings of outbound connections.
+//
+// v2ray:api:beta
+type SockoptRoute interface {
+	// GetSockopt returns the socket settings for outbound connections of the session, or nil if not overridden.
+	GetSockopt() *session.Sockopt
+}
+
 // RouterType return the type of Router interface. Can be used to implement common.HasType.
 //
 // v2ray:api:stable
@@ -21,6 +21,52 @@ type Counter interface {
 	Add(int64) int64
 }
 
+// Gauge is the interface for stats gauges, whose value may go up and down.
+//
+// v2ray:api:beta
+type Gauge interface {
+	// Value is the current value of the gauge.
+	Value() int64
+	// Set sets a new value to the gauge, and returns the previous one.
+	Set(int64) int64
+	// Add adds a value, which may be negative, to the current gauge value, and returns the new value.
+	Add(int64) int64
+}
+
+// Rate is the interface for stats rates, which measure an amount per second over a sliding window.
+//
+// v2ray:api:beta
+type Rate interface {
+	// Value is the average amount per second over the window.
+	Value() int64
+	// Add records an amount at the current time.
+	Add(int64)
+}
+
+// HistogramSnapshot is a point-in-time copy of a Histogram.
+type HistogramSnapshot struct {
+	// Bounds are the inclusive upper bounds of the buckets, in ascending order.
+	Bounds []int64
+	// Buckets are the number of observations in each bucket. It has one more element than Bounds, for observations above the last bound.
+	Buckets []int64
+	// Count is the total number of observations.
+	Count int64
+	// Sum is the sum of all observed values.
+	Sum int64
+}
+
+// Histogram is the interface for stats histograms.
+//
+// v2ray:api:beta
+type Histogram interface {
+	// Observe records a value into the histogram.
+	Observe(int64)
+	// Snapshot returns the current state of the histogram.
+	Snapshot() HistogramSnapshot
+	// Reset clears all observations, and returns the state before clearing.
+	Reset() HistogramSnapshot
+}
+
 // Channel is the interface for stats channel.
 //
 // v2ray:api:stable
@@ -71,6 +117,27 @@ type Manager interface {
 	// GetCounter returns a counter by its identifier.
 	GetCounter(string) Counter
 
+	// RegisterGauge registers a new gauge to the manager. The identifier string must not be empty, and unique among other gauges.
+	RegisterGauge(string) (Gauge, error)
+	// UnregisterGauge unregisters a gauge from the manager by its identifier.
+	UnregisterGauge(string) error
+	// GetGauge returns a gauge by its identifier.
+	GetGauge(string) Gauge
+
+	// RegisterRate registers a new rate to the manager. The identifier string must not be empty, and unique among other rates.
+	RegisterRate(string) (Rate, error)
+	// UnregisterRate unregisters a rate from the manager by its identifier.
+	UnregisterRate(string) error
+	// GetRate returns a rate by its identifier.
+	GetRate(string) Rate
+
+	// RegisterHistogram registers a new histogram with the given bucket bounds to the manager. The identifier string must not be empty, and unique among other histograms.
+	RegisterHistogram(string, []int64) (Histogram, error)
+	// UnregisterHistogram unregisters a histogram from the manager by its identifier.
+	UnregisterHistogram(string) error
+	// GetHistogram returns a histogram by its identifier.
+	GetHistogram(string) Histogram
+
 	// RegisterChannel registers a new channel to the manager. The identifier string must not be empty, and unique among other channels.
 	RegisterChannel(string) (Channel, error)
 	// UnregisterCounter unregisters a channel from the manager by its identifier.
@@ -89,6 +156,36 @@ func GetOrRegisterCounter(m Manager, name string) (Counter, error) {
 	return m.RegisterCounter(name)
 }
 
+// GetOrRegisterGauge tries to get the StatGauge first. If not exist, it then tries to create a new gauge.
+func GetOrRegisterGauge(m Manager, name string) (Gauge, error) {
+	gauge := m.GetGauge(name)
+	if gauge != nil {
+		return gauge, nil
+	}
+
+	return m.RegisterGauge(name)
+}
+
+// GetOrRegisterRate tries to get the StatRate first. If not exist, it then tries to create a new rate.
+func GetOrRegisterRate(m Manager, name string) (Rate, error) {
+	rate := m.GetRate(name)
+	if rate != nil {
+		return rate, nil
+	}
+
+	return m.RegisterRate(name)
+}
+
+// GetOrRegisterHistogram tries to get the StatHistogram first. If not exist, it then tries to create a new histogram with the given bounds.
+func GetOrRegisterHistogram(m Manager, name string, bounds []int64) (Histogram, error) {
+	histogram := m.GetHistogram(name)
+	if histogram != nil {
+		return histogram, nil
+	}
+
+	return m.RegisterHistogram(name, bounds)
+}
+
 // GetOrRegisterChannel tries to get the StatChannel first. If not exist, it then tries to create a new channel.
 func GetOrRegisterChannel(m Manager, name string) (Channel, error) {
 	channel := m.GetChannel(name)
@@ -129,6 +226,51 @@ func (NoopManager) GetCounter(string) Counter {
 	return nil
 }
 
+// RegisterGauge implements Manager.
+func (NoopManager) RegisterGauge(string) (Gauge, error) {
+	return nil, newError("not implemented")
+}
+
+// UnregisterGauge implements Manager.
+func (NoopManager) UnregisterGauge(string) error {
+	return nil
+}
+
+// GetGauge implements Manager.
+func (NoopManager) GetGauge(string) Gauge {
+	return nil
+}
+
+// RegisterRate implements Manager.
+func (NoopManager) RegisterRate(string) (Rate, error) {
+	return nil, newError("not implemented")
+}
+
+// UnregisterRate implements Manager.
+func (NoopManager) UnregisterRate(string) error {
+	return nil
+}
+
+// GetRate implements Manager.
+func (NoopManager) GetRate(string) Rate {
+	return nil
+}
+
+// RegisterHistogram implements Manager.
+func (NoopManager) RegisterHistogram(string, []int64) (Histogram, error) {
+	return nil, newError("not implemented")
+}
+
+// UnregisterHistogram implements Manager.
+func (NoopManager) UnregisterHistogram(string) error {
+	return nil
+}
+
+// GetHistogram implements Manager.
+func (NoopManager) GetHistogram(string) Histogram {
+	return nil
+}
+
 // RegisterChannel implements Manager.
 func (NoopManager) RegisterChannel(string) (Channel, error) {
 	return nil, newError("not implemented")
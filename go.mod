@@ -23,4 +23,5 @@ require (
 	google.golang.org/grpc v1.32.0
 	google.golang.org/protobuf v1.25.0
 	h12.io/socks v1.0.1
+	lukechampine.com/blake3 v1.1.7
 )
@@ -5,7 +5,9 @@ import (
 
 	"v2ray.com/core/app/commander"
 	loggerservice "v2ray.com/core/app/log/command"
+	policyservice "v2ray.com/core/app/policy/command"
 	handlerservice "v2ray.com/core/app/proxyman/command"
+	reloadservice "v2ray.com/core/app/reload/command"
 	statsservice "v2ray.com/core/app/stats/command"
 	"v2ray.com/core/common/serial"
 )
@@ -27,6 +29,12 @@ func (c *ApiConfig) Build() (*commander.Config, error) {
 			services = append(services, serial.ToTypedMessage(&handlerservice.Config{}))
 		case "loggerservice":
 			services = append(services, serial.ToTypedMessage(&loggerservice.Config{}))
+		case "policyservice":
+			services = append(services, serial.ToTypedMessage(&policyservice.PolicyServiceConfig{}))
+		case "reloadservice":
+			services = append(services, serial.ToTypedMessage(&reloadservice.Config{}))
+		case "quotaservice":
+			services = append(services, serial.ToTypedMessage(&policyservice.Config{}))
 		case "statsservice":
 			services = append(services, serial.ToTypedMessage(&statsservice.Config{}))
 		}
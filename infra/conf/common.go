@@ -54,6 +54,26 @@ func (v *Address) Build() *net.IPOrDomain {
 	return net.NewIPOrDomain(v.Address)
 }
 
+// NAT64Prefix is an IPv6 prefix for NAT64 in CIDR notation, such as "64:ff9b::/96".
+type NAT64Prefix string
+
+// Build implements Buildable.
+func (v NAT64Prefix) Build() (*net.NAT64Prefix, error) {
+	_, ipNet, err := net.ParseCIDR(string(v))
+	if err != nil {
+		return nil, newError("invalid NAT64 prefix: ", string(v)).Base(err)
+	}
+	prefix, bits := ipNet.Mask.Size()
+	config := &net.NAT64Prefix{
+		Ip:     ipNet.IP,
+		Prefix: uint32(prefix),
+	}
+	if bits != 128 || !config.IsValid() {
+		return nil, newError("invalid NAT64 prefix: ", string(v), ", expect an IPv6 prefix of length 32, 40, 48, 56, 64 or 96")
+	}
+	return config, nil
+}
+
 type Network string
 
 func (v Network) Build() net.Network {
@@ -112,6 +112,7 @@ type DnsConfig struct {
 	Hosts    map[string]*Address `json:"hosts"`
 	ClientIP *Address            `json:"clientIp"`
 	Tag      string              `json:"tag"`
+	DNS64    NAT64Prefix         `json:"dns64"`
 }
 
 func getHostMapping(addr *Address) *dns.Config_HostMapping {
@@ -139,6 +140,14 @@ func (c *DnsConfig) Build() (*dns.Config, error) {
 		config.ClientIp = []byte(c.ClientIP.IP())
 	}
 
+	if len(c.DNS64) > 0 {
+		prefix, err := c.DNS64.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Dns64 = prefix
+	}
+
 	for _, server := range c.Servers {
 		ns, err := server.Build()
 		if err != nil {
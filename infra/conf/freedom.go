@@ -11,10 +11,42 @@ import (
 )
 
 type FreedomConfig struct {
-	DomainStrategy string  `json:"domainStrategy"`
-	Timeout        *uint32 `json:"timeout"`
-	Redirect       string  `json:"redirect"`
-	UserLevel      uint32  `json:"userLevel"`
+	DomainStrategy string               `json:"domainStrategy"`
+	Timeout        *uint32              `json:"timeout"`
+	Redirect       string               `json:"redirect"`
+	UserLevel      uint32               `json:"userLevel"`
+	HappyEyeballs  *HappyEyeballsConfig `json:"happyEyeballs"`
+	NAT64          NAT64Prefix          `json:"nat64"`
+	Fragment       *FragmentConfig      `json:"fragment"`
+}
+
+// FragmentConfig is the JSON config of TLS ClientHello fragmentation.
+type FragmentConfig struct {
+	Offsets []uint32 `json:"offsets"`
+	Records bool     `json:"records"`
+	// Segments is enabled by default, unless records is set.
+	Segments *bool  `json:"segments"`
+	DelayMs  uint32 `json:"delayMs"`
+}
+
+// Build implements Buildable.
+func (c *FragmentConfig) Build() (*freedom.Fragment, error) {
+	if len(c.Offsets) == 0 {
+		return nil, newError("no offset to fragment at")
+	}
+	config := &freedom.Fragment{
+		Offsets:  c.Offsets,
+		Records:  c.Records,
+		Segments: !c.Records,
+		DelayMs:  c.DelayMs,
+	}
+	if c.Segments != nil {
+		config.Segments = *c.Segments
+	}
+	if !config.Records && !config.Segments {
+		return nil, newError("either records or segments must be enabled to fragment")
+	}
+	return config, nil
 }
 
 // Build implements Buildable
@@ -34,6 +66,27 @@ func (c *FreedomConfig) Build() (proto.Message, error) {
 		config.Timeout = *c.Timeout
 	}
 	config.UserLevel = c.UserLevel
+	if c.HappyEyeballs != nil {
+		he, err := c.HappyEyeballs.Build()
+		if err != nil {
+			return nil, newError("invalid happy eyeballs settings").Base(err)
+		}
+		config.HappyEyeballs = he
+	}
+	if len(c.NAT64) > 0 {
+		prefix, err := c.NAT64.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Nat64 = prefix
+	}
+	if c.Fragment != nil {
+		fragment, err := c.Fragment.Build()
+		if err != nil {
+			return nil, newError("invalid fragment settings").Base(err)
+		}
+		config.Fragment = fragment
+	}
 	if len(c.Redirect) > 0 {
 		host, portStr, err := net.SplitHostPort(c.Redirect)
 		if err != nil {
@@ -1,17 +1,87 @@
 package conf
 
 import (
+	"strings"
+
 	"v2ray.com/core/app/policy"
+	"v2ray.com/core/common/bytespool"
 )
 
+type QuotaConfig struct {
+	Bytes    uint64 `json:"bytes"`
+	Period   string `json:"period"`
+	ResetDay uint32 `json:"resetDay"`
+}
+
+func (c *QuotaConfig) Build() (*policy.Policy_Quota, error) {
+	config := &policy.Policy_Quota{
+		Bytes:    c.Bytes,
+		ResetDay: c.ResetDay,
+	}
+	switch strings.ToLower(c.Period) {
+	case "", "none":
+		config.Period = policy.Policy_Quota_None
+	case "daily":
+		config.Period = policy.Policy_Quota_Daily
+	case "weekly":
+		if c.ResetDay > 6 {
+			return nil, newError("invalid reset day of weekly quota: ", c.ResetDay)
+		}
+		config.Period = policy.Policy_Quota_Weekly
+	case "monthly":
+		if c.ResetDay < 1 || c.ResetDay > 28 {
+			return nil, newError("invalid reset day of monthly quota: ", c.ResetDay)
+		}
+		config.Period = policy.Policy_Quota_Monthly
+	default:
+		return nil, newError("unknown quota period: ", c.Period)
+	}
+	return config, nil
+}
+
+type BandwidthConfig struct {
+	Uplink        uint64 `json:"uplink"`
+	Downlink      uint64 `json:"downlink"`
+	UplinkBurst   uint64 `json:"uplinkBurst"`
+	DownlinkBurst uint64 `json:"downlinkBurst"`
+}
+
+func (c *BandwidthConfig) Build() *policy.Policy_Bandwidth {
+	return &policy.Policy_Bandwidth{
+		Uplink:        c.Uplink,
+		Downlink:      c.Downlink,
+		UplinkBurst:   c.UplinkBurst,
+		DownlinkBurst: c.DownlinkBurst,
+	}
+}
+
+type ConcurrencyConfig struct {
+	Connections uint32 `json:"connections"`
+	IPs         uint32 `json:"ips"`
+	IPWindow    uint32 `json:"ipWindow"`
+}
+
+func (c *ConcurrencyConfig) Build() *policy.Policy_Concurrency {
+	return &policy.Policy_Concurrency{
+		Connections: c.Connections,
+		Ips:         c.IPs,
+		IpWindow:    &policy.Second{Value: c.IPWindow},
+	}
+}
+
 type Policy struct {
-	Handshake         *uint32 `json:"handshake"`
-	ConnectionIdle    *uint32 `json:"connIdle"`
-	UplinkOnly        *uint32 `json:"uplinkOnly"`
-	DownlinkOnly      *uint32 `json:"downlinkOnly"`
-	StatsUserUplink   bool    `json:"statsUserUplink"`
-	StatsUserDownlink bool    `json:"statsUserDownlink"`
-	BufferSize        *int32  `json:"bufferSize"`
+	Handshake           *uint32            `json:"handshake"`
+	ConnectionIdle      *uint32            `json:"connIdle"`
+	UplinkOnly          *uint32            `json:"uplinkOnly"`
+	DownlinkOnly        *uint32            `json:"downlinkOnly"`
+	StatsUserUplink     bool               `json:"statsUserUplink"`
+	StatsUserDownlink   bool               `json:"statsUserDownlink"`
+	StatsUserConnection bool               `json:"statsUserConnection"`
+	StatsUserRate       bool               `json:"statsUserRate"`
+	BufferSize          *int32             `json:"bufferSize"`
+	Quota               *QuotaConfig       `json:"quota"`
+	Bandwidth           *BandwidthConfig   `json:"bandwidth"`
+	Concurrency         *ConcurrencyConfig `json:"concurrency"`
 }
 
 func (t *Policy) Build() (*policy.Policy, error) {
@@ -32,8 +102,10 @@ func (t *Policy) Build() (*policy.Policy, error) {
 	p := &policy.Policy{
 		Timeout: config,
 		Stats: &policy.Policy_Stats{
-			UserUplink:   t.StatsUserUplink,
-			UserDownlink: t.StatsUserDownlink,
+			UserUplink:     t.StatsUserUplink,
+			UserDownlink:   t.StatsUserDownlink,
+			UserConnection: t.StatsUserConnection,
+			UserRate:       t.StatsUserRate,
 		},
 	}
 
@@ -47,29 +119,91 @@ func (t *Policy) Build() (*policy.Policy, error) {
 		}
 	}
 
+	if t.Quota != nil {
+		quota, err := t.Quota.Build()
+		if err != nil {
+			return nil, err
+		}
+		p.Quota = quota
+	}
+
+	if t.Bandwidth != nil {
+		p.Bandwidth = t.Bandwidth.Build()
+	}
+
+	if t.Concurrency != nil {
+		p.Concurrency = t.Concurrency.Build()
+	}
+
 	return p, nil
 }
 
 type SystemPolicy struct {
-	StatsInboundUplink    bool `json:"statsInboundUplink"`
-	StatsInboundDownlink  bool `json:"statsInboundDownlink"`
-	StatsOutboundUplink   bool `json:"statsOutboundUplink"`
-	StatsOutboundDownlink bool `json:"statsOutboundDownlink"`
+	StatsInboundUplink      bool          `json:"statsInboundUplink"`
+	StatsInboundDownlink    bool          `json:"statsInboundDownlink"`
+	StatsOutboundUplink     bool          `json:"statsOutboundUplink"`
+	StatsOutboundDownlink   bool          `json:"statsOutboundDownlink"`
+	StatsInboundConnection  bool          `json:"statsInboundConnection"`
+	StatsInboundRate        bool          `json:"statsInboundRate"`
+	StatsOutboundConnection bool          `json:"statsOutboundConnection"`
+	StatsOutboundRate       bool          `json:"statsOutboundRate"`
+	Memory                  *MemoryConfig `json:"memory"`
+}
+
+type MemoryConfig struct {
+	Limit  uint64 `json:"limit"`
+	Reduce uint32 `json:"reduce"`
+	Pause  uint32 `json:"pause"`
+	Reject uint32 `json:"reject"`
+}
+
+func (c *MemoryConfig) Build() (*policy.SystemPolicy_Memory, error) {
+	thresholds := []uint32{c.Reduce, c.Pause, c.Reject}
+	defaults := []uint32{bytespool.DefaultReduceThreshold, bytespool.DefaultPauseThreshold, bytespool.DefaultRejectThreshold}
+	last := uint32(0)
+	for i, t := range thresholds {
+		if t == 0 {
+			t = defaults[i]
+		}
+		if t > 100 || t < last {
+			return nil, newError("memory thresholds must be ascending percentages: ", c.Reduce, ", ", c.Pause, ", ", c.Reject)
+		}
+		last = t
+	}
+	return &policy.SystemPolicy_Memory{
+		Limit:  c.Limit,
+		Reduce: c.Reduce,
+		Pause:  c.Pause,
+		Reject: c.Reject,
+	}, nil
 }
 
 func (p *SystemPolicy) Build() (*policy.SystemPolicy, error) {
-	return &policy.SystemPolicy{
+	config := &policy.SystemPolicy{
 		Stats: &policy.SystemPolicy_Stats{
-			InboundUplink:    p.StatsInboundUplink,
-			InboundDownlink:  p.StatsInboundDownlink,
-			OutboundUplink:   p.StatsOutboundUplink,
-			OutboundDownlink: p.StatsOutboundDownlink,
+			InboundUplink:      p.StatsInboundUplink,
+			InboundDownlink:    p.StatsInboundDownlink,
+			OutboundUplink:     p.StatsOutboundUplink,
+			OutboundDownlink:   p.StatsOutboundDownlink,
+			InboundConnection:  p.StatsInboundConnection,
+			InboundRate:        p.StatsInboundRate,
+			OutboundConnection: p.StatsOutboundConnection,
+			OutboundRate:       p.StatsOutboundRate,
 		},
-	}, nil
+	}
+	if p.Memory != nil {
+		memory, err := p.Memory.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Memory = memory
+	}
+	return config, nil
 }
 
 type PolicyConfig struct {
 	Levels map[uint32]*Policy `json:"levels"`
+	Users  map[string]*Policy `json:"users"`
 	System *SystemPolicy      `json:"system"`
 }
 
@@ -84,8 +218,19 @@ func (c *PolicyConfig) Build() (*policy.Config, error) {
 			levels[l] = pp
 		}
 	}
+	users := make(map[string]*policy.Policy)
+	for email, p := range c.Users {
+		if p != nil {
+			pp, err := p.Build()
+			if err != nil {
+				return nil, err
+			}
+			users[email] = pp
+		}
+	}
 	config := &policy.Config{
 		Level: levels,
+		User:  users,
 	}
 
 	if c.System != nil {
@@ -94,6 +94,28 @@ type RouterRule struct {
 	BalancerTag string `json:"balancerTag"`
 }
 
+// RoutingSocketConfig overrides the socket settings of the outbound for the sessions matching a routing rule.
+type RoutingSocketConfig struct {
+	Mark        int32    `json:"mark"`
+	SendThrough *Address `json:"sendThrough"`
+	Interface   string   `json:"interface"`
+}
+
+// Build implements Buildable.
+func (c *RoutingSocketConfig) Build() (*router.SocketAttributes, error) {
+	attributes := &router.SocketAttributes{
+		Mark:      c.Mark,
+		Interface: c.Interface,
+	}
+	if c.SendThrough != nil {
+		if c.SendThrough.Family().IsDomain() {
+			return nil, newError("unable to send through: " + c.SendThrough.String())
+		}
+		attributes.Source = c.SendThrough.Build()
+	}
+	return attributes, nil
+}
+
 func ParseIP(s string) (*router.CIDR, error) {
 	var addr, mask string
 	i := strings.Index(s, "/")
@@ -385,16 +407,17 @@ func toCidrList(ips StringList) ([]*router.GeoIP, error) {
 func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	type RawFieldRule struct {
 		RouterRule
-		Domain     *StringList  `json:"domain"`
-		IP         *StringList  `json:"ip"`
-		Port       *PortList    `json:"port"`
-		Network    *NetworkList `json:"network"`
-		SourceIP   *StringList  `json:"source"`
-		SourcePort *PortList    `json:"sourcePort"`
-		User       *StringList  `json:"user"`
-		InboundTag *StringList  `json:"inboundTag"`
-		Protocols  *StringList  `json:"protocol"`
-		Attributes string       `json:"attrs"`
+		Domain     *StringList          `json:"domain"`
+		IP         *StringList          `json:"ip"`
+		Port       *PortList            `json:"port"`
+		Network    *NetworkList         `json:"network"`
+		SourceIP   *StringList          `json:"source"`
+		SourcePort *PortList            `json:"sourcePort"`
+		User       *StringList          `json:"user"`
+		InboundTag *StringList          `json:"inboundTag"`
+		Protocols  *StringList          `json:"protocol"`
+		Attributes string               `json:"attrs"`
+		Sockopt    *RoutingSocketConfig `json:"sockopt"`
 	}
 	rawFieldRule := new(RawFieldRule)
 	err := json.Unmarshal(msg, rawFieldRule)
@@ -475,6 +498,14 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		rule.Attributes = rawFieldRule.Attributes
 	}
 
+	if rawFieldRule.Sockopt != nil {
+		attributes, err := rawFieldRule.Sockopt.Build()
+		if err != nil {
+			return nil, newError("failed to build socket settings of routing rule").Base(err)
+		}
+		rule.SocketAttributes = attributes
+	}
+
 	return rule, nil
 }
 
@@ -26,6 +26,12 @@ func cipherFromString(c string) shadowsocks.CipherType {
 		return shadowsocks.CipherType_AES_256_GCM
 	case "chacha20-poly1305", "aead_chacha20_poly1305", "chacha20-ietf-poly1305":
 		return shadowsocks.CipherType_CHACHA20_POLY1305
+	case "2022-blake3-aes-128-gcm":
+		return shadowsocks.CipherType_BLAKE3_AES_128_GCM
+	case "2022-blake3-aes-256-gcm":
+		return shadowsocks.CipherType_BLAKE3_AES_256_GCM
+	case "2022-blake3-chacha20-poly1305":
+		return shadowsocks.CipherType_BLAKE3_CHACHA20_POLY1305
 	case "none", "plain":
 		return shadowsocks.CipherType_NONE
 	default:
@@ -33,35 +39,91 @@ func cipherFromString(c string) shadowsocks.CipherType {
 	}
 }
 
-type ShadowsocksServerConfig struct {
-	Cipher      string       `json:"method"`
-	Password    string       `json:"password"`
-	UDP         bool         `json:"udp"`
-	Level       byte         `json:"level"`
-	Email       string       `json:"email"`
-	NetworkList *NetworkList `json:"network"`
+type ShadowsocksUserConfig struct {
+	Cipher   string `json:"method"`
+	Password string `json:"password"`
+	Level    byte   `json:"level"`
+	Email    string `json:"email"`
 }
 
-func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
-	config := new(shadowsocks.ServerConfig)
-	config.UdpEnabled = v.UDP
-	config.Network = v.NetworkList.Build()
-
+func (v *ShadowsocksUserConfig) Build(defaultCipher string) (*protocol.User, error) {
 	if v.Password == "" {
 		return nil, newError("Shadowsocks password is not specified.")
 	}
+	cipher := v.Cipher
+	if cipher == "" {
+		cipher = defaultCipher
+	}
 	account := &shadowsocks.Account{
 		Password: v.Password,
 	}
-	account.CipherType = cipherFromString(v.Cipher)
+	account.CipherType = cipherFromString(cipher)
 	if account.CipherType == shadowsocks.CipherType_UNKNOWN {
-		return nil, newError("unknown cipher method: ", v.Cipher)
+		return nil, newError("unknown cipher method: ", cipher)
 	}
 
-	config.User = &protocol.User{
+	return &protocol.User{
 		Email:   v.Email,
 		Level:   uint32(v.Level),
 		Account: serial.ToTypedMessage(account),
+	}, nil
+}
+
+// ShadowsocksPluginConfig is the config of a SIP003 plugin.
+type ShadowsocksPluginConfig struct {
+	Plugin     string   `json:"plugin"`
+	PluginOpts string   `json:"pluginOpts"`
+	PluginArgs []string `json:"pluginArgs"`
+}
+
+func (v *ShadowsocksPluginConfig) Build() *shadowsocks.Plugin {
+	if v.Plugin == "" {
+		return nil
+	}
+	return &shadowsocks.Plugin{
+		Path:    v.Plugin,
+		Options: v.PluginOpts,
+		Args:    v.PluginArgs,
+	}
+}
+
+type ShadowsocksServerConfig struct {
+	ShadowsocksPluginConfig
+
+	Cipher      string                   `json:"method"`
+	Password    string                   `json:"password"`
+	UDP         bool                     `json:"udp"`
+	Level       byte                     `json:"level"`
+	Email       string                   `json:"email"`
+	NetworkList *NetworkList             `json:"network"`
+	Clients     []*ShadowsocksUserConfig `json:"clients"`
+}
+
+func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
+	config := new(shadowsocks.ServerConfig)
+	config.UdpEnabled = v.UDP
+	config.Network = v.NetworkList.Build()
+	config.Plugin = v.ShadowsocksPluginConfig.Build()
+
+	if v.Password != "" || len(v.Clients) == 0 {
+		user, err := (&ShadowsocksUserConfig{
+			Cipher:   v.Cipher,
+			Password: v.Password,
+			Level:    v.Level,
+			Email:    v.Email,
+		}).Build("")
+		if err != nil {
+			return nil, err
+		}
+		config.User = user
+	}
+
+	for _, client := range v.Clients {
+		user, err := client.Build(v.Cipher)
+		if err != nil {
+			return nil, newError("failed to build Shadowsocks client ", client.Email).Base(err)
+		}
+		config.Users = append(config.Users, user)
 	}
 
 	return config, nil
@@ -78,6 +140,7 @@ type ShadowsocksServerTarget struct {
 }
 
 type ShadowsocksClientConfig struct {
+	ShadowsocksPluginConfig
 	Servers []*ShadowsocksServerTarget `json:"servers"`
 }
 
@@ -123,6 +186,7 @@ func (v *ShadowsocksClientConfig) Build() (proto.Message, error) {
 	}
 
 	config.Server = serverSpecs
+	config.Plugin = v.ShadowsocksPluginConfig.Build()
 
 	return config, nil
 }
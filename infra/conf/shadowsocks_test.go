@@ -32,5 +32,83 @@ func TestShadowsocksServerConfigParsing(t *testing.T) {
 				Network: []net.Network{net.Network_TCP},
 			},
 		},
+		{
+			Input: `{
+				"method": "aes-256-gcm",
+				"clients": [
+					{
+						"password": "password-a",
+						"email": "a@v2ray.com"
+					},
+					{
+						"method": "chacha20-poly1305",
+						"password": "password-b",
+						"email": "b@v2ray.com",
+						"level": 1
+					}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &shadowsocks.ServerConfig{
+				Users: []*protocol.User{
+					{
+						Email: "a@v2ray.com",
+						Account: serial.ToTypedMessage(&shadowsocks.Account{
+							CipherType: shadowsocks.CipherType_AES_256_GCM,
+							Password:   "password-a",
+						}),
+					},
+					{
+						Email: "b@v2ray.com",
+						Level: 1,
+						Account: serial.ToTypedMessage(&shadowsocks.Account{
+							CipherType: shadowsocks.CipherType_CHACHA20_POLY1305,
+							Password:   "password-b",
+						}),
+					},
+				},
+				Network: []net.Network{net.Network_TCP},
+			},
+		},
+		{
+			Input: `{
+				"method": "aes-128-gcm",
+				"password": "v2ray-password",
+				"plugin": "obfs-server",
+				"pluginOpts": "obfs=http",
+				"pluginArgs": ["-v"]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &shadowsocks.ServerConfig{
+				User: &protocol.User{
+					Account: serial.ToTypedMessage(&shadowsocks.Account{
+						CipherType: shadowsocks.CipherType_AES_128_GCM,
+						Password:   "v2ray-password",
+					}),
+				},
+				Network: []net.Network{net.Network_TCP},
+				Plugin: &shadowsocks.Plugin{
+					Path:    "obfs-server",
+					Options: "obfs=http",
+					Args:    []string{"-v"},
+				},
+			},
+		},
+		{
+			Input: `{
+				"method": "2022-blake3-aes-128-gcm",
+				"password": "AQIDBAUGBwgJCgsMDQ4PEA=="
+			}`,
+			Parser: loadJSON(creator),
+			Output: &shadowsocks.ServerConfig{
+				User: &protocol.User{
+					Account: serial.ToTypedMessage(&shadowsocks.Account{
+						CipherType: shadowsocks.CipherType_BLAKE3_AES_128_GCM,
+						Password:   "AQIDBAUGBwgJCgsMDQ4PEA==",
+					}),
+				},
+				Network: []net.Network{net.Network_TCP},
+			},
+		},
 	})
 }
@@ -421,9 +421,36 @@ func (p TransportProtocol) Build() (string, error) {
 }
 
 type SocketConfig struct {
-	Mark   int32  `json:"mark"`
-	TFO    *bool  `json:"tcpFastOpen"`
-	TProxy string `json:"tproxy"`
+	Mark                 int32                `json:"mark"`
+	TFO                  *bool                `json:"tcpFastOpen"`
+	TProxy               string               `json:"tproxy"`
+	SystemdSocket        string               `json:"systemdSocket"`
+	TCPKeepAliveIdle     int32                `json:"tcpKeepAliveIdle"`
+	TCPKeepAliveInterval int32                `json:"tcpKeepAliveInterval"`
+	TCPKeepAliveCount    int32                `json:"tcpKeepAliveCount"`
+	TCPUserTimeout       uint32               `json:"tcpUserTimeout"`
+	TCPCongestion        string               `json:"tcpCongestion"`
+	Interface            string               `json:"interface"`
+	TOS                  *uint32              `json:"tos"`
+	DSCP                 *uint32              `json:"dscp"`
+	TCPNoDelay           *bool                `json:"tcpNoDelay"`
+	HappyEyeballs        *HappyEyeballsConfig `json:"happyEyeballs"`
+	FreeBind             bool                 `json:"freeBind"`
+}
+
+type HappyEyeballsConfig struct {
+	PreferIPv4 bool   `json:"preferIPv4"`
+	TryDelayMs uint32 `json:"tryDelayMs"`
+	Interleave uint32 `json:"interleave"`
+}
+
+// Build implements Buildable.
+func (c *HappyEyeballsConfig) Build() (*internet.HappyEyeballsConfig, error) {
+	return &internet.HappyEyeballsConfig{
+		PreferIpv4: c.PreferIPv4,
+		TryDelayMs: c.TryDelayMs,
+		Interleave: c.Interleave,
+	}, nil
 }
 
 // Build implements Buildable.
@@ -446,10 +473,50 @@ func (c *SocketConfig) Build() (*internet.SocketConfig, error) {
 		tproxy = internet.SocketConfig_Off
 	}
 
+	var tos uint32
+	switch {
+	case c.TOS != nil && c.DSCP != nil:
+		return nil, newError("only one of tos and dscp can be set")
+	case c.TOS != nil:
+		if *c.TOS > 255 {
+			return nil, newError("invalid tos: ", *c.TOS)
+		}
+		tos = *c.TOS
+	case c.DSCP != nil:
+		if *c.DSCP > 63 {
+			return nil, newError("invalid dscp: ", *c.DSCP)
+		}
+		tos = *c.DSCP << 2
+	}
+
+	if c.TCPKeepAliveIdle < 0 || c.TCPKeepAliveInterval < 0 || c.TCPKeepAliveCount < 0 {
+		return nil, newError("invalid tcp keepalive settings")
+	}
+
+	var happyEyeballs *internet.HappyEyeballsConfig
+	if c.HappyEyeballs != nil {
+		he, err := c.HappyEyeballs.Build()
+		if err != nil {
+			return nil, newError("invalid happy eyeballs settings").Base(err)
+		}
+		happyEyeballs = he
+	}
+
 	return &internet.SocketConfig{
-		Mark:   c.Mark,
-		Tfo:    tfoSettings,
-		Tproxy: tproxy,
+		Mark:                 c.Mark,
+		Tfo:                  tfoSettings,
+		Tproxy:               tproxy,
+		SystemdSocket:        c.SystemdSocket,
+		TcpKeepAliveIdle:     c.TCPKeepAliveIdle,
+		TcpKeepAliveInterval: c.TCPKeepAliveInterval,
+		TcpKeepAliveCount:    c.TCPKeepAliveCount,
+		TcpUserTimeout:       c.TCPUserTimeout,
+		TcpCongestion:        c.TCPCongestion,
+		Interface:            c.Interface,
+		Tos:                  tos,
+		TcpNoDelayDisabled:   c.TCPNoDelay != nil && !*c.TCPNoDelay,
+		HappyEyeballs:        happyEyeballs,
+		FreeBind:             c.FreeBind,
 	}, nil
 }
 
@@ -10,6 +10,7 @@ import (
 	"v2ray.com/core/app/dispatcher"
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/app/stats"
+	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/serial"
 	"v2ray.com/core/transport/internet/xtls"
 )
@@ -232,13 +233,41 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 }
 
 type OutboundDetourConfig struct {
-	Protocol      string           `json:"protocol"`
-	SendThrough   *Address         `json:"sendThrough"`
-	Tag           string           `json:"tag"`
-	Settings      *json.RawMessage `json:"settings"`
-	StreamSetting *StreamConfig    `json:"streamSettings"`
-	ProxySettings *ProxyConfig     `json:"proxySettings"`
-	MuxSettings   *MuxConfig       `json:"mux"`
+	Protocol            string           `json:"protocol"`
+	SendThrough         *Address         `json:"sendThrough"`
+	SendThroughStrategy string           `json:"sendThroughStrategy"`
+	Tag                 string           `json:"tag"`
+	Settings            *json.RawMessage `json:"settings"`
+	StreamSetting       *StreamConfig    `json:"streamSettings"`
+	ProxySettings       *ProxyConfig     `json:"proxySettings"`
+	MuxSettings         *MuxConfig       `json:"mux"`
+}
+
+func buildViaCIDR(s string, strategy string) (*proxyman.ViaCIDR, error) {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, newError("unable to send through: " + s).Base(err)
+	}
+	ip := ipNet.IP
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	prefix, _ := ipNet.Mask.Size()
+	config := &proxyman.ViaCIDR{
+		Ip:     ip,
+		Prefix: uint32(prefix),
+	}
+	switch strings.ToLower(strategy) {
+	case "", "random":
+		config.Strategy = proxyman.ViaCIDR_Random
+	case "destination":
+		config.Strategy = proxyman.ViaCIDR_Destination
+	case "user":
+		config.Strategy = proxyman.ViaCIDR_User
+	default:
+		return nil, newError("unknown sendThroughStrategy: ", strategy)
+	}
+	return config, nil
 }
 
 // Build implements Buildable.
@@ -247,10 +276,19 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 
 	if c.SendThrough != nil {
 		address := c.SendThrough
-		if address.Family().IsDomain() {
+		if address.Family().IsDomain() && strings.Contains(address.Domain(), "/") {
+			cidr, err := buildViaCIDR(address.Domain(), c.SendThroughStrategy)
+			if err != nil {
+				return nil, err
+			}
+			senderSettings.ViaCidr = cidr
+		} else if address.Family().IsDomain() {
 			return nil, newError("unable to send through: " + address.String())
+		} else if len(c.SendThroughStrategy) > 0 {
+			return nil, newError("sendThroughStrategy is only for sending through a CIDR")
+		} else {
+			senderSettings.Via = address.Build()
 		}
-		senderSettings.Via = address.Build()
 	}
 
 	if c.StreamSetting != nil {
@@ -304,11 +342,42 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	}, nil
 }
 
-type StatsConfig struct{}
+type StatsPersistenceConfig struct {
+	File     string   `json:"file"`
+	Interval uint32   `json:"interval"`
+	Patterns []string `json:"patterns"`
+}
+
+// Build implements Buildable.
+func (c *StatsPersistenceConfig) Build() (*stats.PersistenceConfig, error) {
+	if len(c.File) == 0 {
+		return nil, newError("stats persistence file is not specified")
+	}
+	return &stats.PersistenceConfig{
+		File:     c.File,
+		Interval: c.Interval,
+		Pattern:  c.Patterns,
+	}, nil
+}
+
+type StatsConfig struct {
+	RateWindow  uint32                  `json:"rateWindow"`
+	Persistence *StatsPersistenceConfig `json:"persistence"`
+}
 
 // Build implements Buildable.
 func (c *StatsConfig) Build() (*stats.Config, error) {
-	return &stats.Config{}, nil
+	config := &stats.Config{
+		RateWindow: c.RateWindow,
+	}
+	if c.Persistence != nil {
+		pc, err := c.Persistence.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Persistence = pc
+	}
+	return config, nil
 }
 
 type Config struct {
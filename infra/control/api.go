@@ -12,6 +12,8 @@ import (
 	"google.golang.org/grpc"
 
 	logService "v2ray.com/core/app/log/command"
+	policyService "v2ray.com/core/app/policy/command"
+	reloadService "v2ray.com/core/app/reload/command"
 	statsService "v2ray.com/core/app/stats/command"
 	"v2ray.com/core/common"
 )
@@ -30,14 +32,33 @@ func (c *ApiCommand) Description() Description {
 			"Call an API in an V2Ray process.",
 			"The following methods are currently supported:",
 			"\tLoggerService.RestartLogger",
+			"\tPolicyService.GetPolicy",
+			"\tPolicyService.SetPolicy",
+			"\tPolicyService.DeletePolicy",
+			"\tPolicyService.GetUserPolicy",
+			"\tPolicyService.SetUserPolicy",
+			"\tPolicyService.DeleteUserPolicy",
+			"\tPolicyService.GetSystemPolicy",
+			"\tPolicyService.SetSystemPolicy",
+			"\tPolicyService.ListPolicies",
+			"\tQuotaService.GetQuota",
+			"\tQuotaService.ResetQuota",
+			"\tReloadService.Reload",
 			"\tStatsService.GetStats",
 			"\tStatsService.QueryStats",
+			"\tStatsService.QueryGauges",
+			"\tStatsService.QueryRates",
+			"\tStatsService.QueryHistograms",
 			"API calls in this command have a timeout to the server of 3 seconds.",
 			"Examples:",
 			"v2ctl api --server=127.0.0.1:8080 LoggerService.RestartLogger '' ",
 			"v2ctl api --server=127.0.0.1:8080 StatsService.QueryStats 'pattern: \"\" reset: false'",
 			"v2ctl api --server=127.0.0.1:8080 StatsService.GetStats 'name: \"inbound>>>statin>>>traffic>>>downlink\" reset: false'",
 			"v2ctl api --server=127.0.0.1:8080 StatsService.GetSysStats ''",
+			"v2ctl api --server=127.0.0.1:8080 PolicyService.SetPolicy 'level: 1 policy: <timeout: <handshake: <value: 4>>>'",
+			"v2ctl api --server=127.0.0.1:8080 QuotaService.GetQuota 'email: \"love@v2ray.com\" level: 0'",
+			"v2ctl api --server=127.0.0.1:8080 StatsService.QueryGauges 'pattern: \"connection>>>active\"'",
+			"v2ctl api --server=127.0.0.1:8080 ReloadService.Reload 'drain_timeout: 30'",
 		},
 	}
 }
@@ -95,6 +116,28 @@ type serviceHandler func(ctx context.Context, conn *grpc.ClientConn, method stri
 var serivceHandlerMap = map[string]serviceHandler{
 	"statsservice":  callStatsService,
 	"loggerservice": callLogService,
+	"quotaservice":  callQuotaService,
+	"policyservice": callPolicyService,
+	"reloadservice": callReloadService,
+}
+
+func callReloadService(ctx context.Context, conn *grpc.ClientConn, method string, request string) (string, error) {
+	client := reloadService.NewReloadServiceClient(conn)
+
+	switch strings.ToLower(method) {
+	case "reload":
+		r := &reloadService.ReloadRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.Reload(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	default:
+		return "", errors.New("Unknown method: " + method)
+	}
 }
 
 func callLogService(ctx context.Context, conn *grpc.ClientConn, method string, request string) (string, error) {
@@ -116,6 +159,134 @@ func callLogService(ctx context.Context, conn *grpc.ClientConn, method string, r
 	}
 }
 
+func callQuotaService(ctx context.Context, conn *grpc.ClientConn, method string, request string) (string, error) {
+	client := policyService.NewQuotaServiceClient(conn)
+
+	switch strings.ToLower(method) {
+	case "getquota":
+		r := &policyService.GetQuotaRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.GetQuota(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "resetquota":
+		r := &policyService.ResetQuotaRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.ResetQuota(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	default:
+		return "", errors.New("Unknown method: " + method)
+	}
+}
+
+func callPolicyService(ctx context.Context, conn *grpc.ClientConn, method string, request string) (string, error) {
+	client := policyService.NewPolicyServiceClient(conn)
+
+	switch strings.ToLower(method) {
+	case "getpolicy":
+		r := &policyService.GetPolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.GetPolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "setpolicy":
+		r := &policyService.SetPolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.SetPolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "deletepolicy":
+		r := &policyService.DeletePolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.DeletePolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "getuserpolicy":
+		r := &policyService.GetUserPolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.GetUserPolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "setuserpolicy":
+		r := &policyService.SetUserPolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.SetUserPolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "deleteuserpolicy":
+		r := &policyService.DeleteUserPolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.DeleteUserPolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "getsystempolicy":
+		r := &policyService.GetSystemPolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.GetSystemPolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "setsystempolicy":
+		r := &policyService.SetSystemPolicyRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.SetSystemPolicy(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "listpolicies":
+		r := &policyService.ListPoliciesRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.ListPolicies(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	default:
+		return "", errors.New("Unknown method: " + method)
+	}
+}
+
 func callStatsService(ctx context.Context, conn *grpc.ClientConn, method string, request string) (string, error) {
 	client := statsService.NewStatsServiceClient(conn)
 
@@ -140,6 +311,36 @@ func callStatsService(ctx context.Context, conn *grpc.ClientConn, method string,
 			return "", err
 		}
 		return proto.MarshalTextString(resp), nil
+	case "querygauges":
+		r := &statsService.QueryStatsRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.QueryGauges(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "queryrates":
+		r := &statsService.QueryStatsRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.QueryRates(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
+	case "queryhistograms":
+		r := &statsService.QueryStatsRequest{}
+		if err := proto.UnmarshalText(request, r); err != nil {
+			return "", err
+		}
+		resp, err := client.QueryHistograms(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		return proto.MarshalTextString(resp), nil
 	case "getsysstats":
 		// SysStatsRequest is an empty message
 		r := &statsService.SysStatsRequest{}
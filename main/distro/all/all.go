@@ -11,7 +11,9 @@ import (
 	// Default commander and all its services. This is an optional feature.
 	_ "v2ray.com/core/app/commander"
 	_ "v2ray.com/core/app/log/command"
+	_ "v2ray.com/core/app/policy/command"
 	_ "v2ray.com/core/app/proxyman/command"
+	_ "v2ray.com/core/app/reload/command"
 	_ "v2ray.com/core/app/stats/command"
 
 	// Other optional features.
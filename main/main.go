@@ -3,22 +3,27 @@ package main
 //go:generate go run v2ray.com/core/common/errors/errorgen
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"v2ray.com/core"
+	"v2ray.com/core/common"
 	"v2ray.com/core/common/cmdarg"
 	"v2ray.com/core/common/platform"
 	_ "v2ray.com/core/main/distro/all"
+	"v2ray.com/core/transport/internet"
 )
 
 var (
@@ -27,6 +32,7 @@ var (
 	version     = flag.Bool("version", false, "Show current version of V2Ray.")
 	test        = flag.Bool("test", false, "Test config file only, without launching V2Ray server.")
 	format      = flag.String("format", "json", "Format of input file.")
+	grace       = flag.Duration("shutdowngrace", 0, "Time to wait for ongoing connections to finish on shutdown, or on handlers removed by reloading with SIGHUP, e.g. 30s. A second shutdown signal exits immediately. After upgrading with SIGUSR2, ongoing connections are waited for without limit if it is 0.")
 
 	/* We have to do this here because Golang's Test will also need to parse flag, before
 	 * main func in this file is run.
@@ -107,21 +113,30 @@ func GetConfigFormat() string {
 	}
 }
 
-func startV2Ray() (core.Server, error) {
+func startV2Ray() (*core.Instance, error) {
 	configFiles, err := getConfigFilePath()
 	if err != nil {
 		return nil, err
 	}
 
-	config, err := core.LoadConfig(GetConfigFormat(), configFiles[0], configFiles)
+	loadConfig := func() (*core.Config, error) {
+		config, err := core.LoadConfig(GetConfigFormat(), configFiles[0], configFiles)
+		if err != nil {
+			return nil, newError("failed to read config files: [", configFiles.String(), "]").Base(err)
+		}
+		return config, nil
+	}
+
+	config, err := loadConfig()
 	if err != nil {
-		return nil, newError("failed to read config files: [", configFiles.String(), "]").Base(err)
+		return nil, err
 	}
 
 	server, err := core.New(config)
 	if err != nil {
 		return nil, newError("failed to create server").Base(err)
 	}
+	server.SetConfigLoader(loadConfig)
 
 	return server, nil
 }
@@ -155,18 +170,106 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := internet.AdoptListeners(); err != nil {
+		fmt.Println("Failed to adopt listeners", err)
+		os.Exit(-1)
+	}
+
 	if err := server.Start(); err != nil {
 		fmt.Println("Failed to start", err)
 		os.Exit(-1)
 	}
 	defer server.Close()
 
+	if err := internet.ConfirmListeners(); err != nil {
+		log.Println("Failed to confirm upgrade:", err)
+	}
+
 	// Explicitly triggering GC to remove garbage from config loading.
 	runtime.GC()
 
 	{
+		reloadSignals := make(chan os.Signal, 1)
+		signal.Notify(reloadSignals, syscall.SIGHUP)
+		go func() {
+			for range reloadSignals {
+				log.Println("Reloading config.")
+				if err := server.Reload(nil, *grace); err != nil {
+					log.Println("Failed to reload config:", err)
+				}
+			}
+		}()
+
+		upgraded := make(chan struct{})
+		if len(upgradeSignals) > 0 {
+			upgradeRequests := make(chan os.Signal, 1)
+			signal.Notify(upgradeRequests, upgradeSignals...)
+			go func() {
+				for range upgradeRequests {
+					log.Println("Upgrading.")
+					if err := upgrade(); err != nil {
+						log.Println("Failed to upgrade:", err)
+						continue
+					}
+					signal.Stop(upgradeRequests)
+					close(upgraded)
+					return
+				}
+			}()
+		}
+
 		osSignals := make(chan os.Signal, 1)
 		signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
-		<-osSignals
+		select {
+		case <-osSignals:
+			signal.Stop(reloadSignals)
+			if *grace > 0 {
+				shutdown(server, osSignals, *grace)
+			}
+		case <-upgraded:
+			signal.Stop(reloadSignals)
+			shutdown(server, osSignals, *grace)
+		}
+	}
+}
+
+// upgradeTimeout is the time for the new process to start in an upgrade.
+const upgradeTimeout = time.Minute
+
+// upgrade starts the executable file of this process, which may have been replaced by a new version, and passes the listeners to it.
+func upgrade() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return newError("failed to locate executable file").Base(err)
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return internet.HandoffListeners(cmd, upgradeTimeout)
+}
+
+// shutdown stops accepting new connections, and waits for ongoing ones until the grace period ends or another signal arrives.
+// A zero grace period waits without limit.
+func shutdown(server core.Server, osSignals <-chan os.Signal, grace time.Duration) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if grace > 0 {
+		log.Printf("Shutting down in %s. Send the signal again to exit immediately.\n", grace)
+		ctx, cancel = context.WithTimeout(context.Background(), grace)
+	} else {
+		log.Println("Shutting down after ongoing connections finish. Send the signal again to exit immediately.")
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+	go func() {
+		select {
+		case <-osSignals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := common.Drain(ctx, server); err != nil {
+		log.Println("Closing remaining connections:", err)
 	}
 }
@@ -0,0 +1,10 @@
+// +build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,10 @@
+// +build !linux
+
+package main
+
+import (
+	"os"
+)
+
+// Upgrade is only supported on Linux.
+var upgradeSignals []os.Signal
@@ -20,6 +20,7 @@ import (
 	"v2ray.com/core/common/task"
 	"v2ray.com/core/features/policy"
 	"v2ray.com/core/features/routing"
+	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/internet"
 )
 
@@ -122,6 +123,9 @@ func (d *DokodemoDoor) Process(ctx context.Context, network net.Network, conn in
 	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
 
 	ctx = policy.ContextWithBufferPolicy(ctx, plcy.Buffer)
+	if network == net.Network_TCP {
+		ctx = proxy.ContextWithSplice(ctx)
+	}
 	link, err := dispatcher.Dispatch(ctx, dest)
 	if err != nil {
 		return newError("failed to dispatch request").Base(err)
@@ -135,13 +139,13 @@ func (d *DokodemoDoor) Process(ctx context.Context, network net.Network, conn in
 			}
 		}()
 
-		var reader buf.Reader
+		var err error
 		if dest.Network == net.Network_UDP {
-			reader = buf.NewPacketReader(conn)
+			err = buf.Copy(buf.NewPacketReader(conn), link.Writer, buf.UpdateActivity(timer))
 		} else {
-			reader = buf.NewReader(conn)
+			err = proxy.CopyFromConn(ctx, proxy.SpliceUplink, conn, buf.NewReader(conn), link.Writer, timer)
 		}
-		if err := buf.Copy(reader, link.Writer, buf.UpdateActivity(timer)); err != nil {
+		if err != nil {
 			return newError("failed to transport request").Base(err)
 		}
 
@@ -196,7 +200,13 @@ func (d *DokodemoDoor) Process(ctx context.Context, network net.Network, conn in
 	responseDone := func() error {
 		defer timer.SetTimeout(plcy.Timeouts.UplinkOnly)
 
-		if err := buf.Copy(link.Reader, writer, buf.UpdateActivity(timer)); err != nil {
+		var err error
+		if network == net.Network_TCP {
+			err = proxy.CopyToConn(ctx, proxy.SpliceDownlink, conn, link.Reader, writer, timer)
+		} else {
+			err = buf.Copy(link.Reader, writer, buf.UpdateActivity(timer))
+		}
+		if err != nil {
 			return newError("failed to transport response").Base(err)
 		}
 		return nil
@@ -0,0 +1,135 @@
+package dokodemo_test
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/features/dns/localdns"
+	"v2ray.com/core/features/policy"
+	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/transport"
+	"v2ray.com/core/transport/internet"
+	"v2ray.com/core/transport/pipe"
+)
+
+type systemDialer struct{}
+
+func (systemDialer) Dial(ctx context.Context, dest net.Destination) (internet.Connection, error) {
+	return internet.DialSystem(ctx, dest, nil)
+}
+
+func (systemDialer) Address() net.Address {
+	return nil
+}
+
+type countingReader struct {
+	buf.Reader
+	n int64
+}
+
+func (r *countingReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	atomic.AddInt64(&r.n, int64(mb.Len()))
+	return mb, err
+}
+
+// freedomDispatcher sends the connections to a freedom outbound, counting the data it reads from the link.
+type freedomDispatcher struct {
+	outbound *freedom.Handler
+	uplink   *countingReader
+}
+
+func (*freedomDispatcher) Type() interface{} {
+	return nil
+}
+
+func (*freedomDispatcher) Start() error {
+	return nil
+}
+
+func (*freedomDispatcher) Close() error {
+	return nil
+}
+
+func (d *freedomDispatcher) Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+	uplinkReader, uplinkWriter := pipe.New(pipe.WithSizeLimit(512 * 1024))
+	downlinkReader, downlinkWriter := pipe.New(pipe.WithSizeLimit(512 * 1024))
+	d.uplink = &countingReader{Reader: uplinkReader}
+
+	ctx = session.ContextWithOutbound(ctx, &session.Outbound{Target: dest})
+	go func() {
+		d.outbound.Process(ctx, &transport.Link{Reader: d.uplink, Writer: downlinkWriter}, systemDialer{}) // nolint: errcheck
+		common.Interrupt(uplinkReader)
+	}()
+	return &transport.Link{Reader: downlinkReader, Writer: uplinkWriter}, nil
+}
+
+// tcpPair returns both ends of a loopback TCP connection.
+func tcpPair() (*net.TCPConn, *net.TCPConn) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})
+	common.Must(err)
+	defer l.Close()
+
+	client, err := net.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+	common.Must(err)
+	server, err := l.AcceptTCP()
+	common.Must(err)
+	return client, server
+}
+
+func TestDokodemoSplice(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte {
+			return b
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	door := new(dokodemo.DokodemoDoor)
+	common.Must(door.Init(&dokodemo.Config{
+		Address:  net.NewIPOrDomain(dest.Address),
+		Port:     uint32(dest.Port),
+		Networks: []net.Network{net.Network_TCP},
+	}, policy.DefaultManager{}, nil))
+	outbound := new(freedom.Handler)
+	common.Must(outbound.Init(&freedom.Config{}, policy.DefaultManager{}, localdns.New()))
+	dispatcher := &freedomDispatcher{outbound: outbound}
+
+	client, in := tcpPair()
+	defer client.Close()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- door.Process(context.Background(), net.Network_TCP, in, dispatcher)
+	}()
+
+	// The first message goes through the link, when the outbound has offered its connection.
+	// The inbound may have started reading the next one before that.
+	common.Must2(client.Write([]byte("ping")))
+	common.Must(client.SetReadDeadline(time.Now().Add(time.Minute)))
+	b := make([]byte, 4)
+	common.Must2(io.ReadFull(client, b))
+
+	payload := make([]byte, 4*1024*1024)
+	go func() {
+		common.Must2(client.Write(payload))
+		common.Must(client.CloseWrite())
+	}()
+	common.Must2(io.CopyN(buf.DiscardBytes, client, int64(len(payload))))
+	common.Must(client.Close())
+	<-errs
+
+	if n := atomic.LoadInt64(&dispatcher.uplink.n); n > 4+buf.Size {
+		t.Error("expect data spliced, but ", n, " bytes are read from the link")
+	}
+}
@@ -0,0 +1,162 @@
+// +build !confonly
+
+package freedom
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sort"
+	"time"
+
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/protocol/tls"
+	"v2ray.com/core/common/session"
+)
+
+const (
+	tlsRecordHeaderLen = 5
+	// tlsMaxRecordLen is the maximum length of TLS plaintext in a record.
+	tlsMaxRecordLen = 1 << 14
+)
+
+// fragmentWriter fragments the TLS ClientHello at the beginning of a connection, and writes the rest as is.
+type fragmentWriter struct {
+	ctx     context.Context
+	config  *Fragment
+	conn    io.Writer
+	writer  buf.Writer
+	pending []byte
+	done    bool
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *fragmentWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if w.done {
+		return w.writer.WriteMultiBuffer(mb)
+	}
+
+	pending := make([]byte, len(w.pending)+int(mb.Len()))
+	copy(pending, w.pending)
+	mb.Copy(pending[len(w.pending):])
+	buf.ReleaseMulti(mb)
+	w.pending = pending
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if pending[0] == 0x16 /* TLS Handshake */ && !isRecordComplete(pending) && !isRecordOversized(pending) {
+		// Wait for the rest of the first record.
+		return nil
+	}
+	w.done = true
+	w.pending = nil
+
+	if isRecordOversized(pending) {
+		_, err := w.conn.Write(pending)
+		return err
+	}
+	header, err := tls.SniffTLS(pending)
+	if err != nil {
+		_, err := w.conn.Write(pending)
+		return err
+	}
+	newError("fragmenting TLS ClientHello to ", header.Domain()).AtDebug().WriteToLog(session.ExportIDToError(w.ctx))
+
+	recordLen := tlsRecordHeaderLen + int(binary.BigEndian.Uint16(pending[3:]))
+	if err := w.writeClientHello(pending[:recordLen]); err != nil {
+		return err
+	}
+	if len(pending) > recordLen {
+		_, err := w.conn.Write(pending[recordLen:])
+		return err
+	}
+	return nil
+}
+
+func isRecordComplete(b []byte) bool {
+	return len(b) >= tlsRecordHeaderLen && len(b) >= tlsRecordHeaderLen+int(binary.BigEndian.Uint16(b[3:]))
+}
+
+// isRecordOversized returns true if b declares a record longer than a valid TLS record, which means b is not TLS.
+func isRecordOversized(b []byte) bool {
+	return len(b) >= tlsRecordHeaderLen && int(binary.BigEndian.Uint16(b[3:])) > tlsMaxRecordLen
+}
+
+func (w *fragmentWriter) writeClientHello(record []byte) error {
+	// The ClientHello itself must not be changed, as it is a part of the handshake transcript.
+	hello := record[tlsRecordHeaderLen:]
+
+	var packets [][]byte
+	if w.config.Records {
+		for _, piece := range splitAt(hello, w.config.Offsets, 0) {
+			packets = append(packets, tlsRecord(record[:3], piece))
+		}
+	} else {
+		packets = splitAt(tlsRecord(record[:3], hello), w.config.Offsets, tlsRecordHeaderLen)
+	}
+
+	if !w.config.Segments {
+		var merged []byte
+		for _, packet := range packets {
+			merged = append(merged, packet...)
+		}
+		packets = [][]byte{merged}
+	}
+
+	for i, packet := range packets {
+		if i > 0 && w.config.DelayMs > 0 {
+			if err := w.delay(); err != nil {
+				return err
+			}
+		}
+		if _, err := w.conn.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delay waits for the configured delay between fragments, or until the connection is cancelled.
+func (w *fragmentWriter) delay() error {
+	timer := time.NewTimer(time.Duration(w.config.DelayMs) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// tlsRecord returns a TLS record of the fragment, with the content type and version in the header.
+func tlsRecord(header []byte, fragment []byte) []byte {
+	record := make([]byte, tlsRecordHeaderLen+len(fragment))
+	copy(record, header[:3])
+	binary.BigEndian.PutUint16(record[3:], uint16(len(fragment)))
+	copy(record[tlsRecordHeaderLen:], fragment)
+	return record
+}
+
+// splitAt splits b at the offsets, each of which is shifted by base. Offsets out of b are ignored.
+func splitAt(b []byte, offsets []uint32, base int) [][]byte {
+	positions := make([]int, 0, len(offsets))
+	for _, offset := range offsets {
+		if p := base + int(offset); p > 0 && p < len(b) {
+			positions = append(positions, p)
+		}
+	}
+	sort.Ints(positions)
+
+	var pieces [][]byte
+	last := 0
+	for _, p := range positions {
+		if p == last {
+			continue
+		}
+		pieces = append(pieces, b[last:p])
+		last = p
+	}
+	return append(pieces, b[last:])
+}
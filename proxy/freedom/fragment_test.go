@@ -0,0 +1,183 @@
+package freedom
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/protocol/tls/cert"
+)
+
+type countingWriter struct {
+	sync.Mutex
+	io.Writer
+	writes int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.Lock()
+	w.writes++
+	w.Unlock()
+	return w.Writer.Write(b)
+}
+
+func (w *countingWriter) count() int {
+	w.Lock()
+	defer w.Unlock()
+	return w.writes
+}
+
+func TestFragmentClientHello(t *testing.T) {
+	caCert := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign))
+	serverCert := cert.MustGenerate(caCert, cert.DNSNames("v2fly.org"))
+	certPEM, keyPEM := serverCert.ToPEM()
+	certificate, err := gotls.X509KeyPair(certPEM, keyPEM)
+	common.Must(err)
+	roots := x509.NewCertPool()
+	caPEM, _ := caCert.ToPEM()
+	roots.AppendCertsFromPEM(caPEM)
+
+	cases := []struct {
+		config *Fragment
+		writes int
+	}{
+		{
+			config: &Fragment{Offsets: []uint32{1, 10}, Records: true, Segments: true, DelayMs: 1},
+			writes: 3,
+		},
+		{
+			config: &Fragment{Offsets: []uint32{20, 0}, Segments: true},
+			writes: 3,
+		},
+		{
+			config: &Fragment{Offsets: []uint32{1, 1, 65535}, Records: true},
+			writes: 1,
+		},
+	}
+
+	for _, c := range cases {
+		clientConn, clientRelay := net.Pipe()
+		serverRelay, serverConn := net.Pipe()
+		counter := &countingWriter{Writer: serverRelay}
+		writer := &fragmentWriter{
+			ctx:    context.Background(),
+			config: c.config,
+			conn:   counter,
+			writer: buf.NewWriter(counter),
+		}
+		go buf.Copy(buf.NewReader(clientRelay), writer)
+		go io.Copy(clientRelay, serverRelay)
+
+		writes := 0
+		go func() {
+			server := gotls.Server(serverConn, &gotls.Config{
+				Certificates: []gotls.Certificate{certificate},
+				GetConfigForClient: func(hello *gotls.ClientHelloInfo) (*gotls.Config, error) {
+					writes = counter.count()
+					return nil, nil
+				},
+			})
+			b := make([]byte, 16)
+			n, err := server.Read(b)
+			common.Must(err)
+			common.Must2(server.Write(b[:n]))
+		}()
+
+		client := gotls.Client(clientConn, &gotls.Config{ServerName: "v2fly.org", RootCAs: roots})
+		common.Must2(client.Write([]byte("ping")))
+		b := make([]byte, 16)
+		n, err := client.Read(b)
+		common.Must(err)
+		if string(b[:n]) != "ping" {
+			t.Error("unexpected response ", string(b[:n]))
+		}
+		if writes != c.writes {
+			t.Error("expect ClientHello written in ", c.writes, " writes, but got ", writes)
+		}
+
+		client.Close()
+		serverConn.Close()
+	}
+}
+
+func TestFragmentNotTLS(t *testing.T) {
+	var b buf.MultiBufferContainer
+	counter := &countingWriter{Writer: &b}
+	writer := &fragmentWriter{
+		ctx:    context.Background(),
+		config: &Fragment{Offsets: []uint32{1}, Segments: true},
+		conn:   counter,
+		writer: &b,
+	}
+	common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("GET / HTTP/1.1\r\n"))))
+	common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("\r\n"))))
+	if r := cmp.Diff(b.String(), "GET / HTTP/1.1\r\n\r\n"); r != "" {
+		t.Error(r)
+	}
+	if counter.count() != 1 {
+		t.Error("expect non-TLS data written as is")
+	}
+}
+
+func TestFragmentOversizedRecord(t *testing.T) {
+	var b buf.MultiBufferContainer
+	counter := &countingWriter{Writer: &b}
+	writer := &fragmentWriter{
+		ctx:    context.Background(),
+		config: &Fragment{Offsets: []uint32{1}, Segments: true},
+		conn:   counter,
+		writer: &b,
+	}
+	// The declared length is longer than any TLS record, so the data is not held for the rest of the record.
+	common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte{0x16, 0x03, 0x01, 0xff, 0xff, 0x01})))
+	if r := cmp.Diff(b.String(), "\x16\x03\x01\xff\xff\x01"); r != "" {
+		t.Error(r)
+	}
+	if counter.count() != 1 {
+		t.Error("expect oversized record written as is")
+	}
+}
+
+func TestFragmentDelayCancelled(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	go gotls.Client(clientConn, &gotls.Config{ServerName: "v2fly.org"}).Handshake() // nolint: errcheck
+	defer clientConn.Close()
+
+	record := make([]byte, tlsRecordHeaderLen)
+	common.Must2(io.ReadFull(serverConn, record))
+	record = append(record, make([]byte, binary.BigEndian.Uint16(record[3:]))...)
+	common.Must2(io.ReadFull(serverConn, record[tlsRecordHeaderLen:]))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var b buf.MultiBufferContainer
+	counter := &countingWriter{Writer: &b}
+	writer := &fragmentWriter{
+		ctx:    ctx,
+		config: &Fragment{Offsets: []uint32{1}, Records: true, Segments: true, DelayMs: 60000},
+		conn:   counter,
+		writer: &b,
+	}
+
+	start := time.Now()
+	if err := writer.WriteMultiBuffer(buf.MergeBytes(nil, record)); err == nil {
+		t.Error("expect error when the connection is cancelled during delay")
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("delay is not cancelled")
+	}
+	if counter.count() != 1 {
+		t.Error("expect only the first fragment written, but got ", counter.count())
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"v2ray.com/core/common/task"
 	"v2ray.com/core/features/dns"
 	"v2ray.com/core/features/policy"
+	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport"
 	"v2ray.com/core/transport/internet"
 )
@@ -44,6 +45,9 @@ type Handler struct {
 
 // Init initializes the Handler with necessary parameters.
 func (h *Handler) Init(config *Config, pm policy.Manager, d dns.Client) error {
+	if config.Nat64 != nil && !config.Nat64.IsValid() {
+		return newError("invalid NAT64 prefix")
+	}
 	h.config = config
 	h.policyManager = pm
 	h.dns = d
@@ -82,6 +86,31 @@ func (h *Handler) resolveIP(ctx context.Context, domain string, localAddr net.Ad
 	return net.IPAddress(ips[dice.Roll(len(ips))])
 }
 
+// lookupFuncs returns the functions to resolve IPv4 and IPv6 addresses for Happy Eyeballs, following the domain strategy.
+func (h *Handler) lookupFuncs(localAddr net.Address) (internet.LookupFunc, internet.LookupFunc) {
+	lookupIPv4, lookupIPv6 := internet.LookupFuncs(h.dns)
+	if h.config.DomainStrategy == Config_USE_IP4 || (localAddr != nil && localAddr.Family().IsIPv4()) {
+		lookupIPv6 = nil
+	} else if h.config.DomainStrategy == Config_USE_IP6 || (localAddr != nil && localAddr.Family().IsIPv6()) {
+		lookupIPv4 = nil
+	}
+	return lookupIPv4, lookupIPv6
+}
+
+// nat64 returns the destination to dial through NAT64, if the destination is an IPv4 address.
+func (h *Handler) nat64(ctx context.Context, dest net.Destination) net.Destination {
+	if h.config.Nat64 == nil || !dest.Address.Family().IsIPv4() {
+		return dest
+	}
+	synthesized := net.Destination{
+		Network: dest.Network,
+		Address: h.config.Nat64.SynthesizeAddress(dest.Address),
+		Port:    dest.Port,
+	}
+	newError("dialing ", dest, " through NAT64 address ", synthesized).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+	return synthesized
+}
+
 func isValidAddress(addr *net.IPOrDomain) bool {
 	if addr == nil {
 		return false
@@ -112,11 +141,31 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	input := link.Reader
 	output := link.Writer
 
+	localAddr := dialer.Address()
+	if outbound.Sockopt != nil && outbound.Sockopt.Source != nil {
+		localAddr = outbound.Sockopt.Source
+	}
+	if h.config.Nat64 != nil && localAddr != nil && localAddr.Family().IsIPv6() {
+		// IPv4 addresses are reachable through NAT64.
+		localAddr = nil
+	}
+
 	var conn internet.Connection
 	err := retry.ExponentialBackoff(5, 100).On(func() error {
 		dialDest := destination
+		if h.config.useIP() && h.config.HappyEyeballs != nil && dialDest.Address.Family().IsDomain() && dialDest.Network == net.Network_TCP {
+			lookupIPv4, lookupIPv6 := h.lookupFuncs(localAddr)
+			rawConn, err := internet.DialHappyEyeballs(ctx, dialDest, h.config.HappyEyeballs, lookupIPv4, lookupIPv6, func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+				return dialer.Dial(ctx, h.nat64(ctx, dest))
+			})
+			if err != nil {
+				return err
+			}
+			conn = rawConn
+			return nil
+		}
 		if h.config.useIP() && dialDest.Address.Family().IsDomain() {
-			ip := h.resolveIP(ctx, dialDest.Address.Domain(), dialer.Address())
+			ip := h.resolveIP(ctx, dialDest.Address.Domain(), localAddr)
 			if ip != nil {
 				dialDest = net.Destination{
 					Network: dialDest.Network,
@@ -127,7 +176,7 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 			}
 		}
 
-		rawConn, err := dialer.Dial(ctx, dialDest)
+		rawConn, err := dialer.Dial(ctx, h.nat64(ctx, dialDest))
 		if err != nil {
 			return err
 		}
@@ -149,11 +198,25 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 		var writer buf.Writer
 		if destination.Network == net.Network_TCP {
 			writer = buf.NewWriter(conn)
+			if h.config.Fragment != nil {
+				writer = &fragmentWriter{
+					ctx:    ctx,
+					config: h.config.Fragment,
+					conn:   conn,
+					writer: writer,
+				}
+			}
 		} else {
 			writer = &buf.SequentialWriter{Writer: conn}
 		}
 
-		if err := buf.Copy(input, writer, buf.UpdateActivity(timer)); err != nil {
+		var err error
+		if destination.Network == net.Network_TCP && h.config.Fragment == nil {
+			err = proxy.CopyToConn(ctx, proxy.SpliceUplink, conn, input, writer, timer)
+		} else {
+			err = buf.Copy(input, writer, buf.UpdateActivity(timer))
+		}
+		if err != nil {
 			return newError("failed to process request").Base(err)
 		}
 
@@ -163,13 +226,13 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	responseDone := func() error {
 		defer timer.SetTimeout(plcy.Timeouts.UplinkOnly)
 
-		var reader buf.Reader
+		var err error
 		if destination.Network == net.Network_TCP {
-			reader = buf.NewReader(conn)
+			err = proxy.CopyFromConn(ctx, proxy.SpliceDownlink, conn, buf.NewReader(conn), output, timer)
 		} else {
-			reader = buf.NewPacketReader(conn)
+			err = buf.Copy(buf.NewPacketReader(conn), output, buf.UpdateActivity(timer))
 		}
-		if err := buf.Copy(reader, output, buf.UpdateActivity(timer)); err != nil {
+		if err != nil {
 			return newError("failed to process response").Base(err)
 		}
 
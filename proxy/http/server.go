@@ -94,6 +94,13 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn internet
 
 	reader := bufio.NewReaderSize(readerOnly{conn}, buf.Size)
 
+	var release func()
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
+
 Start:
 	if err := conn.SetReadDeadline(time.Now().Add(s.policy().Timeouts.Handshake)); err != nil {
 		newError("failed to set read deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
@@ -118,6 +125,21 @@ Start:
 		}
 	}
 
+	if release == nil && inbound != nil {
+		release, err = policy.AcquireUser(s.policyManager, inbound.User, inbound.Source.Address)
+		if err != nil {
+			log.Record(&log.AccessMessage{
+				From:   conn.RemoteAddr(),
+				To:     request.URL,
+				Status: log.AccessRejected,
+				Reason: err,
+				Email:  inbound.User.Email,
+			})
+			conn.Write([]byte("HTTP/1.1 429 Too Many Requests\r\nConnection: close\r\n\r\n")) // nolint: errcheck
+			return newError("rejected request from ", conn.RemoteAddr()).Base(err)
+		}
+	}
+
 	newError("request to Method [", request.Method, "] Host [", request.Host, "] with URL [", request.URL, "]").WriteToLog(session.ExportIDToError(ctx))
 	if err := conn.SetReadDeadline(time.Time{}); err != nil {
 		newError("failed to clear read deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
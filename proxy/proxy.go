@@ -24,6 +24,16 @@ type Inbound interface {
 	Process(context.Context, net.Network, internet.Connection, routing.Dispatcher) error
 }
 
+// TCPListenRedirector is the interface for Inbounds that receive TCP connections through a local process, such as a SIP003 plugin.
+// The process listens on the configured address of the inbound, so the inbound listens on the redirected address instead.
+type TCPListenRedirector interface {
+	// RedirectTCPListen returns the address to listen on, in place of the given one.
+	// A port of 0 means a free port picked by the system when listening, which is then passed to ServeTCPListen.
+	RedirectTCPListen(net.Address, net.Port) (net.Address, net.Port, error)
+	// ServeTCPListen starts receiving connections on the given address and port, and forwarding them to the local port that the inbound listens on.
+	ServeTCPListen(address net.Address, port net.Port, localPort net.Port) error
+}
+
 // An Outbound process outbound connections.
 type Outbound interface {
 	// Process processes the given connection. The given dialer may be used to dial a system outbound connection.
@@ -37,6 +47,8 @@ type UserManager interface {
 
 	// RemoveUser removes a user by email.
 	RemoveUser(context.Context, string) error
+	// GetUsers returns all users.
+	GetUsers(context.Context) []*protocol.MemoryUser
 }
 
 type GetInbound interface {
@@ -8,6 +8,7 @@ import (
 	"v2ray.com/core"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/errors"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/common/retry"
@@ -23,6 +24,8 @@ import (
 type Client struct {
 	serverPicker  protocol.ServerPicker
 	policyManager policy.Manager
+	// plugins are SIP003 plugins of each server, by the destination of the server.
+	plugins map[net.Destination]*PluginRunner
 }
 
 // NewClient create a new Shadowsocks client.
@@ -44,9 +47,51 @@ func NewClient(ctx context.Context, config *ClientConfig) (*Client, error) {
 		serverPicker:  protocol.NewRoundRobinServerPicker(serverList),
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 	}
+
+	if config.Plugin != nil && config.Plugin.Path != "" {
+		client.plugins = make(map[net.Destination]*PluginRunner)
+		for _, rec := range config.Server {
+			dest := net.TCPDestination(rec.Address.AsAddress(), net.Port(rec.Port))
+			if _, found := client.plugins[dest]; found {
+				continue
+			}
+			client.plugins[dest] = &PluginRunner{
+				Config:     config.Plugin,
+				RemoteHost: pluginHost(dest.Address),
+				RemotePort: dest.Port,
+				LocalHost:  pluginHost(net.LocalHostIP),
+			}
+		}
+	}
+
 	return client, nil
 }
 
+// Start implements common.Runnable. Local ports of plugins are picked right before the plugins start, as the plugins
+// bind them, not the client.
+func (c *Client) Start() error {
+	for _, plugin := range c.plugins {
+		localPort, err := pickLocalPort()
+		if err != nil {
+			return err
+		}
+		plugin.LocalPort = localPort
+		if err := plugin.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements common.Closable.
+func (c *Client) Close() error {
+	var errs []error
+	for _, plugin := range c.plugins {
+		errs = append(errs, plugin.Close())
+	}
+	return errors.Combine(errs...)
+}
+
 // Process implements OutboundHandler.Process().
 func (c *Client) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
 	outbound := session.OutboundFromContext(ctx)
@@ -63,6 +108,9 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 		server = c.serverPicker.PickServer()
 		dest := server.Destination()
 		dest.Network = network
+		if plugin, found := c.plugins[server.Destination()]; found && network == net.Network_TCP {
+			dest = net.TCPDestination(net.LocalHostIP, plugin.LocalPort)
+		}
 		rawConn, err := dialer.Dial(ctx, dest)
 		if err != nil {
 			return err
@@ -102,7 +150,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 
 	if request.Command == protocol.RequestCommandTCP {
 		bufferedWriter := buf.NewBufferedWriter(buf.NewWriter(conn))
-		bodyWriter, err := WriteTCPRequest(request, bufferedWriter)
+		bodyWriter, requestSalt, err := WriteTCPRequest(request, bufferedWriter)
 		if err != nil {
 			return newError("failed to write request").Base(err)
 		}
@@ -119,7 +167,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 		responseDone := func() error {
 			defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
 
-			responseReader, err := ReadTCPResponse(user, conn)
+			responseReader, err := ReadTCPResponse(user, requestSalt, conn)
 			if err != nil {
 				return err
 			}
@@ -136,10 +184,12 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	}
 
 	if request.Command == protocol.RequestCommandUDP {
+		udpSession := NewUDPSession(false)
 
 		writer := &buf.SequentialWriter{Writer: &UDPWriter{
 			Writer:  conn,
 			Request: request,
+			Session: udpSession,
 		}}
 
 		requestDone := func() error {
@@ -155,8 +205,9 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 			defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
 
 			reader := &UDPReader{
-				Reader: conn,
-				User:   user,
+				Reader:  conn,
+				User:    user,
+				Session: udpSession,
 			}
 
 			if err := buf.Copy(reader, link.Writer, buf.UpdateActivity(timer)); err != nil {
@@ -6,13 +6,18 @@ import (
 	"crypto/cipher"
 	"crypto/md5"
 	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
 	"io"
 
+	"github.com/golang/protobuf/proto"
 	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/hkdf"
+	"lukechampine.com/blake3"
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/bytespool"
 	"v2ray.com/core/common/crypto"
 	"v2ray.com/core/common/protocol"
 )
@@ -21,6 +26,18 @@ import (
 type MemoryAccount struct {
 	Cipher Cipher
 	Key    []byte
+
+	// Password and CipherType are kept for converting back to Account.
+	Password   string
+	CipherType CipherType
+}
+
+// ToProto implements protocol.ToProtoAccount.ToProto().
+func (a *MemoryAccount) ToProto() proto.Message {
+	return &Account{
+		Password:   a.Password,
+		CipherType: a.CipherType,
+	}
 }
 
 // Equals implements protocol.Account.Equals().
@@ -73,6 +90,22 @@ func (a *Account) getCipher() (Cipher, error) {
 			IVBytes:         32,
 			AEADAuthCreator: createChacha20Poly1305,
 		}, nil
+	case CipherType_BLAKE3_AES_128_GCM:
+		return &Cipher2022{
+			KeyBytes:        16,
+			AEADAuthCreator: createAesGcm,
+		}, nil
+	case CipherType_BLAKE3_AES_256_GCM:
+		return &Cipher2022{
+			KeyBytes:        32,
+			AEADAuthCreator: createAesGcm,
+		}, nil
+	case CipherType_BLAKE3_CHACHA20_POLY1305:
+		return &Cipher2022{
+			KeyBytes:        32,
+			AEADAuthCreator: createChacha20Poly1305,
+			XChaCha:         true,
+		}, nil
 	case CipherType_NONE:
 		return NoneCipher{}, nil
 	default:
@@ -86,9 +119,23 @@ func (a *Account) AsAccount() (protocol.Account, error) {
 	if err != nil {
 		return nil, newError("failed to get cipher").Base(err)
 	}
+	var key []byte
+	if _, ok := cipher.(*Cipher2022); ok {
+		key, err = base64.StdEncoding.DecodeString(a.Password)
+		if err != nil {
+			return nil, newError("failed to decode pre-shared key").Base(err)
+		}
+		if int32(len(key)) != cipher.KeySize() {
+			return nil, newError("invalid pre-shared key length: ", len(key), ", expected ", cipher.KeySize())
+		}
+	} else {
+		key = passwordToCipherKey([]byte(a.Password), cipher.KeySize())
+	}
 	return &MemoryAccount{
-		Cipher: cipher,
-		Key:    passwordToCipherKey([]byte(a.Password), cipher.KeySize()),
+		Cipher:     cipher,
+		Key:        key,
+		Password:   a.Password,
+		CipherType: a.CipherType,
 	}, nil
 }
 
@@ -218,6 +265,104 @@ func (c *AEADCipher) DecodePacket(key []byte, b *buf.Buffer) error {
 	return nil
 }
 
+// Cipher2022 represents the Shadowsocks 2022 ciphers specified in SIP022. The key is a pre-shared key,
+// and session keys are derived from it with BLAKE3.
+type Cipher2022 struct {
+	KeyBytes        int32
+	AEADAuthCreator func(key []byte) cipher.AEAD
+	// XChaCha indicates that UDP packets are sealed with XChaCha20-Poly1305 instead of AES.
+	XChaCha bool
+}
+
+func (*Cipher2022) IsAEAD() bool {
+	return true
+}
+
+func (c *Cipher2022) KeySize() int32 {
+	return c.KeyBytes
+}
+
+// IVSize returns the size of salt, which is the same as the key size.
+func (c *Cipher2022) IVSize() int32 {
+	return c.KeyBytes
+}
+
+func (c *Cipher2022) createAuthenticator(key []byte, salt []byte) *crypto.AEADAuthenticator {
+	return &crypto.AEADAuthenticator{
+		AEAD:           c.AEADAuthCreator(sessionKey2022(key, salt, c.KeyBytes)),
+		NonceGenerator: crypto.GenerateInitialAEADNonce(),
+	}
+}
+
+func (c *Cipher2022) NewEncryptionWriter(key []byte, iv []byte, writer io.Writer) (buf.Writer, error) {
+	return newChunkWriter2022(c.createAuthenticator(key, iv), writer), nil
+}
+
+func (c *Cipher2022) NewDecryptionReader(key []byte, iv []byte, reader io.Reader) (buf.Reader, error) {
+	return &chunkReader2022{
+		auth:   c.createAuthenticator(key, iv),
+		reader: reader,
+	}, nil
+}
+
+// EncodePacket implements Cipher. Shadowsocks 2022 packets can only be encoded within a UDPSession.
+func (c *Cipher2022) EncodePacket(key []byte, b *buf.Buffer) error {
+	return newError("Shadowsocks 2022 packets require a UDP session")
+}
+
+// DecodePacket implements Cipher. Shadowsocks 2022 packets can only be decoded within a UDPSession.
+func (c *Cipher2022) DecodePacket(key []byte, b *buf.Buffer) error {
+	return newError("Shadowsocks 2022 packets require a UDP session")
+}
+
+// sessionKey2022 derives the key of a session from the pre-shared key and the salt or session ID.
+func sessionKey2022(psk []byte, salt []byte, keySize int32) []byte {
+	material := make([]byte, 0, len(psk)+len(salt))
+	material = append(material, psk...)
+	material = append(material, salt...)
+	key := make([]byte, keySize)
+	blake3.DeriveKey(key, "shadowsocks 2022 session subkey", material)
+	return key
+}
+
+func newChunkWriter2022(auth *crypto.AEADAuthenticator, writer io.Writer) buf.Writer {
+	return crypto.NewAuthenticationWriter(auth, &crypto.AEADChunkSizeParser{
+		Auth: auth,
+	}, writer, protocol.TransferTypeStream, nil)
+}
+
+// chunkReader2022 reads AEAD chunks of Shadowsocks 2022. Unlike other AEAD ciphers, a chunk may hold
+// up to 0xFFFF bytes of payload.
+type chunkReader2022 struct {
+	auth   *crypto.AEADAuthenticator
+	reader io.Reader
+	size   [2 + 16]byte
+}
+
+func (r *chunkReader2022) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	size, err := openChunk2022(r.auth, r.reader, r.size[:2+r.auth.Overhead()])
+	if err != nil {
+		return nil, err
+	}
+	n := int32(binary.BigEndian.Uint16(size)) + int32(r.auth.Overhead())
+	b := bytespool.Alloc(n)
+	defer bytespool.Free(b)
+
+	payload, err := openChunk2022(r.auth, r.reader, b[:n])
+	if err != nil {
+		return nil, err
+	}
+	return buf.MergeBytes(nil, payload), nil
+}
+
+// openChunk2022 reads a sealed chunk that fills b, and opens it in place.
+func openChunk2022(auth *crypto.AEADAuthenticator, reader io.Reader, b []byte) ([]byte, error) {
+	if _, err := io.ReadFull(reader, b); err != nil {
+		return nil, err
+	}
+	return auth.Open(b[:0], b)
+}
+
 type ChaCha20 struct {
 	IVBytes int32
 }
@@ -0,0 +1,175 @@
+// +build !confonly
+
+package shadowsocks
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/net"
+)
+
+const (
+	pluginRestartDelay    = time.Second
+	pluginMaxRestartDelay = time.Minute
+	// pluginStableTime is the time after which a running plugin is considered healthy, and the restart delay is reset.
+	pluginStableTime = time.Minute
+)
+
+// PluginRunner runs a SIP003 plugin process, and restarts it when it exits unexpectedly.
+//
+// On client side, the plugin listens on the local address and connects to the remote address of the server.
+// On server side, the plugin listens on the remote address and connects to the local address of the server.
+type PluginRunner struct {
+	Config     *Plugin
+	RemoteHost string
+	RemotePort net.Port
+	LocalHost  string
+	LocalPort  net.Port
+
+	access  sync.Mutex
+	cmd     *exec.Cmd
+	closed  bool
+	started bool
+	closing chan struct{}
+	done    chan struct{}
+}
+
+func (r *PluginRunner) command() *exec.Cmd {
+	cmd := exec.Command(r.Config.Path, r.Config.Args...)
+	cmd.Env = append(os.Environ(),
+		"SS_REMOTE_HOST="+r.RemoteHost,
+		"SS_REMOTE_PORT="+r.RemotePort.String(),
+		"SS_LOCAL_HOST="+r.LocalHost,
+		"SS_LOCAL_PORT="+r.LocalPort.String(),
+		"SS_PLUGIN_OPTIONS="+r.Config.Options,
+	)
+	output := &pluginLogWriter{name: r.Config.Path}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	return cmd
+}
+
+// Start implements common.Runnable.
+func (r *PluginRunner) Start() error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	if r.started {
+		return newError("plugin ", r.Config.Path, " is already started")
+	}
+
+	cmd := r.command()
+	if err := cmd.Start(); err != nil {
+		return newError("failed to start plugin ", r.Config.Path).Base(err)
+	}
+	newError("plugin ", r.Config.Path, " started with local port ", r.LocalPort, " and remote address ", r.RemoteHost, ":", r.RemotePort).AtInfo().WriteToLog()
+
+	r.cmd = cmd
+	r.started = true
+	r.closing = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.supervise(cmd)
+	return nil
+}
+
+// supervise waits for the plugin process to exit, and restarts it until the runner is closed.
+func (r *PluginRunner) supervise(cmd *exec.Cmd) {
+	defer close(r.done)
+
+	delay := pluginRestartDelay
+	for {
+		startTime := time.Now()
+		err := cmd.Wait()
+
+		r.access.Lock()
+		if r.closed {
+			r.access.Unlock()
+			return
+		}
+		r.access.Unlock()
+
+		if time.Since(startTime) > pluginStableTime {
+			delay = pluginRestartDelay
+		}
+		newError("plugin ", r.Config.Path, " exited, restarting in ", delay).Base(err).AtWarning().WriteToLog()
+		select {
+		case <-time.After(delay):
+		case <-r.closing:
+			return
+		}
+		if delay *= 2; delay > pluginMaxRestartDelay {
+			delay = pluginMaxRestartDelay
+		}
+
+		r.access.Lock()
+		if r.closed {
+			r.access.Unlock()
+			return
+		}
+		cmd = r.command()
+		if err := cmd.Start(); err != nil {
+			r.access.Unlock()
+			newError("failed to restart plugin ", r.Config.Path).Base(err).AtError().WriteToLog()
+			continue
+		}
+		r.cmd = cmd
+		r.access.Unlock()
+	}
+}
+
+// Close implements common.Closable. It kills the plugin process and waits for it to exit.
+func (r *PluginRunner) Close() error {
+	r.access.Lock()
+	if r.closed || !r.started {
+		r.closed = true
+		r.access.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.closing)
+	cmd := r.cmd
+	r.access.Unlock()
+
+	if err := cmd.Process.Kill(); err != nil {
+		newError("failed to kill plugin ", r.Config.Path).Base(err).AtDebug().WriteToLog()
+	}
+	<-r.done
+	return nil
+}
+
+// pluginLogWriter writes the output of a plugin into log.
+type pluginLogWriter struct {
+	name string
+}
+
+func (w *pluginLogWriter) Write(b []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(b, "\r\n"), []byte("\n")) {
+		if l := strings.TrimSpace(string(line)); l != "" {
+			newError("[", w.name, "] ", l).AtInfo().WriteToLog()
+		}
+	}
+	return len(b), nil
+}
+
+// pluginHost returns the host of the given address in SIP003 environment variables.
+func pluginHost(address net.Address) string {
+	if address.Family().IsDomain() {
+		return address.Domain()
+	}
+	return address.IP().String()
+}
+
+// pickLocalPort returns a free TCP port on loopback address for plugins.
+func pickLocalPort() (net.Port, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, newError("failed to pick a local port").Base(err)
+	}
+	defer listener.Close()
+	return net.Port(listener.Addr().(*net.TCPAddr).Port), nil
+}
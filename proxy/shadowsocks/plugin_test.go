@@ -0,0 +1,95 @@
+package shadowsocks_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	. "v2ray.com/core/proxy/shadowsocks"
+	"v2ray.com/core/testing/servers/tcp"
+)
+
+func buildStubPlugin(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "plugin")
+	if runtime.GOOS == "windows" {
+		path += ".exe"
+	}
+	cmd := exec.Command("go", "build", "-o", path, "./testdata/plugin")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal("failed to build stub plugin: ", err, "\n", string(output))
+	}
+	return path
+}
+
+// echoThroughPlugin sends a message to the given local port and checks the echoed response, retrying until the plugin is ready.
+func echoThroughPlugin(port net.Port) error {
+	var err error
+	for i := 0; i < 50; i++ {
+		var conn net.Conn
+		conn, err = net.Dial("tcp", net.TCPDestination(net.LocalHostIP, port).NetAddr())
+		if err != nil {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		payload := []byte("plugin payload")
+		response := make([]byte, len(payload))
+		_, err = conn.Write(payload)
+		if err == nil {
+			_, err = io.ReadFull(conn, response)
+		}
+		conn.Close()
+		if err == nil && !bytes.Equal(response, payload) {
+			err = errors.New("unexpected response: " + string(response))
+		}
+		if err == nil {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return err
+}
+
+func TestPluginRunner(t *testing.T) {
+	pluginPath := buildStubPlugin(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte { return b },
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	localPort := tcp.PickPort()
+	runner := &PluginRunner{
+		Config: &Plugin{
+			Path:    pluginPath,
+			Options: "once",
+		},
+		RemoteHost: "127.0.0.1",
+		RemotePort: dest.Port,
+		LocalHost:  "127.0.0.1",
+		LocalPort:  localPort,
+	}
+	common.Must(runner.Start())
+
+	if err := echoThroughPlugin(localPort); err != nil {
+		t.Fatal("failed to connect through plugin: ", err)
+	}
+	// The stub plugin exits after the first connection, and is restarted by the runner.
+	if err := echoThroughPlugin(localPort); err != nil {
+		t.Fatal("failed to connect through restarted plugin: ", err)
+	}
+
+	common.Must(runner.Close())
+	if conn, err := net.Dial("tcp", net.TCPDestination(net.LocalHostIP, localPort).NetAddr()); err == nil {
+		conn.Close()
+		t.Error("plugin is still running after close")
+	}
+}
@@ -3,11 +3,8 @@
 package shadowsocks
 
 import (
-	"crypto/hmac"
+	"bytes"
 	"crypto/rand"
-	"crypto/sha256"
-	"hash"
-	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"v2ray.com/core/common/dice"
@@ -31,14 +28,10 @@ var addrParser = protocol.NewAddressParser(
 	}),
 )
 
-// ReadTCPSession reads a Shadowsocks TCP session from the given reader, returns its header and remaining parts.
-func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.RequestHeader, buf.Reader, error) {
-	account := user.Account.(*MemoryAccount)
-
-	hashkdf := hmac.New(func() hash.Hash { return sha256.New() }, []byte("SSBSKDF"))
-	hashkdf.Write(account.Key)
-
-	behaviorSeed := crc32.ChecksumIEEE(hashkdf.Sum(nil))
+// ReadTCPSession reads a Shadowsocks TCP session from the given reader, returns its header, remaining parts and
+// the salt of the session. When there are multiple users in the validator, the user is the one whose key decrypts the session.
+func ReadTCPSession(validator *Validator, reader io.Reader) (*protocol.RequestHeader, buf.Reader, []byte, error) {
+	behaviorSeed := validator.GetBehaviorSeed()
 
 	behaviorRand := dice.NewDeterministicDice(int64(behaviorSeed))
 	BaseDrainSize := behaviorRand.Roll(3266)
@@ -50,23 +43,69 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 	buffer := buf.New()
 	defer buffer.Release()
 
+	var user *protocol.MemoryUser
+	users := validator.GetAll()
+	switch len(users) {
+	case 0:
+		return nil, nil, nil, newError("no user is configured")
+	case 1:
+		user = users[0]
+	default:
+		// Read the salt and the first length chunk, to find out the user that encrypts it.
+		headerLen := validator.maxIVSize() + 2 + 16
+		if _, err := buffer.ReadFullFrom(reader, headerLen); err != nil {
+			readSizeRemain -= int(buffer.Len())
+			DrainConnN(reader, readSizeRemain)
+			return nil, nil, nil, newError("failed to read IV").Base(err)
+		}
+		matched, err := validator.matchTCP(buffer.Bytes())
+		if err != nil {
+			readSizeRemain -= int(buffer.Len())
+			DrainConnN(reader, readSizeRemain)
+			return nil, nil, nil, newError("failed to match user").Base(err)
+		}
+		user = matched
+		ivLen := user.Account.(*MemoryAccount).Cipher.IVSize()
+		reader = io.MultiReader(bytes.NewReader(append([]byte(nil), buffer.BytesFrom(ivLen)...)), reader)
+		buffer.Resize(0, ivLen)
+	}
+	account := user.Account.(*MemoryAccount)
+
 	ivLen := account.Cipher.IVSize()
 	var iv []byte
 	if ivLen > 0 {
-		if _, err := buffer.ReadFullFrom(reader, ivLen); err != nil {
-			readSizeRemain -= int(buffer.Len())
-			DrainConnN(reader, readSizeRemain)
-			return nil, nil, newError("failed to read IV").Base(err)
+		if buffer.IsEmpty() {
+			if _, err := buffer.ReadFullFrom(reader, ivLen); err != nil {
+				readSizeRemain -= int(buffer.Len())
+				DrainConnN(reader, readSizeRemain)
+				return nil, nil, nil, newError("failed to read IV").Base(err)
+			}
 		}
 
 		iv = append([]byte(nil), buffer.BytesTo(ivLen)...)
 	}
 
+	if cipher, ok := account.Cipher.(*Cipher2022); ok {
+		if !validator.checkSalt(iv) {
+			readSizeRemain -= int(buffer.Len())
+			DrainConnN(reader, readSizeRemain)
+			return nil, nil, nil, newError("replayed salt")
+		}
+		request, r, err := cipher.readRequest(account.Key, iv, reader)
+		if err != nil {
+			readSizeRemain -= int(buffer.Len())
+			DrainConnN(reader, readSizeRemain)
+			return nil, nil, nil, newError("failed to read request header").Base(err)
+		}
+		request.User = user
+		return request, r, iv, nil
+	}
+
 	r, err := account.Cipher.NewDecryptionReader(account.Key, iv, reader)
 	if err != nil {
 		readSizeRemain -= int(buffer.Len())
 		DrainConnN(reader, readSizeRemain)
-		return nil, nil, newError("failed to initialize decoding stream").Base(err).AtError()
+		return nil, nil, nil, newError("failed to initialize decoding stream").Base(err).AtError()
 	}
 	br := &buf.BufferedReader{Reader: r}
 
@@ -83,7 +122,7 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 	if err != nil {
 		readSizeRemain -= int(buffer.Len())
 		DrainConnN(reader, readSizeRemain)
-		return nil, nil, newError("failed to read address").Base(err)
+		return nil, nil, nil, newError("failed to read address").Base(err)
 	}
 
 	request.Address = addr
@@ -92,10 +131,10 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 	if request.Address == nil {
 		readSizeRemain -= int(buffer.Len())
 		DrainConnN(reader, readSizeRemain)
-		return nil, nil, newError("invalid remote address.")
+		return nil, nil, nil, newError("invalid remote address.")
 	}
 
-	return request, br, nil
+	return request, br, iv, nil
 }
 
 func DrainConnN(reader io.Reader, n int) error {
@@ -103,8 +142,8 @@ func DrainConnN(reader io.Reader, n int) error {
 	return err
 }
 
-// WriteTCPRequest writes Shadowsocks request into the given writer, and returns a writer for body.
-func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) (buf.Writer, error) {
+// WriteTCPRequest writes Shadowsocks request into the given writer, and returns a writer for body along with the salt of the request.
+func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) (buf.Writer, []byte, error) {
 	user := request.User
 	account := user.Account.(*MemoryAccount)
 
@@ -113,29 +152,39 @@ func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) (buf.Wri
 		iv = make([]byte, account.Cipher.IVSize())
 		common.Must2(rand.Read(iv))
 		if err := buf.WriteAllBytes(writer, iv); err != nil {
-			return nil, newError("failed to write IV")
+			return nil, nil, newError("failed to write IV")
 		}
 	}
 
+	if cipher, ok := account.Cipher.(*Cipher2022); ok {
+		w, err := cipher.writeRequest(account.Key, iv, request, writer)
+		if err != nil {
+			return nil, nil, newError("failed to write request header").Base(err)
+		}
+		return w, iv, nil
+	}
+
 	w, err := account.Cipher.NewEncryptionWriter(account.Key, iv, writer)
 	if err != nil {
-		return nil, newError("failed to create encoding stream").Base(err).AtError()
+		return nil, nil, newError("failed to create encoding stream").Base(err).AtError()
 	}
 
 	header := buf.New()
 
 	if err := addrParser.WriteAddressPort(header, request.Address, request.Port); err != nil {
-		return nil, newError("failed to write address").Base(err)
+		return nil, nil, newError("failed to write address").Base(err)
 	}
 
 	if err := w.WriteMultiBuffer(buf.MultiBuffer{header}); err != nil {
-		return nil, newError("failed to write header").Base(err)
+		return nil, nil, newError("failed to write header").Base(err)
 	}
 
-	return w, nil
+	return w, iv, nil
 }
 
-func ReadTCPResponse(user *protocol.MemoryUser, reader io.Reader) (buf.Reader, error) {
+// ReadTCPResponse reads Shadowsocks response from the given reader, and returns a reader for body. The request salt
+// is the one returned by WriteTCPRequest.
+func ReadTCPResponse(user *protocol.MemoryUser, requestSalt []byte, reader io.Reader) (buf.Reader, error) {
 	account := user.Account.(*MemoryAccount)
 
 	var iv []byte
@@ -146,10 +195,16 @@ func ReadTCPResponse(user *protocol.MemoryUser, reader io.Reader) (buf.Reader, e
 		}
 	}
 
+	if cipher, ok := account.Cipher.(*Cipher2022); ok {
+		return cipher.readResponse(account.Key, iv, requestSalt, reader)
+	}
+
 	return account.Cipher.NewDecryptionReader(account.Key, iv, reader)
 }
 
-func WriteTCPResponse(request *protocol.RequestHeader, writer io.Writer) (buf.Writer, error) {
+// WriteTCPResponse writes Shadowsocks response into the given writer, and returns a writer for body. The request salt
+// is the one returned by ReadTCPSession.
+func WriteTCPResponse(request *protocol.RequestHeader, requestSalt []byte, writer io.Writer) (buf.Writer, error) {
 	user := request.User
 	account := user.Account.(*MemoryAccount)
 
@@ -162,6 +217,10 @@ func WriteTCPResponse(request *protocol.RequestHeader, writer io.Writer) (buf.Wr
 		}
 	}
 
+	if cipher, ok := account.Cipher.(*Cipher2022); ok {
+		return cipher.newResponseWriter(account.Key, iv, requestSalt, writer), nil
+	}
+
 	return account.Cipher.NewEncryptionWriter(account.Key, iv, writer)
 }
 
@@ -188,6 +247,25 @@ func EncodeUDPPacket(request *protocol.RequestHeader, payload []byte) (*buf.Buff
 	return buffer, nil
 }
 
+// DecodeServerUDPPacket decodes a UDP packet from client within the given session. When there are multiple users
+// in the validator, the user is the one whose key decrypts the packet. The payload is consumed on success.
+func DecodeServerUDPPacket(validator *Validator, session *UDPSession, payload *buf.Buffer) (*protocol.RequestHeader, *buf.Buffer, error) {
+	users := validator.GetAll()
+	switch len(users) {
+	case 0:
+		return nil, nil, newError("no user is configured")
+	case 1:
+		return session.DecodePacket(users[0], payload)
+	}
+
+	user, data, err := validator.matchUDP(payload)
+	if err != nil {
+		return nil, nil, newError("failed to decrypt UDP payload").Base(err)
+	}
+	payload.Release()
+	return parseUDPPacket(user, data)
+}
+
 func DecodeUDPPacket(user *protocol.MemoryUser, payload *buf.Buffer) (*protocol.RequestHeader, *buf.Buffer, error) {
 	account := user.Account.(*MemoryAccount)
 
@@ -202,6 +280,11 @@ func DecodeUDPPacket(user *protocol.MemoryUser, payload *buf.Buffer) (*protocol.
 		return nil, nil, newError("failed to decrypt UDP payload").Base(err)
 	}
 
+	return parseUDPPacket(user, payload)
+}
+
+// parseUDPPacket parses the request header from a decrypted UDP packet.
+func parseUDPPacket(user *protocol.MemoryUser, payload *buf.Buffer) (*protocol.RequestHeader, *buf.Buffer, error) {
 	request := &protocol.RequestHeader{
 		Version: Version,
 		User:    user,
@@ -224,6 +307,8 @@ func DecodeUDPPacket(user *protocol.MemoryUser, payload *buf.Buffer) (*protocol.
 type UDPReader struct {
 	Reader io.Reader
 	User   *protocol.MemoryUser
+	// Session is required for Shadowsocks 2022 ciphers.
+	Session *UDPSession
 }
 
 func (v *UDPReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
@@ -233,7 +318,12 @@ func (v *UDPReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
 		buffer.Release()
 		return nil, err
 	}
-	_, payload, err := DecodeUDPPacket(v.User, buffer)
+	var payload *buf.Buffer
+	if v.Session != nil {
+		_, payload, err = v.Session.DecodePacket(v.User, buffer)
+	} else {
+		_, payload, err = DecodeUDPPacket(v.User, buffer)
+	}
 	if err != nil {
 		buffer.Release()
 		return nil, err
@@ -244,11 +334,19 @@ func (v *UDPReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
 type UDPWriter struct {
 	Writer  io.Writer
 	Request *protocol.RequestHeader
+	// Session is required for Shadowsocks 2022 ciphers.
+	Session *UDPSession
 }
 
 // Write implements io.Writer.
 func (w *UDPWriter) Write(payload []byte) (int, error) {
-	packet, err := EncodeUDPPacket(w.Request, payload)
+	var packet *buf.Buffer
+	var err error
+	if w.Session != nil {
+		packet, err = w.Session.EncodePacket(w.Request, payload)
+	} else {
+		packet, err = EncodeUDPPacket(w.Request, payload)
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -112,12 +112,14 @@ func TestTCPRequest(t *testing.T) {
 		cache := buf.New()
 		defer cache.Release()
 
-		writer, err := WriteTCPRequest(request, cache)
+		writer, _, err := WriteTCPRequest(request, cache)
 		common.Must(err)
 
 		common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{data}))
 
-		decodedRequest, reader, err := ReadTCPSession(request.User, cache)
+		validator := new(Validator)
+		common.Must(validator.Add(request.User))
+		decodedRequest, reader, _, err := ReadTCPSession(validator, cache)
 		common.Must(err)
 		if r := cmp.Diff(decodedRequest, request); r != "" {
 			t.Error("request: ", r)
@@ -185,3 +187,118 @@ func TestUDPReaderWriter(t *testing.T) {
 		}
 	}
 }
+
+func TestTCPRequestResponse2022(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Email: "love@v2ray.com",
+		Account: toAccount(&Account{
+			Password:   "AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA=",
+			CipherType: CipherType_BLAKE3_AES_256_GCM,
+		}),
+	}
+	request := &protocol.RequestHeader{
+		Version: Version,
+		Command: protocol.RequestCommandTCP,
+		Address: net.DomainAddress("v2ray.com"),
+		Port:    443,
+		User:    user,
+	}
+
+	cache := buf.New()
+	defer cache.Release()
+
+	writer, requestSalt, err := WriteTCPRequest(request, cache)
+	common.Must(err)
+	data := buf.New()
+	common.Must2(data.WriteString("request"))
+	common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{data}))
+
+	validator := new(Validator)
+	common.Must(validator.Add(user))
+	decodedRequest, reader, decodedSalt, err := ReadTCPSession(validator, cache)
+	common.Must(err)
+	if decodedRequest.User != user || decodedRequest.Destination() != request.Destination() {
+		t.Error("unexpected request: ", decodedRequest)
+	}
+	mb, err := reader.ReadMultiBuffer()
+	common.Must(err)
+	if mb.String() != "request" {
+		t.Error("unexpected request payload: ", mb.String())
+	}
+
+	response := buf.New()
+	defer response.Release()
+
+	writer, err = WriteTCPResponse(decodedRequest, decodedSalt, response)
+	common.Must(err)
+	for _, s := range []string{"response", " continued"} {
+		data := buf.New()
+		common.Must2(data.WriteString(s))
+		common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{data}))
+	}
+
+	reader, err = ReadTCPResponse(user, requestSalt, response)
+	common.Must(err)
+	var payload buf.MultiBuffer
+	for !response.IsEmpty() {
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		payload = append(payload, mb...)
+	}
+	if payload.String() != "response continued" {
+		t.Error("unexpected response payload: ", payload.String())
+	}
+
+	if _, err := ReadTCPResponse(user, []byte("wrong salt"), response); err == nil {
+		t.Error("expected error on wrong request salt")
+	}
+}
+
+func TestUDPSession2022(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Account: toAccount(&Account{
+			Password:   "AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA=",
+			CipherType: CipherType_BLAKE3_CHACHA20_POLY1305,
+		}),
+	}
+	validator := new(Validator)
+	common.Must(validator.Add(user))
+
+	client := NewUDPSession(false)
+	server := NewUDPSession(true)
+	request := &protocol.RequestHeader{
+		Version: Version,
+		Command: protocol.RequestCommandUDP,
+		Address: net.DomainAddress("v2ray.com"),
+		Port:    53,
+		User:    user,
+	}
+
+	for _, s := range []string{"query 1", "query 2"} {
+		packet, err := client.EncodePacket(request, []byte(s))
+		common.Must(err)
+		replayed := buf.New()
+		common.Must2(replayed.Write(packet.Bytes()))
+
+		decodedRequest, payload, err := DecodeServerUDPPacket(validator, server, packet)
+		common.Must(err)
+		if payload.String() != s {
+			t.Error("unexpected payload: ", payload.String())
+		}
+		if _, _, err := DecodeServerUDPPacket(validator, server, replayed); err == nil {
+			t.Error("expected error on replayed packet")
+		}
+
+		packet, err = server.EncodePacket(decodedRequest, []byte("answer"))
+		common.Must(err)
+		_, payload, err = client.DecodePacket(user, packet)
+		common.Must(err)
+		if payload.String() != "answer" {
+			t.Error("unexpected payload: ", payload.String())
+		}
+	}
+
+	if _, err := EncodeUDPPacket(request, []byte("payload")); err == nil {
+		t.Error("expected error on encoding without session")
+	}
+}
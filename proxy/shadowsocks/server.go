@@ -24,31 +24,93 @@ import (
 
 type Server struct {
 	config        *ServerConfig
-	user          *protocol.MemoryUser
+	validator     *Validator
 	policyManager policy.Manager
+	plugin        *PluginRunner
 }
 
 // NewServer create a new Shadowsocks server.
 func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
-	if config.GetUser() == nil {
+	users := config.Users
+	if config.User != nil {
+		users = append([]*protocol.User{config.User}, users...)
+	}
+	if len(users) == 0 {
 		return nil, newError("user is not specified")
 	}
 
-	mUser, err := config.User.ToMemoryUser()
-	if err != nil {
-		return nil, newError("failed to parse user account").Base(err)
+	validator := new(Validator)
+	for _, user := range users {
+		mUser, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, newError("failed to parse user account").Base(err)
+		}
+		if err := validator.Add(mUser); err != nil {
+			return nil, newError("failed to add user").Base(err)
+		}
 	}
 
 	v := core.MustFromContext(ctx)
 	s := &Server{
 		config:        config,
-		user:          mUser,
+		validator:     validator,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 	}
 
 	return s, nil
 }
 
+// RedirectTCPListen implements proxy.TCPListenRedirector. When a plugin is configured, the plugin listens on the given
+// address, and forwards connections to the server on a free local port.
+func (s *Server) RedirectTCPListen(address net.Address, port net.Port) (net.Address, net.Port, error) {
+	if s.config.Plugin == nil || s.config.Plugin.Path == "" {
+		return address, port, nil
+	}
+	if s.plugin != nil {
+		return nil, 0, newError("plugin only supports listening on a single port")
+	}
+
+	s.plugin = &PluginRunner{
+		Config:     s.config.Plugin,
+		RemoteHost: pluginHost(address),
+		RemotePort: port,
+		LocalHost:  pluginHost(net.LocalHostIP),
+	}
+	return net.LocalHostIP, 0, nil
+}
+
+// ServeTCPListen implements proxy.TCPListenRedirector. It starts the plugin with the local port that the server listens on.
+func (s *Server) ServeTCPListen(address net.Address, port net.Port, localPort net.Port) error {
+	if s.plugin == nil {
+		return newError("plugin is not configured")
+	}
+	s.plugin.LocalPort = localPort
+	return s.plugin.Start()
+}
+
+// Close implements common.Closable.
+func (s *Server) Close() error {
+	if s.plugin != nil {
+		return s.plugin.Close()
+	}
+	return nil
+}
+
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	return s.validator.Add(u)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	return s.validator.Del(e)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
 func (s *Server) Network() []net.Network {
 	list := s.config.Network
 	if len(list) == 0 {
@@ -72,6 +134,7 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn internet
 }
 
 func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection, dispatcher routing.Dispatcher) error {
+	udpSession := NewUDPSession(true)
 	udpServer := udp.NewDispatcher(dispatcher, func(ctx context.Context, packet *udp_proto.Packet) {
 		request := protocol.RequestHeaderFromContext(ctx)
 		if request == nil {
@@ -79,7 +142,7 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 		}
 
 		payload := packet.Payload
-		data, err := EncodeUDPPacket(request, payload.Bytes())
+		data, err := udpSession.EncodePacket(request, payload.Bytes())
 		payload.Release()
 		if err != nil {
 			newError("failed to encode UDP packet").Base(err).AtWarning().WriteToLog(session.ExportIDToError(ctx))
@@ -94,7 +157,14 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 	if inbound == nil {
 		panic("no inbound metadata")
 	}
-	inbound.User = s.user
+
+	var release func()
+	var rejected error
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
 
 	reader := buf.NewPacketReader(conn)
 	for {
@@ -104,7 +174,7 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 		}
 
 		for _, payload := range mpayload {
-			request, data, err := DecodeUDPPacket(s.user, payload)
+			request, data, err := DecodeServerUDPPacket(s.validator, udpSession, payload)
 			if err != nil {
 				if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.IsValid() {
 					newError("dropping invalid UDP packet from: ", inbound.Source).Base(err).WriteToLog(session.ExportIDToError(ctx))
@@ -119,8 +189,27 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 				continue
 			}
 
-			currentPacketCtx := ctx
 			dest := request.Destination()
+			if release == nil && rejected == nil {
+				inbound.User = request.User
+				release, err = policy.AcquireUser(s.policyManager, request.User, inbound.Source.Address)
+				if err != nil {
+					log.Record(&log.AccessMessage{
+						From:   inbound.Source,
+						To:     dest,
+						Status: log.AccessRejected,
+						Reason: err,
+						Email:  request.User.Email,
+					})
+					rejected = newError("rejected UDP session from ", inbound.Source).Base(err)
+				}
+			}
+			if rejected != nil {
+				payload.Release()
+				continue
+			}
+
+			currentPacketCtx := ctx
 			if inbound.Source.IsValid() {
 				currentPacketCtx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 					From:   inbound.Source,
@@ -135,17 +224,30 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 			currentPacketCtx = protocol.ContextWithRequestHeader(currentPacketCtx, request)
 			udpServer.Dispatch(currentPacketCtx, dest, data)
 		}
+
+		if rejected != nil {
+			return rejected
+		}
 	}
 
 	return nil
 }
 
+// handshakePolicy returns the policy for reading a request, before its user is known.
+// With a single user, it is the policy of that user.
+func (s *Server) handshakePolicy() policy.Session {
+	if users := s.validator.GetAll(); len(users) == 1 {
+		return s.policyManager.ForUser(users[0].Level, users[0].Email)
+	}
+	return s.policyManager.ForLevel(0)
+}
+
 func (s *Server) handleConnection(ctx context.Context, conn internet.Connection, dispatcher routing.Dispatcher) error {
-	sessionPolicy := s.policyManager.ForLevel(s.user.Level)
+	sessionPolicy := s.handshakePolicy()
 	conn.SetReadDeadline(time.Now().Add(sessionPolicy.Timeouts.Handshake))
 
 	bufferedReader := buf.BufferedReader{Reader: buf.NewReader(conn)}
-	request, bodyReader, err := ReadTCPSession(s.user, &bufferedReader)
+	request, bodyReader, requestSalt, err := ReadTCPSession(s.validator, &bufferedReader)
 	if err != nil {
 		log.Record(&log.AccessMessage{
 			From:   conn.RemoteAddr(),
@@ -156,14 +258,28 @@ func (s *Server) handleConnection(ctx context.Context, conn internet.Connection,
 		return newError("failed to create request from: ", conn.RemoteAddr()).Base(err)
 	}
 	conn.SetReadDeadline(time.Time{})
+	sessionPolicy = s.policyManager.ForUser(request.User.Level, request.User.Email)
 
 	inbound := session.InboundFromContext(ctx)
 	if inbound == nil {
 		panic("no inbound metadata")
 	}
-	inbound.User = s.user
+	inbound.User = request.User
 
 	dest := request.Destination()
+	release, err := policy.AcquireUser(s.policyManager, request.User, inbound.Source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   conn.RemoteAddr(),
+			To:     dest,
+			Status: log.AccessRejected,
+			Reason: err,
+			Email:  request.User.Email,
+		})
+		return newError("rejected request from ", conn.RemoteAddr()).Base(err)
+	}
+	defer release()
+
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   conn.RemoteAddr(),
 		To:     dest,
@@ -186,7 +302,7 @@ func (s *Server) handleConnection(ctx context.Context, conn internet.Connection,
 		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
 
 		bufferedWriter := buf.NewBufferedWriter(buf.NewWriter(conn))
-		responseWriter, err := WriteTCPResponse(request, bufferedWriter)
+		responseWriter, err := WriteTCPResponse(request, requestSalt, bufferedWriter)
 		if err != nil {
 			return newError("failed to write response").Base(err)
 		}
@@ -0,0 +1,294 @@
+package shadowsocks_test
+
+import (
+	"context"
+	gonet "net"
+	"sync"
+	"testing"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/common/session"
+	feature_policy "v2ray.com/core/features/policy"
+	"v2ray.com/core/features/routing"
+	. "v2ray.com/core/proxy/shadowsocks"
+	"v2ray.com/core/transport"
+	"v2ray.com/core/transport/pipe"
+)
+
+const v2rayKey core.V2rayKey = 1
+
+// dispatchRecord is what echoDispatcher sees in a dispatched request.
+type dispatchRecord struct {
+	email  string
+	buffer int32
+}
+
+// echoDispatcher is a routing.Dispatcher that echoes payloads back, and records the user and buffer policy of each request.
+type echoDispatcher struct {
+	sync.Mutex
+	records []dispatchRecord
+}
+
+func (*echoDispatcher) Type() interface{} {
+	return routing.DispatcherType()
+}
+
+func (*echoDispatcher) Start() error {
+	return nil
+}
+
+func (*echoDispatcher) Close() error {
+	return nil
+}
+
+func (d *echoDispatcher) Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+	record := dispatchRecord{
+		buffer: feature_policy.BufferPolicyFromContext(ctx).PerConnection,
+	}
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil {
+		record.email = inbound.User.Email
+	}
+	d.Lock()
+	d.records = append(d.records, record)
+	d.Unlock()
+
+	uplinkReader, uplinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	downlinkReader, downlinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	go func() {
+		buf.Copy(uplinkReader, downlinkWriter) // nolint: errcheck
+		downlinkWriter.Close()
+	}()
+	return &transport.Link{Reader: downlinkReader, Writer: uplinkWriter}, nil
+}
+
+func (d *echoDispatcher) takeRecords() []dispatchRecord {
+	d.Lock()
+	defer d.Unlock()
+	records := d.records
+	d.records = nil
+	return records
+}
+
+func newAEADUser(email string, level uint32, password string) *protocol.MemoryUser {
+	return &protocol.MemoryUser{
+		Email: email,
+		Level: level,
+		Account: toAccount(&Account{
+			Password:   password,
+			CipherType: CipherType_AES_128_GCM,
+		}),
+	}
+}
+
+func newMultiUserServer() (*Server, *core.Instance, context.Context) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{serial.ToTypedMessage(&policy.Config{
+			Level: map[uint32]*policy.Policy{
+				1: {Buffer: &policy.Policy_Buffer{Connection: 1024}},
+				2: {
+					Buffer:      &policy.Policy_Buffer{Connection: 4096},
+					Concurrency: &policy.Policy_Concurrency{Connections: 1},
+				},
+			},
+		})},
+	})
+	common.Must(err)
+	ctx := context.WithValue(context.Background(), v2rayKey, v)
+
+	server, err := NewServer(ctx, &ServerConfig{
+		Users: []*protocol.User{
+			{Email: "a@v2fly.org", Level: 1, Account: serial.ToTypedMessage(&Account{Password: "password-a", CipherType: CipherType_AES_128_GCM})},
+			{Email: "b@v2fly.org", Level: 2, Account: serial.ToTypedMessage(&Account{Password: "password-b", CipherType: CipherType_AES_128_GCM})},
+		},
+		UdpEnabled: true,
+	})
+	common.Must(err)
+	return server, v, ctx
+}
+
+func inboundContext(ctx context.Context) context.Context {
+	return session.ContextWithInbound(ctx, &session.Inbound{
+		Source: net.TCPDestination(net.LocalHostIP, 10000),
+	})
+}
+
+func tcpRequest(user *protocol.MemoryUser) *protocol.RequestHeader {
+	return &protocol.RequestHeader{
+		Version: Version,
+		Command: protocol.RequestCommandTCP,
+		Address: net.LocalHostIP,
+		Port:    1234,
+		User:    user,
+	}
+}
+
+// tcpRoundTrip sends payload through a TCP session of user, and returns the echoed response.
+func tcpRoundTrip(ctx context.Context, server *Server, dispatcher routing.Dispatcher, user *protocol.MemoryUser, payload string) string {
+	clientConn, serverConn := gonet.Pipe()
+	defer clientConn.Close()
+
+	processErr := make(chan error, 1)
+	go func() {
+		processErr <- server.Process(inboundContext(ctx), net.Network_TCP, serverConn, dispatcher)
+		serverConn.Close()
+	}()
+
+	bufferedWriter := buf.NewBufferedWriter(buf.NewWriter(clientConn))
+	writer, salt, err := WriteTCPRequest(tcpRequest(user), bufferedWriter)
+	common.Must(err)
+	common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte(payload))))
+	common.Must(bufferedWriter.SetBuffered(false))
+
+	reader, err := ReadTCPResponse(user, salt, clientConn)
+	common.Must(err)
+	mb, err := reader.ReadMultiBuffer()
+	common.Must(err)
+	response := mb.String()
+	buf.ReleaseMulti(mb)
+
+	clientConn.Close()
+	<-processErr
+	return response
+}
+
+func udpRequest(user *protocol.MemoryUser) *protocol.RequestHeader {
+	return &protocol.RequestHeader{
+		Version: Version,
+		Command: protocol.RequestCommandUDP,
+		Address: net.LocalHostIP,
+		Port:    1234,
+		User:    user,
+	}
+}
+
+// udpRoundTrip sends payload in a UDP session of user. It returns the echoed response, or the error from Process if the session ends without response.
+func udpRoundTrip(ctx context.Context, server *Server, dispatcher routing.Dispatcher, user *protocol.MemoryUser, payload string) (string, error) {
+	clientConn, serverConn := gonet.Pipe()
+	defer clientConn.Close()
+
+	processErr := make(chan error, 1)
+	go func() {
+		processErr <- server.Process(inboundContext(ctx), net.Network_UDP, serverConn, dispatcher)
+		serverConn.Close()
+	}()
+
+	packet, err := EncodeUDPPacket(udpRequest(user), []byte(payload))
+	common.Must(err)
+	defer packet.Release()
+	common.Must2(clientConn.Write(packet.Bytes()))
+
+	response := make([]byte, buf.Size)
+	n, err := clientConn.Read(response)
+	if err != nil {
+		return "", <-processErr
+	}
+	b := buf.New()
+	defer b.Release()
+	common.Must2(b.Write(response[:n]))
+	_, data, err := DecodeUDPPacket(user, b)
+	if err != nil {
+		return "", err
+	}
+	defer data.Release()
+
+	clientConn.Close()
+	<-processErr
+	return data.String(), nil
+}
+
+func TestServerMultiUserTCP(t *testing.T) {
+	server, _, ctx := newMultiUserServer()
+	dispatcher := new(echoDispatcher)
+
+	cases := []struct {
+		user   *protocol.MemoryUser
+		buffer int32
+	}{
+		{user: newAEADUser("a@v2fly.org", 1, "password-a"), buffer: 1024},
+		{user: newAEADUser("b@v2fly.org", 2, "password-b"), buffer: 4096},
+	}
+	for _, c := range cases {
+		if response := tcpRoundTrip(ctx, server, dispatcher, c.user, "ping "+c.user.Email); response != "ping "+c.user.Email {
+			t.Error("unexpected response: ", response)
+		}
+		records := dispatcher.takeRecords()
+		if len(records) != 1 || records[0].email != c.user.Email || records[0].buffer != c.buffer {
+			t.Error("unexpected dispatch for ", c.user.Email, ": ", records)
+		}
+	}
+
+	clientConn, serverConn := gonet.Pipe()
+	go func() {
+		bufferedWriter := buf.NewBufferedWriter(buf.NewWriter(clientConn))
+		writer, _, err := WriteTCPRequest(tcpRequest(newAEADUser("c@v2fly.org", 1, "password-c")), bufferedWriter)
+		common.Must(err)
+		common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("ping"))))
+		bufferedWriter.SetBuffered(false) // nolint: errcheck
+		clientConn.Close()
+	}()
+	if err := server.Process(inboundContext(ctx), net.Network_TCP, serverConn, dispatcher); err == nil {
+		t.Error("expect error on wrong key")
+	}
+	if records := dispatcher.takeRecords(); len(records) != 0 {
+		t.Error("unexpected dispatch with wrong key: ", records)
+	}
+}
+
+func TestServerMultiUserUDP(t *testing.T) {
+	server, v, ctx := newMultiUserServer()
+	dispatcher := new(echoDispatcher)
+
+	for _, user := range []*protocol.MemoryUser{
+		newAEADUser("a@v2fly.org", 1, "password-a"),
+		newAEADUser("b@v2fly.org", 2, "password-b"),
+	} {
+		response, err := udpRoundTrip(ctx, server, dispatcher, user, "ping "+user.Email)
+		common.Must(err)
+		if response != "ping "+user.Email {
+			t.Error("unexpected response: ", response)
+		}
+		records := dispatcher.takeRecords()
+		if len(records) != 1 || records[0].email != user.Email {
+			t.Error("unexpected dispatch for ", user.Email, ": ", records)
+		}
+	}
+
+	// User b is limited to one connection by its level, while user a is not.
+	limiter := v.GetFeature(feature_policy.ManagerType()).(feature_policy.UserLimiter)
+	release, err := limiter.Acquire(2, "b@v2fly.org", net.LocalHostIP)
+	common.Must(err)
+	if _, err := udpRoundTrip(ctx, server, dispatcher, newAEADUser("b@v2fly.org", 2, "password-b"), "ping"); err == nil {
+		t.Error("expect error on exceeding connection limit")
+	}
+	common.Must2(udpRoundTrip(ctx, server, dispatcher, newAEADUser("a@v2fly.org", 1, "password-a"), "ping"))
+	release()
+
+	// The rejected session holds no connection slot.
+	release, err = limiter.Acquire(2, "b@v2fly.org", net.LocalHostIP)
+	common.Must(err)
+	release()
+	dispatcher.takeRecords()
+
+	clientConn, serverConn := gonet.Pipe()
+	processErr := make(chan error, 1)
+	go func() {
+		processErr <- server.Process(inboundContext(ctx), net.Network_UDP, serverConn, dispatcher)
+	}()
+	packet, err := EncodeUDPPacket(udpRequest(newAEADUser("c@v2fly.org", 1, "password-c")), []byte("ping"))
+	common.Must(err)
+	common.Must2(clientConn.Write(packet.Bytes()))
+	packet.Release()
+	clientConn.Close()
+	if err := <-processErr; err != nil {
+		t.Error("unexpected error on dropped packet: ", err)
+	}
+	if records := dispatcher.takeRecords(); len(records) != 0 {
+		t.Error("unexpected dispatch with wrong key: ", records)
+	}
+}
@@ -0,0 +1,465 @@
+// +build !confonly
+
+package shadowsocks
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/crypto"
+	"v2ray.com/core/common/dice"
+	"v2ray.com/core/common/protocol"
+)
+
+const (
+	headerTypeClient2022 = 0
+	headerTypeServer2022 = 1
+
+	// maxTimeDiff2022 is the max difference in seconds between the timestamp in a header and local time.
+	maxTimeDiff2022 = 30
+	// maxPadding2022 is the max length of padding in a request header.
+	maxPadding2022 = 900
+
+	packetNonceSize2022 = 24
+)
+
+// timeNow returns the current time. It is replaced in tests.
+var timeNow = time.Now
+
+func checkTimestamp2022(timestamp uint64) error {
+	diff := timeNow().Unix() - int64(timestamp)
+	if diff < -maxTimeDiff2022 || diff > maxTimeDiff2022 {
+		return newError("invalid timestamp: ", timestamp, ", time difference: ", diff, "s")
+	}
+	return nil
+}
+
+// readRequest reads the request header of a Shadowsocks 2022 TCP session, after the salt.
+func (c *Cipher2022) readRequest(key []byte, salt []byte, reader io.Reader) (*protocol.RequestHeader, buf.Reader, error) {
+	auth := c.createAuthenticator(key, salt)
+
+	var fixedBytes [1 + 8 + 2 + 16]byte
+	fixed, err := openChunk2022(auth, reader, fixedBytes[:11+auth.Overhead()])
+	if err != nil {
+		return nil, nil, newError("failed to read request header").Base(err)
+	}
+	if fixed[0] != headerTypeClient2022 {
+		return nil, nil, newError("unexpected header type: ", fixed[0])
+	}
+	if err := checkTimestamp2022(binary.BigEndian.Uint64(fixed[1:9])); err != nil {
+		return nil, nil, err
+	}
+
+	variable, err := openChunk2022(auth, reader, make([]byte, int(binary.BigEndian.Uint16(fixed[9:11]))+auth.Overhead()))
+	if err != nil {
+		return nil, nil, newError("failed to read request header").Base(err)
+	}
+	variableReader := bytes.NewReader(variable)
+	addr, port, err := addrParser.ReadAddressPort(nil, variableReader)
+	if err != nil {
+		return nil, nil, newError("failed to read address").Base(err)
+	}
+	var paddingLen uint16
+	if err := binary.Read(variableReader, binary.BigEndian, &paddingLen); err != nil {
+		return nil, nil, newError("failed to read padding length").Base(err)
+	}
+	if int(paddingLen) > variableReader.Len() {
+		return nil, nil, newError("invalid padding length: ", paddingLen)
+	}
+	payload := variable[len(variable)-variableReader.Len()+int(paddingLen):]
+	if paddingLen == 0 && len(payload) == 0 {
+		return nil, nil, newError("neither padding nor payload in request header")
+	}
+
+	request := &protocol.RequestHeader{
+		Version: Version,
+		Command: protocol.RequestCommandTCP,
+		Address: addr,
+		Port:    port,
+	}
+	return request, &buf.BufferedReader{
+		Reader: &chunkReader2022{auth: auth, reader: reader},
+		Buffer: buf.MergeBytes(nil, payload),
+	}, nil
+}
+
+// writeRequest writes the request header of a Shadowsocks 2022 TCP session, after the salt.
+func (c *Cipher2022) writeRequest(key []byte, salt []byte, request *protocol.RequestHeader, writer io.Writer) (buf.Writer, error) {
+	auth := c.createAuthenticator(key, salt)
+
+	variable := buf.New()
+	defer variable.Release()
+
+	if err := addrParser.WriteAddressPort(variable, request.Address, request.Port); err != nil {
+		return nil, newError("failed to write address").Base(err)
+	}
+	paddingLen := dice.Roll(maxPadding2022) + 1
+	binary.BigEndian.PutUint16(variable.Extend(2), uint16(paddingLen))
+	common.Must2(variable.ReadFullFrom(rand.Reader, int32(paddingLen)))
+
+	header := make([]byte, 0, 11+auth.Overhead()+int(variable.Len())+auth.Overhead())
+	header = append(header, headerTypeClient2022)
+	header = appendUint64(header, uint64(timeNow().Unix()))
+	header = appendUint16(header, uint16(variable.Len()))
+	header, err := auth.Seal(header[:0], header)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := auth.Seal(header[len(header):], variable.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if err := buf.WriteAllBytes(writer, header[:len(header)+len(sealed)]); err != nil {
+		return nil, newError("failed to write header").Base(err)
+	}
+
+	return newChunkWriter2022(auth, writer), nil
+}
+
+// readResponse reads the response header of a Shadowsocks 2022 TCP session, after the salt.
+func (c *Cipher2022) readResponse(key []byte, salt []byte, requestSalt []byte, reader io.Reader) (buf.Reader, error) {
+	auth := c.createAuthenticator(key, salt)
+
+	fixed, err := openChunk2022(auth, reader, make([]byte, 1+8+len(requestSalt)+2+auth.Overhead()))
+	if err != nil {
+		return nil, newError("failed to read response header").Base(err)
+	}
+	if fixed[0] != headerTypeServer2022 {
+		return nil, newError("unexpected header type: ", fixed[0])
+	}
+	if err := checkTimestamp2022(binary.BigEndian.Uint64(fixed[1:9])); err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(fixed[9:9+len(requestSalt)], requestSalt) != 1 {
+		return nil, newError("unexpected request salt in response")
+	}
+
+	length := int(binary.BigEndian.Uint16(fixed[9+len(requestSalt):]))
+	payload, err := openChunk2022(auth, reader, make([]byte, length+auth.Overhead()))
+	if err != nil {
+		return nil, newError("failed to read response payload").Base(err)
+	}
+
+	return &buf.BufferedReader{
+		Reader: &chunkReader2022{auth: auth, reader: reader},
+		Buffer: buf.MergeBytes(nil, payload),
+	}, nil
+}
+
+// responseWriter2022 writes the response header of a Shadowsocks 2022 TCP session along with the first
+// chunk of payload, as the header carries the length of the chunk.
+type responseWriter2022 struct {
+	auth        *crypto.AEADAuthenticator
+	requestSalt []byte
+	writer      io.Writer
+	body        buf.Writer
+}
+
+func (c *Cipher2022) newResponseWriter(key []byte, salt []byte, requestSalt []byte, writer io.Writer) buf.Writer {
+	auth := c.createAuthenticator(key, salt)
+	return &responseWriter2022{
+		auth:        auth,
+		requestSalt: requestSalt,
+		writer:      writer,
+		body:        newChunkWriter2022(auth, writer),
+	}
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *responseWriter2022) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if w.requestSalt == nil {
+		return w.body.WriteMultiBuffer(mb)
+	}
+
+	mb, first := buf.SplitFirst(mb)
+	if first == nil {
+		return nil
+	}
+	defer first.Release()
+
+	overhead := w.auth.Overhead()
+	header := make([]byte, 0, 1+8+len(w.requestSalt)+2+overhead+int(first.Len())+overhead)
+	header = append(header, headerTypeServer2022)
+	header = appendUint64(header, uint64(timeNow().Unix()))
+	header = append(header, w.requestSalt...)
+	header = appendUint16(header, uint16(first.Len()))
+	header, err := w.auth.Seal(header[:0], header)
+	if err != nil {
+		buf.ReleaseMulti(mb)
+		return err
+	}
+	payload, err := w.auth.Seal(header[len(header):], first.Bytes())
+	if err != nil {
+		buf.ReleaseMulti(mb)
+		return err
+	}
+	w.requestSalt = nil
+	if err := buf.WriteAllBytes(w.writer, header[:len(header)+len(payload)]); err != nil {
+		buf.ReleaseMulti(mb)
+		return err
+	}
+
+	if mb.IsEmpty() {
+		return nil
+	}
+	return w.body.WriteMultiBuffer(mb)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var bytes [8]byte
+	binary.BigEndian.PutUint64(bytes[:], v)
+	return append(b, bytes[:]...)
+}
+
+// UDPSession is the state of a Shadowsocks UDP association. Shadowsocks 2022 packets carry session IDs
+// and packet IDs, while packets of other ciphers are encoded and decoded without state.
+type UDPSession struct {
+	access sync.Mutex
+	server bool
+
+	sessionID uint64
+	packetID  uint64
+	cipher    cipher.AEAD
+
+	// remoteCipher is nil for XChaCha20-Poly1305, where packets are sealed with the pre-shared key.
+	hasRemote       bool
+	remoteSessionID uint64
+	remoteCipher    cipher.AEAD
+	window          slidingWindow
+
+	hasLastRemote       bool
+	lastRemoteSessionID uint64
+	lastRemoteCipher    cipher.AEAD
+	lastWindow          slidingWindow
+}
+
+// NewUDPSession creates a new UDPSession on server or client side.
+func NewUDPSession(server bool) *UDPSession {
+	var id [8]byte
+	common.Must2(rand.Read(id[:]))
+	return &UDPSession{
+		server:    server,
+		sessionID: binary.BigEndian.Uint64(id[:]),
+	}
+}
+
+// EncodePacket encodes a UDP packet of the given request.
+func (s *UDPSession) EncodePacket(request *protocol.RequestHeader, payload []byte) (*buf.Buffer, error) {
+	account := request.User.Account.(*MemoryAccount)
+	c, ok := account.Cipher.(*Cipher2022)
+	if !ok {
+		return EncodeUDPPacket(request, payload)
+	}
+
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	buffer := buf.New()
+	var dataIndex int32
+	if c.XChaCha {
+		common.Must2(buffer.ReadFullFrom(rand.Reader, packetNonceSize2022))
+		dataIndex = packetNonceSize2022
+	}
+	header := buffer.Extend(16)
+	binary.BigEndian.PutUint64(header[:8], s.sessionID)
+	binary.BigEndian.PutUint64(header[8:], s.packetID)
+	s.packetID++
+
+	if s.server {
+		common.Must(buffer.WriteByte(headerTypeServer2022))
+		binary.BigEndian.PutUint64(buffer.Extend(8), uint64(timeNow().Unix()))
+		binary.BigEndian.PutUint64(buffer.Extend(8), s.remoteSessionID)
+	} else {
+		common.Must(buffer.WriteByte(headerTypeClient2022))
+		binary.BigEndian.PutUint64(buffer.Extend(8), uint64(timeNow().Unix()))
+	}
+	binary.BigEndian.PutUint16(buffer.Extend(2), 0)
+	if err := addrParser.WriteAddressPort(buffer, request.Address, request.Port); err != nil {
+		buffer.Release()
+		return nil, newError("failed to write address").Base(err)
+	}
+	if buffer.Len()+int32(len(payload))+16 > buf.Size {
+		buffer.Release()
+		return nil, newError("UDP payload too large: ", len(payload))
+	}
+	common.Must2(buffer.Write(payload))
+
+	if c.XChaCha {
+		aead, err := chacha20poly1305.NewX(account.Key)
+		common.Must(err)
+		aead.Seal(buffer.BytesFrom(dataIndex)[:0], buffer.BytesTo(dataIndex), buffer.BytesFrom(dataIndex), nil)
+		buffer.Extend(int32(aead.Overhead()))
+		return buffer, nil
+	}
+
+	if s.cipher == nil {
+		s.cipher = c.AEADAuthCreator(sessionKey2022(account.Key, header[:8], c.KeyBytes))
+	}
+	s.cipher.Seal(buffer.BytesFrom(16)[:0], header[4:16], buffer.BytesFrom(16), nil)
+	buffer.Extend(int32(s.cipher.Overhead()))
+	block, err := aes.NewCipher(account.Key)
+	common.Must(err)
+	block.Encrypt(header, header)
+	return buffer, nil
+}
+
+// DecodePacket decodes a UDP packet from the given user. The payload is consumed on success.
+func (s *UDPSession) DecodePacket(user *protocol.MemoryUser, payload *buf.Buffer) (*protocol.RequestHeader, *buf.Buffer, error) {
+	account := user.Account.(*MemoryAccount)
+	c, ok := account.Cipher.(*Cipher2022)
+	if !ok {
+		return DecodeUDPPacket(user, payload)
+	}
+
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	var sessionID, packetID uint64
+	var remoteCipher cipher.AEAD
+	if c.XChaCha {
+		if payload.Len() < packetNonceSize2022+16+16 {
+			return nil, nil, newError("insufficient data: ", payload.Len())
+		}
+		aead, err := chacha20poly1305.NewX(account.Key)
+		common.Must(err)
+		data, err := aead.Open(payload.BytesFrom(packetNonceSize2022)[:0], payload.BytesTo(packetNonceSize2022), payload.BytesFrom(packetNonceSize2022), nil)
+		if err != nil {
+			return nil, nil, newError("failed to decrypt UDP payload").Base(err)
+		}
+		payload.Resize(packetNonceSize2022, packetNonceSize2022+int32(len(data)))
+		sessionID = binary.BigEndian.Uint64(payload.BytesTo(8))
+		packetID = binary.BigEndian.Uint64(payload.BytesRange(8, 16))
+	} else {
+		if payload.Len() < 16+16 {
+			return nil, nil, newError("insufficient data: ", payload.Len())
+		}
+		var header [16]byte
+		block, err := aes.NewCipher(account.Key)
+		common.Must(err)
+		block.Decrypt(header[:], payload.BytesTo(16))
+		sessionID = binary.BigEndian.Uint64(header[:8])
+		packetID = binary.BigEndian.Uint64(header[8:])
+
+		switch {
+		case s.hasRemote && sessionID == s.remoteSessionID:
+			remoteCipher = s.remoteCipher
+		case s.hasLastRemote && sessionID == s.lastRemoteSessionID:
+			remoteCipher = s.lastRemoteCipher
+		default:
+			remoteCipher = c.AEADAuthCreator(sessionKey2022(account.Key, header[:8], c.KeyBytes))
+		}
+		data, err := remoteCipher.Open(payload.BytesFrom(16)[:0], header[4:16], payload.BytesFrom(16), nil)
+		if err != nil {
+			return nil, nil, newError("failed to decrypt UDP payload").Base(err)
+		}
+		payload.Resize(0, 16+int32(len(data)))
+	}
+	payload.Advance(16)
+
+	headerType := byte(headerTypeClient2022)
+	headerLen := int32(1 + 8 + 2)
+	if !s.server {
+		headerType = headerTypeServer2022
+		headerLen += 8
+	}
+	if payload.Len() < headerLen {
+		return nil, nil, newError("insufficient data: ", payload.Len())
+	}
+	if payload.Byte(0) != headerType {
+		return nil, nil, newError("unexpected header type: ", payload.Byte(0))
+	}
+	if err := checkTimestamp2022(binary.BigEndian.Uint64(payload.BytesRange(1, 9))); err != nil {
+		return nil, nil, err
+	}
+	if !s.server && binary.BigEndian.Uint64(payload.BytesRange(9, 17)) != s.sessionID {
+		return nil, nil, newError("unexpected client session ID")
+	}
+	paddingLen := int32(binary.BigEndian.Uint16(payload.BytesRange(headerLen-2, headerLen)))
+	if payload.Len() < headerLen+paddingLen {
+		return nil, nil, newError("invalid padding length: ", paddingLen)
+	}
+
+	if err := s.checkPacketID(sessionID, packetID, remoteCipher); err != nil {
+		return nil, nil, err
+	}
+	payload.Advance(headerLen + paddingLen)
+
+	return parseUDPPacket(user, payload)
+}
+
+// checkPacketID checks the packet ID against the window of its remote session, and switches to a new
+// remote session if the session ID is unknown. The previous remote session is kept for reordered packets.
+func (s *UDPSession) checkPacketID(sessionID uint64, packetID uint64, remoteCipher cipher.AEAD) error {
+	switch {
+	case s.hasRemote && sessionID == s.remoteSessionID:
+		if !s.window.check(packetID) {
+			return newError("duplicated packet ID: ", packetID)
+		}
+	case s.hasLastRemote && sessionID == s.lastRemoteSessionID:
+		if !s.lastWindow.check(packetID) {
+			return newError("duplicated packet ID: ", packetID)
+		}
+	default:
+		s.hasLastRemote = s.hasRemote
+		s.lastRemoteSessionID = s.remoteSessionID
+		s.lastRemoteCipher = s.remoteCipher
+		s.lastWindow = s.window
+		s.hasRemote = true
+		s.remoteSessionID = sessionID
+		s.remoteCipher = remoteCipher
+		s.window = slidingWindow{}
+		s.window.check(packetID)
+	}
+	return nil
+}
+
+const (
+	windowBlocks = 16
+	windowSize   = (windowBlocks - 1) * 64
+)
+
+// slidingWindow filters replayed packet IDs, while allowing packets within the window to be reordered.
+type slidingWindow struct {
+	last   uint64
+	blocks [windowBlocks]uint64
+}
+
+// check returns false if the packet ID has been seen, or is too old. Otherwise the packet ID is recorded.
+func (w *slidingWindow) check(id uint64) bool {
+	if id > w.last {
+		current, next := w.last/64, id/64
+		diff := next - current
+		if diff > windowBlocks {
+			diff = windowBlocks
+		}
+		for i := uint64(1); i <= diff; i++ {
+			w.blocks[(current+i)%windowBlocks] = 0
+		}
+		w.last = id
+	} else if w.last-id > windowSize {
+		return false
+	}
+
+	block := &w.blocks[(id/64)%windowBlocks]
+	bit := uint64(1) << (id % 64)
+	if *block&bit != 0 {
+		return false
+	}
+	*block |= bit
+	return true
+}
@@ -0,0 +1,152 @@
+package shadowsocks
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+)
+
+func hexBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	common.Must(err)
+	return b
+}
+
+func newBuffer(b []byte) *buf.Buffer {
+	buffer := buf.New()
+	common.Must2(buffer.Write(b))
+	return buffer
+}
+
+// TestShadowsocks2022Vectors decodes sessions and packets produced by sing-shadowsocks, at Unix time 1700000000.
+// The TCP request is to example.com:443, and the UDP request is to 1.2.3.4:5678.
+func TestShadowsocks2022Vectors(t *testing.T) {
+	vectors := []struct {
+		cipher          CipherType
+		psk             string
+		request         string
+		response        string
+		udpRequest      string
+		udpResponse     string
+		clientSessionID uint64
+	}{
+		{
+			cipher:          CipherType_BLAKE3_AES_128_GCM,
+			psk:             "AQIDBAUGBwgJCgsMDQ4PEA==",
+			request:         "10b77b5172dec90c697036fd2b907d728d644aff8c3206512412e5cdeaad10994ce5b6a84940fc558b63b816a84f45f4833547b81ad58edd94ab374300c9c53486b25cf97b94f7b6138472850e5109b96f7a43339dce39190ad08202668311eca8e18d4db64de25850d652f73f559e7445b96b0ebf9fa1a50acfd2edf9cccc283abbdf45aa57bd080f0a6ebe816bd17dc94fbc18f87f54",
+			response:        "b179277249a37582cf861ec897531c88eaa473b809d53ab78894c9954fc77b6f68501d8cf10e50bbfed1785baaeb4c6b2019a0bc341cd47e7d96f707ad6c1e395ef2ab9915204f42229f87e21f10eae5a384779530d82dde31908e",
+			udpRequest:      "4d1532643c0a96a6855a20c02f5ebca540d0976aaa4a830cde68416784f74cbb50f483d295825d814476c5a7fbed4fd13501618172a2d574e4d61e0a17",
+			udpResponse:     "f4406972f20de2964e171d9bf82c53df3c0c969c6272f1b10bbbf91eb41f5ce7f3568aca9ca24d55ad97320fe5a75cdfa99418453a2a9a515b4706aa463c0b73add71b3d5ac1",
+			clientSessionID: 0xd25062144d87d669,
+		},
+		{
+			cipher:          CipherType_BLAKE3_AES_256_GCM,
+			psk:             "AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA=",
+			request:         "1b048968100cf26114427bdf8c67eaf605d9dc5490f09330608abe54d7b2143a387631decb091ce0787b1310c0f885288b8c359266e463077e7a216be4f226256e6c862c07514f49769af2a39ee259c472f02545f763febb4ebe539fb5d49c6617e401167fc9aa64b340936b1d84356862e2de4d91113dfabc916ac58b32178f890e6dee9e02502b194190bd8524",
+			response:        "4ebfc131f176f4ab4832cecccd09e970512f234f5f1964c4882c1d6c47873ea9a7dcc54c9fccc0cee9d7c34fd52762eb9c936d4b89e4c297277fbab1df999ace9e5bb077153978408906e57d1ecd2700f0dd38ca5dd8bb93fe73de1ab3decd7df9aaace7c79e25e3d3fca15e46498bbe778321c734b9c3027d8811",
+			udpRequest:      "e83252c6937ea58367a3aee0b4a1630c76613cf71ab0f7b1152a109d61294373792e702f132ab698c017adbb87574182b65ad7f6af5d23da5604937e67",
+			udpResponse:     "ece8221d660af1529d075679454f46cdc40e497f7023de9646466aa1dad2685e050afaf1a6efa5914dd2413b7941b8dc7fae78660fc031860e919854654c03da729d53bdf0aa",
+			clientSessionID: 0x305416cc1c003d87,
+		},
+		{
+			cipher:          CipherType_BLAKE3_CHACHA20_POLY1305,
+			psk:             "AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA=",
+			request:         "0270f1f273ae4faac4a45beefef50d29dc41f998fc627795b99ffeab44600b24bb1d5cb9c18dcea138bf283ca8bea09a769cd5a06ca18bb09dd43268b33cd0a8469a52906478aa056237df6e3678464c48462556c77024ed5ddd7906863a4dc35b7928865e8799a55feeecf568aebebf6d857a32a6665de84bd8",
+			response:        "806a5f78c6ecc59c129e6978cfce24f79bf811eb86821726c6c91b5056ff88ad0a51a491342b71a39be76e0ac74b2729df45a28508962335f90d5421b2f20dd706aadf7746b35dcea9d775dedd485a112456814364c0beaa7e0214153891eca43768276d011554ea6a7c2f62b2e97ca66651de8575bf842bc0c7dc",
+			udpRequest:      "d6a8d919bf47fca85f4c13dc901a3ec4d0a4dc158e26598e3bddb171b314e3805418466fb9ac90b221388c0d650488e19a8da5b5a9970b8410582e1bc63981e889ec4cd85ea79294659c8b65a762847bd639ce24d9",
+			udpResponse:     "aee0e1c8ad3085b3b711535f1f15118af8fb6930657bc66658bf84e0c5819dcc5ad22f6e2343aad064906523ca51f682ed27d3415a60615cfa48b9b06f30eb37f030e1adc6d20b86c82f199f7aee525009ec6f5f78ee0cece1b8353d54fc",
+			clientSessionID: 0xd9e4460751480938,
+		},
+	}
+
+	timeNow = func() time.Time { return time.Unix(1700000000, 0) }
+	defer func() { timeNow = time.Now }()
+
+	for _, v := range vectors {
+		account, err := (&Account{Password: v.psk, CipherType: v.cipher}).AsAccount()
+		common.Must(err)
+		user := &protocol.MemoryUser{Email: "love@v2ray.com", Account: account}
+		validator := new(Validator)
+		common.Must(validator.Add(user))
+
+		request, reader, requestSalt, err := ReadTCPSession(validator, bytes.NewReader(hexBytes(v.request)))
+		common.Must(err)
+		if dest := request.Destination(); dest != net.TCPDestination(net.DomainAddress("example.com"), 443) {
+			t.Error("unexpected destination: ", dest)
+		}
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		if mb.String() != "request payload" {
+			t.Error("unexpected request payload: ", mb.String())
+		}
+		buf.ReleaseMulti(mb)
+
+		if _, _, _, err := ReadTCPSession(validator, bytes.NewReader(hexBytes(v.request))); err == nil {
+			t.Error("expected error on replayed request")
+		}
+
+		reader, err = ReadTCPResponse(user, requestSalt, bytes.NewReader(hexBytes(v.response)))
+		common.Must(err)
+		mb, err = reader.ReadMultiBuffer()
+		common.Must(err)
+		if mb.String() != "response payload" {
+			t.Error("unexpected response payload: ", mb.String())
+		}
+		buf.ReleaseMulti(mb)
+
+		serverSession := NewUDPSession(true)
+		request, payload, err := DecodeServerUDPPacket(validator, serverSession, newBuffer(hexBytes(v.udpRequest)))
+		common.Must(err)
+		if dest := request.Destination(); dest != net.UDPDestination(net.ParseAddress("1.2.3.4"), 5678) {
+			t.Error("unexpected destination: ", dest)
+		}
+		if payload.String() != "udp request" {
+			t.Error("unexpected UDP request payload: ", payload.String())
+		}
+		payload.Release()
+
+		if _, _, err := DecodeServerUDPPacket(validator, serverSession, newBuffer(hexBytes(v.udpRequest))); err == nil {
+			t.Error("expected error on replayed packet")
+		}
+
+		clientSession := &UDPSession{sessionID: v.clientSessionID}
+		_, payload, err = clientSession.DecodePacket(user, newBuffer(hexBytes(v.udpResponse)))
+		common.Must(err)
+		if payload.String() != "udp response" {
+			t.Error("unexpected UDP response payload: ", payload.String())
+		}
+		payload.Release()
+	}
+
+	timeNow = time.Now
+	account, err := (&Account{Password: vectors[0].psk, CipherType: vectors[0].cipher}).AsAccount()
+	common.Must(err)
+	validator := new(Validator)
+	common.Must(validator.Add(&protocol.MemoryUser{Account: account}))
+	if _, _, _, err := ReadTCPSession(validator, bytes.NewReader(hexBytes(vectors[0].request))); err == nil {
+		t.Error("expected error on expired request")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	var w slidingWindow
+	for _, id := range []uint64{0, 2, 1, 100, 50, 2000} {
+		if !w.check(id) {
+			t.Error("unexpected rejection of packet ID ", id)
+		}
+	}
+	for _, id := range []uint64{0, 2, 100, 2000} {
+		if w.check(id) {
+			t.Error("unexpected acceptance of packet ID ", id)
+		}
+	}
+	if !w.check(1999) {
+		t.Error("unexpected rejection of packet ID 1999")
+	}
+}
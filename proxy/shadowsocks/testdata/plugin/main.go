@@ -0,0 +1,61 @@
+// Command plugin is a stub SIP003 plugin for tests. It forwards TCP connections from the local address to the remote
+// address, or the other way around with "server" in SS_PLUGIN_OPTIONS. With "once" in SS_PLUGIN_OPTIONS, it exits after
+// the first connection is closed.
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+func main() {
+	options := strings.Split(os.Getenv("SS_PLUGIN_OPTIONS"), ";")
+	hasOption := func(name string) bool {
+		for _, option := range options {
+			if option == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	listenAddr := net.JoinHostPort(os.Getenv("SS_LOCAL_HOST"), os.Getenv("SS_LOCAL_PORT"))
+	targetAddr := net.JoinHostPort(os.Getenv("SS_REMOTE_HOST"), os.Getenv("SS_REMOTE_PORT"))
+	if hasOption("server") {
+		listenAddr, targetAddr = targetAddr, listenAddr
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			os.Exit(1)
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer conn.Close()
+			target, err := net.Dial("tcp", targetAddr)
+			if err != nil {
+				return
+			}
+			defer target.Close()
+			go func() {
+				io.Copy(target, conn)
+				target.Close()
+			}()
+			io.Copy(conn, target)
+		}()
+		if hasOption("once") {
+			<-done
+			os.Exit(0)
+		}
+	}
+}
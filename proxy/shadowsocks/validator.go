@@ -0,0 +1,238 @@
+// +build !confonly
+
+package shadowsocks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+	"hash/crc32"
+	"strings"
+	"sync"
+
+	"v2ray.com/core/common/antireplay"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/protocol"
+)
+
+// recentCacheSize is the number of recently matched users that are tried first.
+const recentCacheSize = 16
+
+// Validator stores valid Shadowsocks users.
+type Validator struct {
+	sync.RWMutex
+	users []*protocol.MemoryUser
+
+	// recent holds recently matched users, the most recent first.
+	recentAccess sync.Mutex
+	recent       []*protocol.MemoryUser
+
+	behaviorSeed  uint32
+	behaviorFused bool
+
+	// saltFilter rejects replayed salts of Shadowsocks 2022 sessions.
+	saltFilterOnce sync.Once
+	saltFilter     *antireplay.AntiReplayWindow
+}
+
+// Add a Shadowsocks user. Only AEAD ciphers can be used when there are multiple users.
+func (v *Validator) Add(u *protocol.MemoryUser) error {
+	v.Lock()
+	defer v.Unlock()
+
+	account := u.Account.(*MemoryAccount)
+	if len(v.users) > 0 {
+		if is2022(account) || is2022(v.users[0].Account.(*MemoryAccount)) {
+			return newError("multiple users are not supported with Shadowsocks 2022 ciphers")
+		}
+		if _, ok := account.Cipher.(*AEADCipher); !ok {
+			return newError("multiple users are only supported with AEAD ciphers")
+		}
+		if _, ok := v.users[0].Account.(*MemoryAccount).Cipher.(*AEADCipher); !ok {
+			return newError("multiple users are only supported with AEAD ciphers")
+		}
+	}
+	for _, user := range v.users {
+		if u.Email != "" && strings.EqualFold(user.Email, u.Email) {
+			return newError("User ", u.Email, " already exists.")
+		}
+		if user.Account.Equals(account) {
+			return newError("User ", u.Email, " has the same key as user ", user.Email)
+		}
+	}
+	v.users = append(v.users, u)
+
+	if !v.behaviorFused {
+		hashkdf := hmac.New(func() hash.Hash { return sha256.New() }, []byte("SSBSKDF"))
+		hashkdf.Write(account.Key)
+		v.behaviorSeed = crc32.Update(v.behaviorSeed, crc32.IEEETable, hashkdf.Sum(nil))
+	}
+
+	return nil
+}
+
+func is2022(account *MemoryAccount) bool {
+	_, ok := account.Cipher.(*Cipher2022)
+	return ok
+}
+
+// Del a Shadowsocks user with its email.
+func (v *Validator) Del(email string) error {
+	if email == "" {
+		return newError("Email must not be empty.")
+	}
+
+	v.Lock()
+	defer v.Unlock()
+
+	for i, user := range v.users {
+		if strings.EqualFold(user.Email, email) {
+			v.users = append(v.users[:i:i], v.users[i+1:]...)
+			v.forget(user)
+			return nil
+		}
+	}
+	return newError("User ", email, " not found.")
+}
+
+// candidates returns all users, with recently matched ones first.
+func (v *Validator) candidates() []*protocol.MemoryUser {
+	v.recentAccess.Lock()
+	users := make([]*protocol.MemoryUser, 0, len(v.users))
+	users = append(users, v.recent...)
+	v.recentAccess.Unlock()
+
+	recent := len(users)
+	for _, user := range v.users {
+		if !containsUser(users[:recent], user) {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// remember moves the given user to the front of recently matched users.
+func (v *Validator) remember(user *protocol.MemoryUser) {
+	v.recentAccess.Lock()
+	defer v.recentAccess.Unlock()
+
+	if len(v.recent) > 0 && v.recent[0] == user {
+		return
+	}
+	recent := make([]*protocol.MemoryUser, 0, recentCacheSize)
+	recent = append(recent, user)
+	for _, u := range v.recent {
+		if u != user && len(recent) < recentCacheSize {
+			recent = append(recent, u)
+		}
+	}
+	v.recent = recent
+}
+
+// forget removes the given user from recently matched users.
+func (v *Validator) forget(user *protocol.MemoryUser) {
+	v.recentAccess.Lock()
+	defer v.recentAccess.Unlock()
+
+	for i, u := range v.recent {
+		if u == user {
+			v.recent = append(v.recent[:i:i], v.recent[i+1:]...)
+			return
+		}
+	}
+}
+
+func containsUser(users []*protocol.MemoryUser, user *protocol.MemoryUser) bool {
+	for _, u := range users {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAll returns all users in this Validator.
+func (v *Validator) GetAll() []*protocol.MemoryUser {
+	v.RLock()
+	defer v.RUnlock()
+
+	return append([]*protocol.MemoryUser(nil), v.users...)
+}
+
+// GetBehaviorSeed returns the seed for behaviors on invalid requests. It doesn't change after the first call.
+func (v *Validator) GetBehaviorSeed() uint32 {
+	v.Lock()
+	defer v.Unlock()
+
+	v.behaviorFused = true
+	return v.behaviorSeed
+}
+
+// checkSalt returns false if the salt of a Shadowsocks 2022 session has been seen recently.
+func (v *Validator) checkSalt(salt []byte) bool {
+	v.saltFilterOnce.Do(func() {
+		v.saltFilter = antireplay.NewAntiReplayWindow(60)
+	})
+	return v.saltFilter.Check(salt)
+}
+
+// maxIVSize returns the largest IV size among all users.
+func (v *Validator) maxIVSize() int32 {
+	v.RLock()
+	defer v.RUnlock()
+
+	var size int32
+	for _, user := range v.users {
+		if s := user.Account.(*MemoryAccount).Cipher.IVSize(); s > size {
+			size = s
+		}
+	}
+	return size
+}
+
+// matchTCP returns the user whose key decrypts the first length chunk of a TCP session in header.
+func (v *Validator) matchTCP(header []byte) (*protocol.MemoryUser, error) {
+	v.RLock()
+	defer v.RUnlock()
+
+	for _, user := range v.candidates() {
+		account := user.Account.(*MemoryAccount)
+		cipher, ok := account.Cipher.(*AEADCipher)
+		if !ok {
+			continue
+		}
+		ivLen := cipher.IVSize()
+		if int32(len(header)) <= ivLen {
+			continue
+		}
+		auth := cipher.createAuthenticator(account.Key, header[:ivLen])
+		chunkLen := ivLen + 2 + int32(auth.Overhead())
+		if int32(len(header)) < chunkLen {
+			continue
+		}
+		if _, err := auth.Open(nil, header[ivLen:chunkLen]); err == nil {
+			v.remember(user)
+			return user, nil
+		}
+	}
+	return nil, newError("no matching user")
+}
+
+// matchUDP decrypts the given UDP packet with each user's key, and returns the user that matches along with the decrypted packet.
+func (v *Validator) matchUDP(payload *buf.Buffer) (*protocol.MemoryUser, *buf.Buffer, error) {
+	v.RLock()
+	defer v.RUnlock()
+
+	for _, user := range v.candidates() {
+		account := user.Account.(*MemoryAccount)
+		// Decryption happens in place, and the payload is wiped on failure.
+		b := buf.New()
+		b.Write(payload.Bytes())
+		if err := account.Cipher.DecodePacket(account.Key, b); err == nil {
+			v.remember(user)
+			return user, b, nil
+		}
+		b.Release()
+	}
+	return nil, nil, newError("no matching user")
+}
@@ -0,0 +1,129 @@
+package shadowsocks_test
+
+import (
+	"strings"
+	"testing"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	. "v2ray.com/core/proxy/shadowsocks"
+)
+
+func TestValidatorMultiUser(t *testing.T) {
+	users := []*protocol.MemoryUser{
+		{
+			Email: "a@v2ray.com",
+			Account: toAccount(&Account{
+				Password:   "password-a",
+				CipherType: CipherType_AES_128_GCM,
+			}),
+		},
+		{
+			Email: "b@v2ray.com",
+			Account: toAccount(&Account{
+				Password:   "password-b",
+				CipherType: CipherType_AES_256_GCM,
+			}),
+		},
+		{
+			Email: "c@v2ray.com",
+			Account: toAccount(&Account{
+				Password:   "password-c",
+				CipherType: CipherType_CHACHA20_POLY1305,
+			}),
+		},
+	}
+
+	validator := new(Validator)
+	for _, u := range users {
+		common.Must(validator.Add(u))
+	}
+
+	if err := validator.Add(users[0]); err == nil {
+		t.Error("expected error on duplicated user")
+	}
+	if err := validator.Add(&protocol.MemoryUser{
+		Email: "cfb@v2ray.com",
+		Account: toAccount(&Account{
+			Password:   "password-cfb",
+			CipherType: CipherType_AES_128_CFB,
+		}),
+	}); err == nil {
+		t.Error("expected error on non-AEAD cipher with multiple users")
+	}
+
+	for _, u := range users {
+		request := &protocol.RequestHeader{
+			Version: Version,
+			Command: protocol.RequestCommandTCP,
+			Address: net.DomainAddress("v2ray.com"),
+			Port:    443,
+			User:    u,
+		}
+
+		cache := buf.New()
+		writer, _, err := WriteTCPRequest(request, cache)
+		common.Must(err)
+		payload := buf.New()
+		common.Must2(payload.WriteString("test payload"))
+		common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{payload}))
+
+		decoded, reader, _, err := ReadTCPSession(validator, cache)
+		common.Must(err)
+		if decoded.User.Email != u.Email {
+			t.Error("unexpected user: ", decoded.User.Email, " want ", u.Email)
+		}
+		data, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		if data.String() != "test payload" {
+			t.Error("unexpected payload: ", data.String())
+		}
+		buf.ReleaseMulti(data)
+		cache.Release()
+
+		request.Command = protocol.RequestCommandUDP
+		packet, err := EncodeUDPPacket(request, []byte("udp payload"))
+		common.Must(err)
+		decoded, udpData, err := DecodeServerUDPPacket(validator, NewUDPSession(true), packet)
+		common.Must(err)
+		if decoded.User.Email != u.Email {
+			t.Error("unexpected user: ", decoded.User.Email, " want ", u.Email)
+		}
+		if udpData.String() != "udp payload" {
+			t.Error("unexpected payload: ", udpData.String())
+		}
+		udpData.Release()
+	}
+
+	common.Must(validator.Del("b@v2ray.com"))
+	if len(validator.GetAll()) != 2 {
+		t.Error("unexpected users: ", validator.GetAll())
+	}
+	if err := validator.Del("b@v2ray.com"); err == nil {
+		t.Error("expected error on removed user")
+	}
+}
+
+func TestValidatorMultiUser2022(t *testing.T) {
+	validator := new(Validator)
+	common.Must(validator.Add(&protocol.MemoryUser{
+		Email: "a@v2ray.com",
+		Account: toAccount(&Account{
+			Password:   "AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA=",
+			CipherType: CipherType_BLAKE3_AES_256_GCM,
+		}),
+	}))
+
+	err := validator.Add(&protocol.MemoryUser{
+		Email: "b@v2ray.com",
+		Account: toAccount(&Account{
+			Password:   "ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8=",
+			CipherType: CipherType_BLAKE3_AES_256_GCM,
+		}),
+	})
+	if err == nil || !strings.Contains(err.Error(), "Shadowsocks 2022") {
+		t.Error("expected error on multiple Shadowsocks 2022 users, but got ", err)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"v2ray.com/core/features"
 	"v2ray.com/core/features/policy"
 	"v2ray.com/core/features/routing"
+	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/internet"
 	"v2ray.com/core/transport/internet/udp"
 )
@@ -112,6 +113,19 @@ func (s *Server) processTCP(ctx context.Context, conn internet.Connection, dispa
 		inbound.User.Email = request.User.Email
 	}
 
+	release, err := policy.AcquireUser(s.policyManager, inbound.User, inbound.Source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   inbound.Source,
+			To:     request.Destination(),
+			Status: log.AccessRejected,
+			Reason: err,
+			Email:  inbound.User.Email,
+		})
+		return newError("rejected request from ", inbound.Source).Base(err)
+	}
+	defer release()
+
 	if err := conn.SetReadDeadline(time.Time{}); err != nil {
 		newError("failed to clear deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
 	}
@@ -144,12 +158,13 @@ func (*Server) handleUDP(c io.Reader) error {
 	return common.Error2(io.Copy(buf.DiscardBytes, c))
 }
 
-func (s *Server) transport(ctx context.Context, reader io.Reader, writer io.Writer, dest net.Destination, dispatcher routing.Dispatcher) error {
+func (s *Server) transport(ctx context.Context, reader buf.Reader, conn internet.Connection, dest net.Destination, dispatcher routing.Dispatcher) error {
 	ctx, cancel := context.WithCancel(ctx)
 	timer := signal.CancelAfterInactivity(ctx, cancel, s.policy().Timeouts.ConnectionIdle)
 
 	plcy := s.policy()
 	ctx = policy.ContextWithBufferPolicy(ctx, plcy.Buffer)
+	ctx = proxy.ContextWithSplice(ctx)
 	link, err := dispatcher.Dispatch(ctx, dest)
 	if err != nil {
 		return err
@@ -157,7 +172,7 @@ func (s *Server) transport(ctx context.Context, reader io.Reader, writer io.Writ
 
 	requestDone := func() error {
 		defer timer.SetTimeout(plcy.Timeouts.DownlinkOnly)
-		if err := buf.Copy(buf.NewReader(reader), link.Writer, buf.UpdateActivity(timer)); err != nil {
+		if err := proxy.CopyFromConn(ctx, proxy.SpliceUplink, conn, reader, link.Writer, timer); err != nil {
 			return newError("failed to transport all TCP request").Base(err)
 		}
 
@@ -167,8 +182,8 @@ func (s *Server) transport(ctx context.Context, reader io.Reader, writer io.Writ
 	responseDone := func() error {
 		defer timer.SetTimeout(plcy.Timeouts.UplinkOnly)
 
-		v2writer := buf.NewWriter(writer)
-		if err := buf.Copy(link.Reader, v2writer, buf.UpdateActivity(timer)); err != nil {
+		v2writer := buf.NewWriter(conn)
+		if err := proxy.CopyToConn(ctx, proxy.SpliceDownlink, conn, link.Reader, v2writer, timer); err != nil {
 			return newError("failed to transport all TCP response").Base(err)
 		}
 
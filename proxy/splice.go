@@ -0,0 +1,251 @@
+// +build !confonly
+
+package proxy
+
+//go:generate go run v2ray.com/core/common/errors/errorgen
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/features/stats"
+	"v2ray.com/core/transport/internet"
+	"v2ray.com/core/transport/pipe"
+)
+
+// SpliceDirection is the direction of data in a connection, between its inbound and outbound.
+type SpliceDirection int
+
+const (
+	// SpliceUplink is the direction from the inbound to the outbound.
+	SpliceUplink SpliceDirection = iota
+	// SpliceDownlink is the direction from the outbound to the inbound.
+	SpliceDownlink
+)
+
+// spliceLink coordinates the reading side and the writing side of one direction, which hand off the data to the kernel together.
+type spliceLink struct {
+	offerOnce sync.Once
+	offered   chan struct{}
+	conn      *tcpConn
+	timer     signal.ActivityUpdater
+
+	handoff int32
+	flushed chan struct{}
+	exited  chan struct{}
+	done    chan struct{}
+	err     error
+}
+
+func newSpliceLink() *spliceLink {
+	return &spliceLink{
+		offered: make(chan struct{}),
+		flushed: make(chan struct{}),
+		exited:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+type spliceSession struct {
+	links [2]*spliceLink
+}
+
+type spliceKey struct{}
+
+// ContextWithSplice returns a context in which TCP data between the inbound and the outbound of a connection may be relayed by the kernel.
+// Inbounds that pass raw TCP data, call this before dispatching the connection.
+//
+// v2ray:api:beta
+func ContextWithSplice(ctx context.Context) context.Context {
+	return context.WithValue(ctx, spliceKey{}, &spliceSession{
+		links: [2]*spliceLink{newSpliceLink(), newSpliceLink()},
+	})
+}
+
+// ContextWithoutSplice returns a context in which the connection is not relayed by the kernel.
+// It is used when the link of the connection is passed on to another outbound, which must not take over the connection of the inbound.
+//
+// v2ray:api:beta
+func ContextWithoutSplice(ctx context.Context) context.Context {
+	if ctx.Value(spliceKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spliceKey{}, (*spliceSession)(nil))
+}
+
+func spliceLinkFromContext(ctx context.Context, dir SpliceDirection) *spliceLink {
+	if s, ok := ctx.Value(spliceKey{}).(*spliceSession); ok && s != nil {
+		return s.links[dir]
+	}
+	return nil
+}
+
+func (l *spliceLink) offer(conn *tcpConn, timer signal.ActivityUpdater) bool {
+	offered := false
+	l.offerOnce.Do(func() {
+		l.conn = conn
+		l.timer = timer
+		close(l.offered)
+		offered = true
+	})
+	return offered
+}
+
+func (l *spliceLink) isOffered() bool {
+	select {
+	case <-l.offered:
+		return true
+	default:
+		return false
+	}
+}
+
+// tcpConn is a raw TCP connection, with the stats counters of the connection it is unwrapped from.
+type tcpConn struct {
+	*net.TCPConn
+	readCounter  stats.Counter
+	writeCounter stats.Counter
+	readRate     stats.Rate
+	writeRate    stats.Rate
+}
+
+func unwrapTCPConn(conn net.Conn) *tcpConn {
+	if !spliceSupported {
+		return nil
+	}
+	c := new(tcpConn)
+	if sc, ok := conn.(*internet.StatCouterConnection); ok {
+		c.readCounter = sc.ReadCounter
+		c.writeCounter = sc.WriteCounter
+		c.readRate = sc.ReadRate
+		c.writeRate = sc.WriteRate
+		conn = sc.Connection
+	}
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	c.TCPConn = tc
+	return c
+}
+
+func (c *tcpConn) addRead(n int64) {
+	if c.readCounter != nil {
+		c.readCounter.Add(n)
+	}
+	if c.readRate != nil {
+		c.readRate.Add(n)
+	}
+}
+
+func (c *tcpConn) addWritten(n int64) {
+	if c.writeCounter != nil {
+		c.writeCounter.Add(n)
+	}
+	if c.writeRate != nil {
+		c.writeRate.Add(n)
+	}
+}
+
+// CopyToConn copies data from the reader to the writer of the connection, as buf.Copy does.
+// Once the reading side of the same direction takes over, it returns after the reading side relays the rest of the data to the connection with splice(2).
+//
+// v2ray:api:beta
+func CopyToConn(ctx context.Context, dir SpliceDirection, conn net.Conn, reader buf.Reader, writer buf.Writer, timer signal.ActivityUpdater) error {
+	l := spliceLinkFromContext(ctx, dir)
+	c := unwrapTCPConn(conn)
+	if l == nil || c == nil || !l.offer(c, timer) {
+		return buf.Copy(reader, writer, buf.UpdateActivity(timer))
+	}
+	defer close(l.exited)
+
+	if err := buf.Copy(reader, writer, buf.UpdateActivity(timer)); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&l.handoff) == 0 {
+		return nil
+	}
+
+	// All data before the hand-off is written, and the rest goes to the connection directly.
+	close(l.flushed)
+	select {
+	case <-l.done:
+		return l.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CopyFromConn copies data from the reader of the connection to the writer, as buf.Copy does.
+// When the writing side of the same direction offers a raw TCP connection, and the writer is not wrapped for user stats or policies,
+// it relays the rest of the data from the connection to the other one with splice(2).
+//
+// v2ray:api:beta
+func CopyFromConn(ctx context.Context, dir SpliceDirection, conn net.Conn, reader buf.Reader, writer buf.Writer, timer signal.ActivityUpdater) error {
+	l := spliceLinkFromContext(ctx, dir)
+	c := unwrapTCPConn(conn)
+	pw, isPipe := writer.(*pipe.Writer)
+	if l == nil || c == nil || !isPipe {
+		return buf.Copy(reader, writer, buf.UpdateActivity(timer))
+	}
+
+	for {
+		if l.isOffered() && bufferedBytes(reader) == 0 {
+			return l.splice(ctx, c, pw, timer)
+		}
+
+		mb, err := reader.ReadMultiBuffer()
+		if !mb.IsEmpty() {
+			timer.Update()
+			if werr := writer.WriteMultiBuffer(mb); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			if errors.Cause(err) == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func bufferedBytes(reader buf.Reader) int32 {
+	if r, ok := reader.(*buf.BufferedReader); ok {
+		return r.BufferedBytes()
+	}
+	return 0
+}
+
+func (l *spliceLink) splice(ctx context.Context, src *tcpConn, writer *pipe.Writer, timer signal.ActivityUpdater) error {
+	defer close(l.done)
+
+	// Let the writing side drain the pipe, before writing to its connection.
+	atomic.StoreInt32(&l.handoff, 1)
+	common.Must(writer.Close())
+	select {
+	case <-l.flushed:
+	case <-l.exited:
+		return newError("writer exited before splicing")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	dst := l.conn
+	newError("splicing ", src.RemoteAddr(), " to ", dst.RemoteAddr()).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+	l.err = spliceTCP(dst.TCPConn, src.TCPConn, func(n int64) {
+		src.addRead(n)
+		dst.addWritten(n)
+		timer.Update()
+		l.timer.Update()
+	})
+	return l.err
+}
@@ -0,0 +1,74 @@
+// +build !confonly
+
+package proxy
+
+import (
+	"golang.org/x/sys/unix"
+
+	"v2ray.com/core/common/net"
+)
+
+const (
+	spliceSupported = true
+	maxSpliceSize   = 1 << 20
+)
+
+// spliceTCP relays data from src to dst through a pipe in the kernel, until src reaches EOF.
+func spliceTCP(dst, src *net.TCPConn, onData func(int64)) error {
+	srcConn, err := src.SyscallConn()
+	if err != nil {
+		return err
+	}
+	dstConn, err := dst.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var p [2]int
+	if err := unix.Pipe2(p[:], unix.O_CLOEXEC|unix.O_NONBLOCK); err != nil {
+		return newError("failed to create pipe").Base(err)
+	}
+	defer unix.Close(p[0])
+	defer unix.Close(p[1])
+
+	for {
+		var n int64
+		var serr error
+		if err := srcConn.Read(func(fd uintptr) bool {
+			for {
+				n, serr = unix.Splice(int(fd), nil, p[1], nil, maxSpliceSize, unix.SPLICE_F_MOVE|unix.SPLICE_F_NONBLOCK)
+				if serr != unix.EINTR {
+					return serr != unix.EAGAIN
+				}
+			}
+		}); err != nil {
+			return err
+		}
+		if serr != nil {
+			return newError("failed to splice from ", src.RemoteAddr()).Base(serr)
+		}
+		if n == 0 {
+			return nil
+		}
+
+		// The pipe is always drained, so that reading from src never blocks on a full pipe.
+		for remain := n; remain > 0; {
+			var m int64
+			if err := dstConn.Write(func(fd uintptr) bool {
+				for {
+					m, serr = unix.Splice(p[0], nil, int(fd), nil, int(remain), unix.SPLICE_F_MOVE|unix.SPLICE_F_NONBLOCK)
+					if serr != unix.EINTR {
+						return serr != unix.EAGAIN
+					}
+				}
+			}); err != nil {
+				return err
+			}
+			if serr != nil {
+				return newError("failed to splice to ", dst.RemoteAddr()).Base(serr)
+			}
+			remain -= m
+		}
+		onData(n)
+	}
+}
@@ -0,0 +1,195 @@
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app/stats"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/signal"
+	. "v2ray.com/core/proxy"
+	"v2ray.com/core/transport/internet"
+	"v2ray.com/core/transport/pipe"
+)
+
+// tcpPair returns both ends of a loopback TCP connection.
+func tcpPair() (*net.TCPConn, *net.TCPConn) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})
+	common.Must(err)
+	defer l.Close()
+
+	client, err := net.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+	common.Must(err)
+	server, err := l.AcceptTCP()
+	common.Must(err)
+	return client, server
+}
+
+type countingWriter struct {
+	buf.Writer
+	n int64
+}
+
+func (w *countingWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	atomic.AddInt64(&w.n, int64(mb.Len()))
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+// relay copies data from src to dst, as an inbound and an outbound do through the pipe of a link.
+func relay(ctx context.Context, src, dst net.Conn, writer buf.Writer) <-chan error {
+	timer := signal.CancelAfterInactivity(ctx, func() {}, time.Minute)
+	r, w := pipe.New(pipe.WithSizeLimit(512 * 1024))
+	errs := make(chan error, 2)
+	go func() {
+		err := CopyFromConn(ctx, SpliceUplink, src, buf.NewReader(src), w, timer)
+		common.Must(w.Close())
+		errs <- err
+	}()
+	go func() {
+		errs <- CopyToConn(ctx, SpliceUplink, dst, r, writer, timer)
+	}()
+	return errs
+}
+
+func TestSpliceRelay(t *testing.T) {
+	client, in := tcpPair()
+	defer client.Close()
+	defer in.Close()
+	out, remote := tcpPair()
+	defer out.Close()
+	defer remote.Close()
+
+	readCounter := new(stats.Counter)
+	writeCounter := new(stats.Counter)
+	inConn := &internet.StatCouterConnection{Connection: in, ReadCounter: readCounter}
+	outConn := &internet.StatCouterConnection{Connection: out, WriteCounter: writeCounter}
+	writer := &countingWriter{Writer: buf.NewWriter(outConn)}
+	errs := relay(ContextWithSplice(context.Background()), inConn, outConn, writer)
+
+	// The first message goes through the link, when the outbound has offered its connection.
+	// The inbound may have started reading the next one before that.
+	common.Must2(client.Write([]byte("ping")))
+	b := make([]byte, 4)
+	common.Must2(io.ReadFull(remote, b))
+
+	payload := make([]byte, 8*1024*1024)
+	common.Must2(rand.Read(payload))
+	go func() {
+		common.Must2(client.Write(payload))
+		common.Must(client.CloseWrite())
+	}()
+
+	received := make([]byte, len(payload))
+	common.Must(remote.SetReadDeadline(time.Now().Add(time.Minute)))
+	common.Must2(io.ReadFull(remote, received))
+	if !bytes.Equal(received, payload) {
+		t.Error("unexpected data from relay")
+	}
+	for i := 0; i < 2; i++ {
+		common.Must(<-errs)
+	}
+
+	if n := atomic.LoadInt64(&writer.n); n > 4+buf.Size {
+		t.Error("expect data spliced, but ", n, " bytes are written through the link")
+	}
+	total := int64(len(payload) + 4)
+	if v := readCounter.Value(); v != total {
+		t.Error("read counter: ", v, ", want ", total)
+	}
+	if v := writeCounter.Value(); v != total {
+		t.Error("write counter: ", v, ", want ", total)
+	}
+}
+
+// testRelayWithoutSplice checks that all data goes through the link in the context.
+func testRelayWithoutSplice(t *testing.T, ctx context.Context) {
+	client, in := tcpPair()
+	defer client.Close()
+	defer in.Close()
+	out, remote := tcpPair()
+	defer out.Close()
+	defer remote.Close()
+
+	writer := &countingWriter{Writer: buf.NewWriter(out)}
+	errs := relay(ctx, in, out, writer)
+
+	payload := make([]byte, 1024*1024)
+	common.Must2(rand.Read(payload))
+	go func() {
+		common.Must2(client.Write(payload))
+		common.Must(client.CloseWrite())
+	}()
+
+	received := make([]byte, len(payload))
+	common.Must(remote.SetReadDeadline(time.Now().Add(time.Minute)))
+	common.Must2(io.ReadFull(remote, received))
+	if !bytes.Equal(received, payload) {
+		t.Error("unexpected data from relay")
+	}
+	for i := 0; i < 2; i++ {
+		common.Must(<-errs)
+	}
+	if n := atomic.LoadInt64(&writer.n); n != int64(len(payload)) {
+		t.Error("expect all data through the link, but got ", n, " bytes")
+	}
+}
+
+func TestSpliceFallback(t *testing.T) {
+	// Without the context from an inbound, data always goes through the link.
+	testRelayWithoutSplice(t, context.Background())
+}
+
+func TestSpliceRemovedFromContext(t *testing.T) {
+	testRelayWithoutSplice(t, ContextWithoutSplice(ContextWithSplice(context.Background())))
+}
+
+func cpuTime() time.Duration {
+	var usage syscall.Rusage
+	common.Must(syscall.Getrusage(syscall.RUSAGE_SELF, &usage))
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
+
+func benchmarkRelay(ctx context.Context, b *testing.B) {
+	client, in := tcpPair()
+	defer client.Close()
+	defer in.Close()
+	out, remote := tcpPair()
+	defer out.Close()
+	defer remote.Close()
+
+	errs := relay(ctx, in, out, buf.NewWriter(out))
+
+	chunk := make([]byte, 64*1024)
+	b.SetBytes(int64(len(chunk)))
+	b.ResetTimer()
+	start := cpuTime()
+
+	go func() {
+		for i := 0; i < b.N; i++ {
+			common.Must2(client.Write(chunk))
+		}
+		common.Must(client.CloseWrite())
+	}()
+	common.Must2(io.CopyN(buf.DiscardBytes, remote, int64(b.N*len(chunk))))
+	for i := 0; i < 2; i++ {
+		common.Must(<-errs)
+	}
+
+	b.ReportMetric(float64(cpuTime()-start)/float64(b.N), "cpu-ns/op")
+}
+
+func BenchmarkRelayCopy(b *testing.B) {
+	benchmarkRelay(context.Background(), b)
+}
+
+func BenchmarkRelaySplice(b *testing.B) {
+	benchmarkRelay(ContextWithSplice(context.Background()), b)
+}
@@ -0,0 +1,13 @@
+// +build !linux,!confonly
+
+package proxy
+
+import (
+	"v2ray.com/core/common/net"
+)
+
+const spliceSupported = false
+
+func spliceTCP(dst, src *net.TCPConn, onData func(int64)) error {
+	return newError("splice is not supported on this platform")
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	fmt "fmt"
 
+	"github.com/golang/protobuf/proto"
+
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/protocol"
 )
@@ -25,6 +27,13 @@ func (a *Account) AsAccount() (protocol.Account, error) {
 	}, nil
 }
 
+// ToProto implements protocol.ToProtoAccount.ToProto().
+func (a *MemoryAccount) ToProto() proto.Message {
+	return &Account{
+		Password: a.Password,
+	}
+}
+
 // Equals implements protocol.Account.Equals().
 func (a *MemoryAccount) Equals(another protocol.Account) bool {
 	if account, ok := another.(*MemoryAccount); ok {
@@ -85,6 +85,21 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	return server, nil
 }
 
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	return s.validator.Add(u)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	return s.validator.Del(e)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
 // Network implements proxy.Inbound.Network().
 func (s *Server) Network() []net.Network {
 	return []net.Network{net.Network_TCP}
@@ -179,7 +194,21 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn internet
 		panic("no inbound metadata")
 	}
 	inbound.User = user
-	sessionPolicy = s.policyManager.ForLevel(user.Level)
+
+	release, err := policy.AcquireUser(s.policyManager, user, inbound.Source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   conn.RemoteAddr(),
+			To:     destination,
+			Status: log.AccessRejected,
+			Reason: err,
+			Email:  user.Email,
+		})
+		return newError("rejected request from ", conn.RemoteAddr()).Base(err)
+	}
+	defer release()
+
+	sessionPolicy = s.policyManager.ForUser(user.Level, user.Email)
 
 	if destination.Network == net.Network_UDP { // handle udp request
 		return s.handleUDPPayload(ctx, &PacketReader{Reader: clientReader}, &PacketWriter{Writer: conn}, dispatcher)
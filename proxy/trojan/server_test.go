@@ -0,0 +1,66 @@
+package trojan_test
+
+import (
+	"context"
+	"testing"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	. "v2ray.com/core/proxy/trojan"
+)
+
+const v2rayKey core.V2rayKey = 1
+
+func newUser(email, password string) *protocol.MemoryUser {
+	return &protocol.MemoryUser{
+		Email:   email,
+		Account: toAccount(&Account{Password: password}),
+	}
+}
+
+func TestServerManageUsers(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{serial.ToTypedMessage(&policy.Config{})},
+	})
+	common.Must(err)
+	ctx := context.WithValue(context.Background(), v2rayKey, v)
+
+	server, err := NewServer(ctx, &ServerConfig{
+		Users: []*protocol.User{
+			{Email: "a@v2fly.org", Account: serial.ToTypedMessage(&Account{Password: "password-a"})},
+		},
+	})
+	common.Must(err)
+
+	common.Must(server.AddUser(ctx, newUser("b@v2fly.org", "password-b")))
+	if err := server.AddUser(ctx, newUser("B@v2fly.org", "password-c")); err == nil {
+		t.Error("expect error on duplicated email")
+	}
+	if err := server.AddUser(ctx, newUser("c@v2fly.org", "password-a")); err == nil {
+		t.Error("expect error on duplicated password")
+	}
+	if users := server.GetUsers(ctx); len(users) != 2 {
+		t.Error("users: ", users)
+	}
+
+	common.Must(server.RemoveUser(ctx, "A@v2fly.org"))
+	if err := server.RemoveUser(ctx, "a@v2fly.org"); err == nil {
+		t.Error("expect error on removing missing user")
+	}
+	if err := server.RemoveUser(ctx, ""); err == nil {
+		t.Error("expect error on removing user without email")
+	}
+	users := server.GetUsers(ctx)
+	if len(users) != 1 || users[0].Email != "b@v2fly.org" {
+		t.Error("users: ", users)
+	}
+
+	// The password of a removed user is available again.
+	common.Must(server.AddUser(ctx, newUser("c@v2fly.org", "password-a")))
+	if users := server.GetUsers(ctx); len(users) != 2 {
+		t.Error("users: ", users)
+	}
+}
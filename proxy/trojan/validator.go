@@ -1,6 +1,7 @@
 package trojan
 
 import (
+	"strings"
 	"sync"
 
 	"v2ray.com/core/common/protocol"
@@ -8,16 +9,52 @@ import (
 
 // Validator stores valid trojan users
 type Validator struct {
+	// Considering email's usage here, map + sync.Mutex/RWMutex may have better performance.
+	email sync.Map
 	users sync.Map
 }
 
-// Add a trojan user
+// Add a trojan user, Email must be empty or unique, and password must be unique.
 func (v *Validator) Add(u *protocol.MemoryUser) error {
-	user := u.Account.(*MemoryAccount)
-	v.users.Store(hexString(user.Key), u)
+	key := hexString(u.Account.(*MemoryAccount).Key)
+	if existing, loaded := v.users.LoadOrStore(key, u); loaded {
+		return newError("User ", u.Email, " has the same password as user ", existing.(*protocol.MemoryUser).Email)
+	}
+	if u.Email != "" {
+		_, loaded := v.email.LoadOrStore(strings.ToLower(u.Email), u)
+		if loaded {
+			v.users.Delete(key)
+			return newError("User ", u.Email, " already exists.")
+		}
+	}
 	return nil
 }
 
+// Del a trojan user with a non-empty Email.
+func (v *Validator) Del(e string) error {
+	if e == "" {
+		return newError("Email must not be empty.")
+	}
+	le := strings.ToLower(e)
+	u, _ := v.email.Load(le)
+	if u == nil {
+		return newError("User ", e, " not found.")
+	}
+	v.email.Delete(le)
+	v.users.Delete(hexString(u.(*protocol.MemoryUser).Account.(*MemoryAccount).Key))
+	return nil
+}
+
+// GetAll returns all trojan users.
+func (v *Validator) GetAll() []*protocol.MemoryUser {
+	var users []*protocol.MemoryUser
+	v.users.Range(func(key, value interface{}) bool {
+		users = append(users, value.(*protocol.MemoryUser))
+		return true
+	})
+	return users
+}
+
 // Get user with hashed key, nil if user doesn't exist.
 func (v *Validator) Get(hash string) *protocol.MemoryUser {
 	u, _ := v.users.Load(hash)
@@ -3,6 +3,8 @@
 package vless
 
 import (
+	"github.com/golang/protobuf/proto"
+
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/common/uuid"
 )
@@ -30,6 +32,15 @@ type MemoryAccount struct {
 	Encryption string
 }
 
+// ToProto implements protocol.ToProtoAccount.ToProto().
+func (a *MemoryAccount) ToProto() proto.Message {
+	return &Account{
+		Id:         a.ID.String(),
+		Flow:       a.Flow,
+		Encryption: a.Encryption,
+	}
+}
+
 // Equals implements protocol.Account.Equals().
 func (a *MemoryAccount) Equals(account protocol.Account) bool {
 	vlessAccount, ok := account.(*MemoryAccount)
@@ -41,6 +41,15 @@ func (v *Validator) Del(e string) error {
 	return nil
 }
 
+func (v *Validator) GetAll() []*protocol.MemoryUser {
+	var users []*protocol.MemoryUser
+	v.users.Range(func(key, value interface{}) bool {
+		users = append(users, value.(*protocol.MemoryUser))
+		return true
+	})
+	return users
+}
+
 func (v *Validator) Get(id uuid.UUID) *protocol.MemoryUser {
 	u, _ := v.users.Load(id)
 	if u != nil {
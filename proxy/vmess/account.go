@@ -3,6 +3,8 @@
 package vmess
 
 import (
+	"github.com/golang/protobuf/proto"
+
 	"v2ray.com/core/common/dice"
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/common/uuid"
@@ -36,6 +38,17 @@ func (a *MemoryAccount) Equals(account protocol.Account) bool {
 	return a.ID.Equals(vmessAccount.ID)
 }
 
+// ToProto implements protocol.ToProtoAccount.
+func (a *MemoryAccount) ToProto() proto.Message {
+	return &Account{
+		Id:      a.ID.String(),
+		AlterId: uint32(len(a.AlterIDs)),
+		SecuritySettings: &protocol.SecurityConfig{
+			Type: a.Security,
+		},
+	}
+}
+
 // AsAccount implements protocol.Account.
 func (a *Account) AsAccount() (protocol.Account, error) {
 	id, err := uuid.ParseString(a.Id)
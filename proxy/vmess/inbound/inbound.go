@@ -166,6 +166,11 @@ func (h *Handler) AddUser(ctx context.Context, user *protocol.MemoryUser) error
 	return h.clients.Add(user)
 }
 
+// GetUsers implements proxy.UserManager.
+func (h *Handler) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return h.clients.GetUsers()
+}
+
 func (h *Handler) RemoveUser(ctx context.Context, email string) error {
 	if email == "" {
 		return newError("Email must not be empty.")
@@ -271,7 +276,20 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection i
 	}
 	inbound.User = request.User
 
-	sessionPolicy = h.policyManager.ForLevel(request.User.Level)
+	release, err := policy.AcquireUser(h.policyManager, request.User, inbound.Source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   connection.RemoteAddr(),
+			To:     request.Destination(),
+			Status: log.AccessRejected,
+			Reason: err,
+			Email:  request.User.Email,
+		})
+		return newError("rejected request from ", connection.RemoteAddr()).Base(err)
+	}
+	defer release()
+
+	sessionPolicy = h.policyManager.ForUser(request.User.Level, request.User.Email)
 
 	ctx, cancel := context.WithCancel(ctx)
 	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
@@ -186,6 +186,18 @@ func (v *TimedUserValidator) GetAEAD(userHash []byte) (*protocol.MemoryUser, boo
 	return userd.(*protocol.MemoryUser), true, err
 }
 
+// GetUsers returns all users in this validator.
+func (v *TimedUserValidator) GetUsers() []*protocol.MemoryUser {
+	v.RLock()
+	defer v.RUnlock()
+
+	users := make([]*protocol.MemoryUser, 0, len(v.users))
+	for _, u := range v.users {
+		users = append(users, &u.user)
+	}
+	return users
+}
+
 func (v *TimedUserValidator) Remove(email string) bool {
 	v.Lock()
 	defer v.Unlock()
@@ -0,0 +1,331 @@
+// +build !confonly
+
+package core
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/features"
+	"v2ray.com/core/features/inbound"
+	"v2ray.com/core/features/outbound"
+)
+
+// appFeature is an app config and the feature created from it. The feature may be nil if the app is not a feature.
+type appFeature struct {
+	config  proto.Message
+	feature features.Feature
+}
+
+// SetConfigLoader sets the function that loads config for Reload.
+func (s *Instance) SetConfigLoader(loader func() (*Config, error)) {
+	s.reloadAccess.Lock()
+	defer s.reloadAccess.Unlock()
+
+	s.configLoader = loader
+}
+
+// Reload applies the config to the running Instance. If config is nil, it is loaded by the function set in SetConfigLoader.
+//
+// Inbound and outbound handlers that are created from config are matched by tag. Only changed handlers are added, replaced or removed,
+// and the replaced or removed ones are drained for at most drainTimeout. Handlers without tag are left untouched.
+// Apps that implement features.Reloadable take their new config. Changes to other apps take effect after restart.
+//
+// The whole config is validated before any change is made. If it is invalid, the running Instance stays unchanged.
+func (s *Instance) Reload(config *Config, drainTimeout time.Duration) error {
+	s.reloadAccess.Lock()
+	defer s.reloadAccess.Unlock()
+
+	if config == nil {
+		if s.configLoader == nil {
+			return newError("no config to reload")
+		}
+		c, err := s.configLoader()
+		if err != nil {
+			return newError("failed to load config").Base(err)
+		}
+		config = c
+	}
+
+	appChanges, err := s.prepareApps(config)
+	if err != nil {
+		return err
+	}
+
+	ihm := s.GetFeature(inbound.ManagerType()).(inbound.Manager)
+	ohm := s.GetFeature(outbound.ManagerType()).(outbound.Manager)
+
+	inboundChanges, err := s.prepareInbounds(ihm, config.Inbound)
+	if err != nil {
+		appChanges.discard()
+		return err
+	}
+	outboundChanges, err := s.prepareOutbounds(ohm, config.Outbound)
+	if err != nil {
+		appChanges.discard()
+		inboundChanges.discard()
+		return err
+	}
+
+	var errs []error
+	// New outbounds are ready before routing to them, and old ones are removed after routing away from them.
+	errs = append(errs, s.addOutbounds(ohm, outboundChanges, drainTimeout)...)
+	appChanges.apply()
+	errs = append(errs, s.applyInbounds(ihm, inboundChanges, drainTimeout)...)
+	errs = append(errs, s.removeOutbounds(ohm, outboundChanges, drainTimeout)...)
+
+	if len(errs) > 0 {
+		return newError("config is partially reloaded").Base(errs[0])
+	}
+	newError("config reloaded").AtWarning().WriteToLog()
+	return nil
+}
+
+// appChanges are the changes of apps in a reload.
+type appChanges struct {
+	applies  []func()
+	discards []func()
+}
+
+// apply applies the changes.
+func (c *appChanges) apply() {
+	for _, apply := range c.applies {
+		apply()
+	}
+}
+
+// discard releases the resources created for the changes.
+func (c *appChanges) discard() {
+	for _, discard := range c.discards {
+		discard()
+	}
+}
+
+// prepareApps validates app configs, and prepares changes to the changed ones.
+func (s *Instance) prepareApps(config *Config) (*appChanges, error) {
+	newConfigs := make(map[reflect.Type]proto.Message, len(config.App))
+	for _, app := range config.App {
+		settings, err := app.GetInstance()
+		if err != nil {
+			return nil, err
+		}
+		newConfigs[reflect.TypeOf(settings)] = settings
+	}
+
+	s.access.Lock()
+	apps := append([]*appFeature(nil), s.apps...)
+	s.access.Unlock()
+
+	changes := new(appChanges)
+	for _, app := range apps {
+		t := reflect.TypeOf(app.config)
+		newConfig, found := newConfigs[t]
+		if !found {
+			newConfig = reflect.New(t.Elem()).Interface().(proto.Message)
+		}
+		delete(newConfigs, t)
+
+		if proto.Equal(app.config, newConfig) {
+			continue
+		}
+		reloadable, ok := app.feature.(features.Reloadable)
+		if !ok {
+			newError("changes to ", t, " take effect after restart").AtWarning().WriteToLog()
+			continue
+		}
+		apply, discard, err := reloadable.PrepareReload(newConfig)
+		if err != nil {
+			changes.discard()
+			return nil, newError("invalid config ", t).Base(err)
+		}
+		if discard != nil {
+			changes.discards = append(changes.discards, discard)
+		}
+		app := app
+		changes.applies = append(changes.applies, func() {
+			apply()
+			s.access.Lock()
+			app.config = newConfig
+			s.access.Unlock()
+		})
+	}
+
+	for t := range newConfigs {
+		newError("new app ", t, " takes effect after restart").AtWarning().WriteToLog()
+	}
+
+	return changes, nil
+}
+
+// handlerConfig is implemented by InboundHandlerConfig and OutboundHandlerConfig.
+type handlerConfig interface {
+	proto.Message
+	GetTag() string
+}
+
+// handlerWithConfig is implemented by handlers that keep the config they are created from.
+type handlerWithConfig interface {
+	Tag() string
+	Config() handlerConfig
+}
+
+// handlerChanges are the changes of handlers in a reload. Handlers in added and replaced are created but not started.
+type handlerChanges struct {
+	added    []interface{}
+	replaced []interface{}
+	removed  []string
+}
+
+// discard closes the created handlers.
+func (c *handlerChanges) discard() {
+	for _, h := range c.added {
+		common.Close(h) // nolint: errcheck
+	}
+	for _, h := range c.replaced {
+		common.Close(h) // nolint: errcheck
+	}
+}
+
+// diffHandlers matches the running handlers and new configs by tag, and creates handlers for new or changed configs.
+func (s *Instance) diffHandlers(kind string, running []handlerWithConfig, configs []handlerConfig) (*handlerChanges, error) {
+	current := make(map[string]handlerConfig)
+	untagged := 0
+	for _, h := range running {
+		if tag := h.Tag(); tag != "" {
+			current[tag] = h.Config()
+		} else {
+			untagged++
+		}
+	}
+
+	changes := new(handlerChanges)
+	for _, config := range configs {
+		tag := config.GetTag()
+		if tag == "" {
+			untagged--
+			continue
+		}
+		old, found := current[tag]
+		delete(current, tag)
+		if found && proto.Equal(old, config) {
+			continue
+		}
+		handler, err := CreateObject(s, config)
+		if err != nil {
+			changes.discard()
+			return nil, newError("invalid ", kind, " ", tag).Base(err)
+		}
+		if found {
+			changes.replaced = append(changes.replaced, handler)
+		} else {
+			changes.added = append(changes.added, handler)
+		}
+	}
+	for tag := range current {
+		changes.removed = append(changes.removed, tag)
+	}
+	if untagged != 0 {
+		newError("changes to ", kind, "s without tag take effect after restart").AtWarning().WriteToLog()
+	}
+
+	return changes, nil
+}
+
+type inboundWithConfig struct {
+	inbound.Handler
+	config *InboundHandlerConfig
+}
+
+func (h inboundWithConfig) Config() handlerConfig {
+	return h.config
+}
+
+func (s *Instance) prepareInbounds(ihm inbound.Manager, configs []*InboundHandlerConfig) (*handlerChanges, error) {
+	var running []handlerWithConfig
+	for _, handler := range ihm.ListHandlers(s.ctx) {
+		if c, ok := handler.(interface{ Config() *InboundHandlerConfig }); ok && c.Config() != nil {
+			running = append(running, inboundWithConfig{Handler: handler, config: c.Config()})
+		}
+	}
+	newConfigs := make([]handlerConfig, 0, len(configs))
+	for _, config := range configs {
+		newConfigs = append(newConfigs, config)
+	}
+	return s.diffHandlers("inbound", running, newConfigs)
+}
+
+type outboundWithConfig struct {
+	outbound.Handler
+	config *OutboundHandlerConfig
+}
+
+func (h outboundWithConfig) Config() handlerConfig {
+	return h.config
+}
+
+func (s *Instance) prepareOutbounds(ohm outbound.Manager, configs []*OutboundHandlerConfig) (*handlerChanges, error) {
+	var running []handlerWithConfig
+	for _, handler := range ohm.ListHandlers(s.ctx) {
+		if c, ok := handler.(interface{ Config() *OutboundHandlerConfig }); ok && c.Config() != nil {
+			running = append(running, outboundWithConfig{Handler: handler, config: c.Config()})
+		}
+	}
+	if len(configs) > 0 {
+		if d := ohm.GetDefaultHandler(); d != nil && d.Tag() != configs[0].Tag {
+			newError("change of default outbound takes effect after restart").AtWarning().WriteToLog()
+		}
+	}
+	newConfigs := make([]handlerConfig, 0, len(configs))
+	for _, config := range configs {
+		newConfigs = append(newConfigs, config)
+	}
+	return s.diffHandlers("outbound", running, newConfigs)
+}
+
+func (s *Instance) applyInbounds(ihm inbound.Manager, changes *handlerChanges, drainTimeout time.Duration) []error {
+	var errs []error
+	for _, h := range changes.added {
+		if err := ihm.AddHandler(s.ctx, h.(inbound.Handler)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, h := range changes.replaced {
+		if err := ihm.ReplaceHandler(s.ctx, h.(inbound.Handler), drainTimeout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, tag := range changes.removed {
+		if err := ihm.DrainHandler(s.ctx, tag, drainTimeout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (s *Instance) addOutbounds(ohm outbound.Manager, changes *handlerChanges, drainTimeout time.Duration) []error {
+	var errs []error
+	for _, h := range changes.added {
+		if err := ohm.AddHandler(s.ctx, h.(outbound.Handler)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, h := range changes.replaced {
+		if err := ohm.ReplaceHandler(s.ctx, h.(outbound.Handler), drainTimeout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (s *Instance) removeOutbounds(ohm outbound.Manager, changes *handlerChanges, drainTimeout time.Duration) []error {
+	var errs []error
+	for _, tag := range changes.removed {
+		if err := ohm.DrainHandler(s.ctx, tag, drainTimeout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -496,3 +496,126 @@ func TestCommanderStats(t *testing.T) {
 		t.Error("value < 10240*1024: ", sresp.Stat.Value)
 	}
 }
+
+func TestCommanderListHandlersAndUsers(t *testing.T) {
+	u1 := protocol.NewID(uuid.New())
+
+	cmdPort := tcp.PickPort()
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&commander.Config{
+				Tag: "api",
+				Service: []*serial.TypedMessage{
+					serial.ToTypedMessage(&command.Config{}),
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						InboundTag: []string{"api"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "api",
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				Tag: "v",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&inbound.Config{
+					User: []*protocol.User{
+						{
+							Email: "love@v2ray.com",
+							Level: 1,
+							Account: serial.ToTypedMessage(&vmess.Account{
+								Id:      u1.String(),
+								AlterId: 4,
+								SecuritySettings: &protocol.SecurityConfig{
+									Type: protocol.SecurityType_AES128_GCM,
+								},
+							}),
+						},
+					},
+				}),
+			},
+			{
+				Tag: "api",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(cmdPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(net.LocalHostIP),
+					Port:     uint32(cmdPort),
+					Networks: []net.Network{net.Network_TCP},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	cmdConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", cmdPort), grpc.WithInsecure(), grpc.WithBlock())
+	common.Must(err)
+	defer cmdConn.Close()
+
+	hsClient := command.NewHandlerServiceClient(cmdConn)
+
+	inbounds, err := hsClient.ListInbounds(context.Background(), &command.ListInboundsRequest{})
+	common.Must(err)
+	if len(inbounds.Inbounds) != 2 || inbounds.Inbounds[0].Tag != "api" || inbounds.Inbounds[1].Tag != "v" {
+		t.Fatal("unexpected inbounds: ", inbounds)
+	}
+	if inbounds.Inbounds[1].ProxySettings == nil {
+		t.Error("missing proxy settings of inbound v")
+	}
+
+	outbounds, err := hsClient.ListOutbounds(context.Background(), &command.ListOutboundsRequest{})
+	common.Must(err)
+	if len(outbounds.Outbounds) != 1 || outbounds.Outbounds[0].Tag != "direct" {
+		t.Fatal("unexpected outbounds: ", outbounds)
+	}
+
+	users, err := hsClient.ListUsers(context.Background(), &command.ListUsersRequest{Tag: "v"})
+	common.Must(err)
+	if len(users.Users) != 1 {
+		t.Fatal("unexpected users: ", users)
+	}
+
+	user, err := hsClient.GetUser(context.Background(), &command.GetUserRequest{Tag: "v", Email: "love@v2ray.com"})
+	common.Must(err)
+	if r := cmp.Diff(user.User, &protocol.User{
+		Email: "love@v2ray.com",
+		Level: 1,
+		Account: serial.ToTypedMessage(&vmess.Account{
+			Id:      u1.String(),
+			AlterId: 4,
+			SecuritySettings: &protocol.SecurityConfig{
+				Type: protocol.SecurityType_AES128_GCM,
+			},
+		}),
+	}, cmpopts.IgnoreUnexported(protocol.User{}, serial.TypedMessage{})); r != "" {
+		t.Error(r)
+	}
+
+	if _, err := hsClient.GetUser(context.Background(), &command.GetUserRequest{Tag: "v", Email: "none@v2ray.com"}); err == nil {
+		t.Error("expected error for unknown user")
+	}
+	if _, err := hsClient.ListUsers(context.Background(), &command.ListUsersRequest{Tag: "api"}); err == nil {
+		t.Error("expected error for inbound without user manager")
+	}
+}
@@ -1,7 +1,10 @@
 package internet
 
 import (
+	"github.com/golang/protobuf/proto"
+
 	"v2ray.com/core/common/serial"
+	"v2ray.com/core/common/session"
 	"v2ray.com/core/features"
 )
 
@@ -122,3 +125,26 @@ func (c *ProxyConfig) HasTag() bool {
 func (m SocketConfig_TProxyMode) IsEnabled() bool {
 	return m != SocketConfig_Off
 }
+
+// HasTCPKeepAlive returns true if any of the TCP keepalive options is set.
+func (c *SocketConfig) HasTCPKeepAlive() bool {
+	return c.TcpKeepAliveIdle > 0 || c.TcpKeepAliveInterval > 0 || c.TcpKeepAliveCount > 0
+}
+
+// withSessionSockopt returns a copy of the config, with the mark and interface overridden by the session.
+func (c *SocketConfig) withSessionSockopt(s *session.Sockopt) *SocketConfig {
+	if s.Mark == 0 && len(s.Interface) == 0 {
+		return c
+	}
+	config := new(SocketConfig)
+	if c != nil {
+		config = proto.Clone(c).(*SocketConfig)
+	}
+	if s.Mark != 0 {
+		config.Mark = s.Mark
+	}
+	if len(s.Interface) > 0 {
+		config.Interface = s.Interface
+	}
+	return config
+}
@@ -14,6 +14,8 @@ type StatCouterConnection struct {
 	Connection
 	ReadCounter  stats.Counter
 	WriteCounter stats.Counter
+	ReadRate     stats.Rate
+	WriteRate    stats.Rate
 }
 
 func (c *StatCouterConnection) Read(b []byte) (int, error) {
@@ -21,6 +23,9 @@ func (c *StatCouterConnection) Read(b []byte) (int, error) {
 	if c.ReadCounter != nil {
 		c.ReadCounter.Add(int64(nBytes))
 	}
+	if c.ReadRate != nil {
+		c.ReadRate.Add(int64(nBytes))
+	}
 
 	return nBytes, err
 }
@@ -30,5 +35,8 @@ func (c *StatCouterConnection) Write(b []byte) (int, error) {
 	if c.WriteCounter != nil {
 		c.WriteCounter.Add(int64(nBytes))
 	}
+	if c.WriteRate != nil {
+		c.WriteRate.Add(int64(nBytes))
+	}
 	return nBytes, err
 }
@@ -67,6 +67,12 @@ func DialSystem(ctx context.Context, dest net.Destination, sockopt *SocketConfig
 	var src net.Address
 	if outbound := session.OutboundFromContext(ctx); outbound != nil {
 		src = outbound.Gateway
+		if outbound.Sockopt != nil {
+			if outbound.Sockopt.Source != nil {
+				src = outbound.Sockopt.Source
+			}
+			sockopt = sockopt.withSessionSockopt(outbound.Sockopt)
+		}
 	}
 	return effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
 }
@@ -1,6 +1,4 @@
-// +build !windows
-// +build !wasm
-// +build !confonly
+// +build !windows,!wasm,!confonly
 
 package domainsocket
 
@@ -39,7 +37,9 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 		return nil, err
 	}
 
-	unixListener, err := net.ListenUnix("unix", addr)
+	// The lock of an inherited socket is still held by the previous process.
+	inherited := internet.HasInheritedListener(ctx, addr)
+	unixListener, err := internet.ListenSystem(ctx, addr, nil)
 	if err != nil {
 		return nil, newError("failed to listen domain socket").Base(err).AtWarning()
 	}
@@ -63,7 +63,7 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 		}
 	}
 
-	if !settings.Abstract {
+	if !settings.Abstract && !inherited {
 		ln.locker = &fileLocker{
 			path: settings.Path + ".lock",
 		}
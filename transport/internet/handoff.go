@@ -0,0 +1,156 @@
+package internet
+
+import (
+	"context"
+	"os"
+	"sync"
+	"syscall"
+
+	"v2ray.com/core/common/net"
+)
+
+type listenerTagKey struct{}
+
+// ContextWithListenerTag returns a context for listening sockets of the inbound with the given tag.
+// Sockets listened with such a context can be passed to a new process in an upgrade, and adopted by the same tag and address there.
+//
+// v2ray:api:beta
+func ContextWithListenerTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, listenerTagKey{}, tag)
+}
+
+// handoffKey identifies a listening socket across processes.
+func handoffKey(ctx context.Context, addr net.Addr) (string, bool) {
+	tag, ok := ctx.Value(listenerTagKey{}).(string)
+	if !ok {
+		return "", false
+	}
+	return tag + "|" + addr.Network() + "|" + addr.String(), true
+}
+
+// handoffSocket is implemented by *net.TCPListener, *net.UDPConn and *net.UnixListener.
+type handoffSocket interface {
+	syscall.Conn
+	File() (*os.File, error)
+}
+
+func isSocketClosed(s handoffSocket) bool {
+	rawConn, err := s.SyscallConn()
+	if err != nil {
+		return true
+	}
+	return rawConn.Control(func(uintptr) {}) != nil
+}
+
+type handoffRegistry struct {
+	sync.Mutex
+	// active are the listening sockets of this process.
+	active map[string]handoffSocket
+	// inherited are the sockets passed from the previous process, and not adopted yet.
+	inherited map[string]*os.File
+}
+
+var handoff = &handoffRegistry{
+	active:    make(map[string]handoffSocket),
+	inherited: make(map[string]*os.File),
+}
+
+// track records a listening socket, so that it can be passed in an upgrade.
+func (r *handoffRegistry) track(key string, s interface{}) {
+	socket, ok := s.(handoffSocket)
+	if !ok {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for k, s := range r.active {
+		if isSocketClosed(s) {
+			delete(r.active, k)
+		}
+	}
+	r.active[key] = socket
+}
+
+// take returns the inherited socket of the key, if any.
+func (r *handoffRegistry) take(key string) *os.File {
+	r.Lock()
+	defer r.Unlock()
+
+	f, found := r.inherited[key]
+	if found {
+		delete(r.inherited, key)
+	}
+	return f
+}
+
+func (r *handoffRegistry) adoptListener(ctx context.Context, addr net.Addr) (net.Listener, bool, error) {
+	key, ok := handoffKey(ctx, addr)
+	if !ok {
+		return nil, false, nil
+	}
+	f := r.take(key)
+	if f == nil {
+		return nil, false, nil
+	}
+	defer f.Close()
+
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, true, newError("failed to adopt listener on ", addr).Base(err)
+	}
+	if ul, ok := l.(*net.UnixListener); ok {
+		// The socket file is created by the previous process, and should be removed when this process stops using it.
+		ul.SetUnlinkOnClose(true)
+	}
+	newError("adopted listener on ", addr).AtInfo().WriteToLog()
+	return l, true, nil
+}
+
+func (r *handoffRegistry) adoptPacketConn(ctx context.Context, addr net.Addr) (net.PacketConn, bool, error) {
+	key, ok := handoffKey(ctx, addr)
+	if !ok {
+		return nil, false, nil
+	}
+	f := r.take(key)
+	if f == nil {
+		return nil, false, nil
+	}
+	defer f.Close()
+
+	conn, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, true, newError("failed to adopt packet conn on ", addr).Base(err)
+	}
+	newError("adopted packet conn on ", addr).AtInfo().WriteToLog()
+	return conn, true, nil
+}
+
+// HasInheritedListener returns true if a socket for the listener context and address is passed from the previous process.
+//
+// v2ray:api:beta
+func HasInheritedListener(ctx context.Context, addr net.Addr) bool {
+	key, ok := handoffKey(ctx, addr)
+	if !ok {
+		return false
+	}
+
+	handoff.Lock()
+	defer handoff.Unlock()
+
+	_, found := handoff.inherited[key]
+	return found
+}
+
+// closeInherited closes the inherited sockets that are not adopted.
+func (r *handoffRegistry) closeInherited() {
+	r.Lock()
+	defer r.Unlock()
+
+	for key, f := range r.inherited {
+		newError("closing inherited socket that is not adopted: ", key).AtWarning().WriteToLog()
+		f.Close()
+		delete(r.inherited, key)
+	}
+}
@@ -0,0 +1,209 @@
+// +build linux
+
+package internet
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/platform"
+)
+
+const handoffReady = "ready"
+
+var (
+	handoffFd     = platform.NewEnvFlag("v2ray.handoff.fd")
+	handoffParent *net.UnixConn
+)
+
+func newHandoffConn(fd int) (*net.UnixConn, error) {
+	f := os.NewFile(uintptr(fd), "handoff")
+	defer f.Close()
+
+	conn, err := net.FileConn(f)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.UnixConn), nil
+}
+
+// HandoffListeners starts cmd as a new process, and passes all listening sockets of inbounds to it over a Unix socket.
+// It returns after the new process confirms that it is started, or kills the new process if it fails to do so in timeout.
+// The listeners in this process are still open, and should be drained and closed by the caller.
+// The passed UDP sockets are closed in this process, so that all packets are received by the new process.
+//
+// v2ray:api:beta
+func HandoffListeners(cmd *exec.Cmd, timeout time.Duration) error {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return newError("failed to create socket pair").Base(err)
+	}
+	remote := os.NewFile(uintptr(fds[1]), "handoff")
+	defer remote.Close()
+	conn, err := newHandoffConn(fds[0])
+	if err != nil {
+		return newError("failed to create handoff conn").Base(err)
+	}
+	defer conn.Close()
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, handoffFd.AltName+"="+strconv.Itoa(3+len(cmd.ExtraFiles)))
+	cmd.ExtraFiles = append(cmd.ExtraFiles, remote)
+	if err := cmd.Start(); err != nil {
+		return newError("failed to start new process").Base(err)
+	}
+	remote.Close()
+
+	handoff.Lock()
+	sockets := make(map[string]handoffSocket, len(handoff.active))
+	for key, s := range handoff.active {
+		sockets[key] = s
+	}
+	handoff.Unlock()
+
+	err = sendSockets(conn, sockets)
+	if err == nil {
+		err = waitReady(conn, timeout)
+	}
+	if err != nil {
+		cmd.Process.Kill() // nolint: errcheck
+		cmd.Wait()         // nolint: errcheck
+		return err
+	}
+	go cmd.Wait() // nolint: errcheck
+
+	for _, s := range sockets {
+		switch s := s.(type) {
+		case *net.UnixListener:
+			// The socket file is now used by the new process.
+			s.SetUnlinkOnClose(false)
+		case *net.UDPConn:
+			// Packets read by a draining process are dropped.
+			s.Close()
+		}
+	}
+	return nil
+}
+
+func sendSockets(conn *net.UnixConn, sockets map[string]handoffSocket) error {
+	for key, s := range sockets {
+		f, err := s.File()
+		if err != nil {
+			// The socket is closed.
+			continue
+		}
+		_, _, err = conn.WriteMsgUnix([]byte(key), unix.UnixRights(int(f.Fd())), nil)
+		f.Close()
+		if err != nil {
+			return newError("failed to pass socket ", key).Base(err)
+		}
+		newError("passed socket ", key).AtDebug().WriteToLog()
+	}
+	if err := conn.CloseWrite(); err != nil {
+		return newError("failed to finish passing sockets").Base(err)
+	}
+	return nil
+}
+
+func waitReady(conn *net.UnixConn, timeout time.Duration) error {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	b := make([]byte, len(handoffReady))
+	n, err := conn.Read(b)
+	if err != nil && err != io.EOF {
+		return newError("failed to wait for new process").Base(err)
+	}
+	if string(b[:n]) != handoffReady {
+		return newError("new process exited before it is started")
+	}
+	return nil
+}
+
+// AdoptListeners receives the listening sockets passed from the previous process, if this process is started by HandoffListeners.
+// Listening on the same tag and address adopts the received sockets instead of creating new ones.
+//
+// v2ray:api:beta
+func AdoptListeners() error {
+	v := handoffFd.GetValue(func() string { return "" })
+	if v == "" {
+		return nil
+	}
+	os.Unsetenv(handoffFd.Name)    // nolint: errcheck
+	os.Unsetenv(handoffFd.AltName) // nolint: errcheck
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return newError("invalid handoff fd: ", v).Base(err)
+	}
+	unix.CloseOnExec(fd)
+	conn, err := newHandoffConn(fd)
+	if err != nil {
+		return newError("failed to open handoff conn").Base(err)
+	}
+
+	key := make([]byte, 4096)
+	oob := make([]byte, unix.CmsgSpace(4))
+	received := 0
+	for {
+		n, oobn, flags, _, err := conn.ReadMsgUnix(key, oob)
+		if err == io.EOF || (err == nil && n == 0 && oobn == 0) {
+			break
+		}
+		if err != nil {
+			conn.Close()
+			return newError("failed to receive sockets").Base(err)
+		}
+		if flags&(unix.MSG_TRUNC|unix.MSG_CTRUNC) != 0 {
+			conn.Close()
+			return newError("truncated handoff message")
+		}
+		msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+		if err != nil || len(msgs) != 1 {
+			conn.Close()
+			return newError("invalid handoff message").Base(err)
+		}
+		fds, err := unix.ParseUnixRights(&msgs[0])
+		if err != nil || len(fds) != 1 {
+			conn.Close()
+			return newError("invalid handoff message").Base(err)
+		}
+		unix.CloseOnExec(fds[0])
+
+		handoff.Lock()
+		handoff.inherited[string(key[:n])] = os.NewFile(uintptr(fds[0]), string(key[:n]))
+		handoff.Unlock()
+		received++
+	}
+
+	handoffParent = conn
+	newError("received ", received, " sockets from previous process").AtInfo().WriteToLog()
+	return nil
+}
+
+// ConfirmListeners tells the previous process that this process is started, and closes the received sockets that are not adopted.
+//
+// v2ray:api:beta
+func ConfirmListeners() error {
+	handoff.closeInherited()
+	if handoffParent == nil {
+		return nil
+	}
+	defer func() {
+		handoffParent.Close()
+		handoffParent = nil
+	}()
+
+	if _, err := handoffParent.Write([]byte(handoffReady)); err != nil {
+		return newError("failed to notify previous process").Base(err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// +build linux
+
+package internet_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/transport/internet"
+)
+
+const handoffHelperEnv = "V2RAY_TEST_HANDOFF_HELPER"
+
+// TestHandoffHelper runs in the process started by TestHandoffListeners.
+func TestHandoffHelper(t *testing.T) {
+	tcpAddr := os.Getenv(handoffHelperEnv)
+	if tcpAddr == "" {
+		t.Skip("not started by TestHandoffListeners")
+	}
+
+	common.Must(internet.AdoptListeners())
+
+	created := false
+	common.Must(internet.RegisterListenerController(func(network, address string, fd uintptr) error {
+		created = true
+		return nil
+	}))
+
+	ctx := internet.ContextWithListenerTag(context.Background(), "in")
+	addr, err := net.ResolveTCPAddr("tcp", tcpAddr)
+	common.Must(err)
+	l, err := internet.ListenSystem(ctx, addr, nil)
+	common.Must(err)
+	common.Must(internet.ConfirmListeners())
+
+	conn, err := l.Accept()
+	common.Must(err)
+	if created {
+		common.Must2(conn.Write([]byte("created")))
+	} else {
+		common.Must2(conn.Write([]byte("adopted")))
+	}
+	common.Must(conn.Close())
+	common.Must(l.Close())
+}
+
+func TestHandoffListeners(t *testing.T) {
+	ctx := internet.ContextWithListenerTag(context.Background(), "in")
+	l, err := internet.ListenSystem(ctx, &net.TCPAddr{IP: net.IP{127, 0, 0, 1}, Port: int(tcp.PickPort())}, nil)
+	common.Must(err)
+	defer l.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHandoffHelper$")
+	cmd.Env = append(os.Environ(), handoffHelperEnv+"="+l.Addr().String())
+	cmd.Stderr = os.Stderr
+	common.Must(internet.HandoffListeners(cmd, 10*time.Second))
+
+	// Connections on the passed socket are accepted by the new process only.
+	common.Must(l.Close())
+	conn, err := net.Dial("tcp", l.Addr().String())
+	common.Must(err)
+	defer conn.Close()
+
+	common.Must(conn.SetReadDeadline(time.Now().Add(10 * time.Second)))
+	b := make([]byte, 16)
+	n, err := conn.Read(b)
+	common.Must(err)
+	if string(b[:n]) != "adopted" {
+		t.Error("expect listener adopted by the new process, but got ", string(b[:n]))
+	}
+}
+
+func TestHandoffListenersFailure(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$", "-test.bad-flag")
+	if err := internet.HandoffListeners(cmd, 10*time.Second); err == nil {
+		t.Error("expect error when the new process exits before it is started")
+	}
+}
@@ -0,0 +1,23 @@
+// +build !linux
+
+package internet
+
+import (
+	"os/exec"
+	"time"
+)
+
+// HandoffListeners is only supported on Linux.
+func HandoffListeners(cmd *exec.Cmd, timeout time.Duration) error {
+	return newError("listener handoff is not supported on this platform")
+}
+
+// AdoptListeners does nothing on this platform.
+func AdoptListeners() error {
+	return nil
+}
+
+// ConfirmListeners does nothing on this platform.
+func ConfirmListeners() error {
+	return nil
+}
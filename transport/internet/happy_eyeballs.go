@@ -0,0 +1,241 @@
+package internet
+
+import (
+	"context"
+	"time"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/features/dns"
+)
+
+const (
+	happyEyeballsTryDelay        = 250 * time.Millisecond
+	happyEyeballsResolutionDelay = 50 * time.Millisecond
+)
+
+// LookupFunc resolves the IP addresses of a domain.
+type LookupFunc func(domain string) ([]net.IP, error)
+
+// LookupFuncs returns the functions for resolving IPv4 and IPv6 addresses with the DNS client.
+//
+// v2ray:api:beta
+func LookupFuncs(client dns.Client) (lookupIPv4 LookupFunc, lookupIPv6 LookupFunc) {
+	lookupIPv4 = func(domain string) ([]net.IP, error) {
+		return lookupFamily(client, domain, false)
+	}
+	if l, ok := client.(dns.IPv4Lookup); ok {
+		lookupIPv4 = l.LookupIPv4
+	}
+	lookupIPv6 = func(domain string) ([]net.IP, error) {
+		return lookupFamily(client, domain, true)
+	}
+	if l, ok := client.(dns.IPv6Lookup); ok {
+		lookupIPv6 = l.LookupIPv6
+	}
+	return
+}
+
+func lookupFamily(client dns.Client, domain string, ipv6 bool) ([]net.IP, error) {
+	ips, err := client.LookupIP(domain)
+	if err != nil {
+		return nil, err
+	}
+	var result []net.IP
+	for _, ip := range ips {
+		if (ip.To4() == nil) == ipv6 {
+			result = append(result, ip)
+		}
+	}
+	return result, nil
+}
+
+type dnsClientKey struct{}
+
+// ContextWithDNSClient returns a context in which domain destinations are resolved by the DNS client for Happy Eyeballs.
+//
+// v2ray:api:beta
+func ContextWithDNSClient(ctx context.Context, client dns.Client) context.Context {
+	return context.WithValue(ctx, dnsClientKey{}, client)
+}
+
+func dnsClientFromContext(ctx context.Context) dns.Client {
+	client, _ := ctx.Value(dnsClientKey{}).(dns.Client)
+	return client
+}
+
+// addressQueue orders addresses of both families for connection attempts.
+type addressQueue struct {
+	preferred     []net.IP
+	other         []net.IP
+	preferIPv6    bool
+	interleave    uint32
+	lastPreferred bool
+}
+
+func (q *addressQueue) add(ipv6 bool, ips []net.IP) {
+	if ipv6 == q.preferIPv6 {
+		q.preferred = append(q.preferred, ips...)
+	} else {
+		q.other = append(q.other, ips...)
+	}
+}
+
+func (q *addressQueue) empty() bool {
+	return len(q.preferred) == 0 && len(q.other) == 0
+}
+
+// next returns the address to try next, or nil if there is none.
+func (q *addressQueue) next() net.IP {
+	if q.empty() {
+		return nil
+	}
+	usePreferred := q.interleave > 0 || !q.lastPreferred
+	if usePreferred && len(q.preferred) == 0 {
+		usePreferred = false
+	} else if !usePreferred && len(q.other) == 0 {
+		usePreferred = true
+	}
+
+	var ip net.IP
+	if usePreferred {
+		ip, q.preferred = q.preferred[0], q.preferred[1:]
+		if q.interleave > 0 {
+			q.interleave--
+		}
+	} else {
+		ip, q.other = q.other[0], q.other[1:]
+	}
+	q.lastPreferred = usePreferred
+	return ip
+}
+
+type lookupResult struct {
+	ipv6 bool
+	ips  []net.IP
+	err  error
+}
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// DialHappyEyeballs resolves the domain of dest, and races staggered connection attempts to the resolved addresses, as in RFC 8305.
+// A nil lookup function disables the address family. The first established connection is returned, and the others are closed.
+//
+// v2ray:api:beta
+func DialHappyEyeballs(ctx context.Context, dest net.Destination, config *HappyEyeballsConfig, lookupIPv4, lookupIPv6 LookupFunc, dial func(context.Context, net.Destination) (net.Conn, error)) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	domain := dest.Address.Domain()
+	lookups := make(chan lookupResult, 2)
+	pendingLookups := 0
+	for _, l := range []struct {
+		ipv6   bool
+		lookup LookupFunc
+	}{{false, lookupIPv4}, {true, lookupIPv6}} {
+		if l.lookup == nil {
+			continue
+		}
+		pendingLookups++
+		go func(ipv6 bool, lookup LookupFunc) {
+			ips, err := lookup(domain)
+			lookups <- lookupResult{ipv6: ipv6, ips: ips, err: err}
+		}(l.ipv6, l.lookup)
+	}
+	if pendingLookups == 0 {
+		return nil, newError("no address family to dial ", dest)
+	}
+
+	tryDelay := happyEyeballsTryDelay
+	if config.TryDelayMs > 0 {
+		tryDelay = time.Duration(config.TryDelayMs) * time.Millisecond
+	}
+	queue := &addressQueue{
+		preferIPv6: !config.PreferIpv4,
+		interleave: config.Interleave,
+	}
+	if queue.interleave == 0 {
+		queue.interleave = 1
+	}
+
+	results := make(chan dialResult)
+	attempts := 0
+	started := false
+	var timer <-chan time.Time
+	var lastErr error
+
+	startNext := func() {
+		ip := queue.next()
+		if ip == nil {
+			timer = nil
+			return
+		}
+		started = true
+		attempts++
+		ipDest := net.Destination{
+			Network: dest.Network,
+			Address: net.IPAddress(ip),
+			Port:    dest.Port,
+		}
+		newError("dialing to ", ipDest, " for ", dest).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		go func() {
+			conn, err := dial(ctx, ipDest)
+			results <- dialResult{conn: conn, err: err}
+		}()
+		timer = time.After(tryDelay)
+	}
+
+	for {
+		select {
+		case r := <-lookups:
+			pendingLookups--
+			if r.err != nil {
+				lastErr = r.err
+				newError("failed to lookup ", domain).Base(r.err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+			}
+			queue.add(r.ipv6, r.ips)
+			switch {
+			case started && (attempts == 0 || timer == nil):
+				// All attempts so far have failed, or the delay for the next attempt has passed.
+				startNext()
+			case !started && (r.ipv6 == queue.preferIPv6 || pendingLookups == 0):
+				startNext()
+			case !started && timer == nil:
+				// Wait a while for addresses of the preferred family.
+				timer = time.After(happyEyeballsResolutionDelay)
+			}
+		case r := <-results:
+			attempts--
+			if r.err == nil {
+				go closeDialResults(results, attempts)
+				return r.conn, nil
+			}
+			lastErr = r.err
+			startNext()
+		case <-timer:
+			startNext()
+		case <-ctx.Done():
+			go closeDialResults(results, attempts)
+			return nil, ctx.Err()
+		}
+
+		if attempts == 0 && pendingLookups == 0 && queue.empty() {
+			if lastErr == nil {
+				lastErr = newError("no address resolved")
+			}
+			return nil, newError("failed to dial ", dest).Base(lastErr)
+		}
+	}
+}
+
+// closeDialResults closes the connections established after the race is finished.
+func closeDialResults(results <-chan dialResult, n int) {
+	for ; n > 0; n-- {
+		if r := <-results; r.err == nil {
+			r.conn.Close()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package internet_test
+
+import (
+	"context"
+	"errors"
+	gonet "net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	. "v2ray.com/core/transport/internet"
+)
+
+func staticLookup(ips ...string) LookupFunc {
+	return func(domain string) ([]net.IP, error) {
+		var result []net.IP
+		for _, ip := range ips {
+			result = append(result, net.ParseIP(ip))
+		}
+		return result, nil
+	}
+}
+
+type fakeDialer struct {
+	sync.Mutex
+	attempts []string
+	dial     func(ctx context.Context, dest net.Destination) (net.Conn, error)
+}
+
+func (d *fakeDialer) Dial(ctx context.Context, dest net.Destination) (net.Conn, error) {
+	d.Lock()
+	d.attempts = append(d.attempts, dest.Address.IP().String())
+	d.Unlock()
+	return d.dial(ctx, dest)
+}
+
+func TestHappyEyeballsOrder(t *testing.T) {
+	dest := net.TCPDestination(net.DomainAddress("v2fly.org"), 443)
+	lookupIPv4 := staticLookup("10.0.0.1", "10.0.0.2")
+	lookupIPv6 := staticLookup("fd00::1", "fd00::2")
+
+	cases := []struct {
+		config   *HappyEyeballsConfig
+		attempts []string
+	}{
+		{
+			config:   &HappyEyeballsConfig{},
+			attempts: []string{"fd00::1", "10.0.0.1", "fd00::2", "10.0.0.2"},
+		},
+		{
+			config:   &HappyEyeballsConfig{PreferIpv4: true},
+			attempts: []string{"10.0.0.1", "fd00::1", "10.0.0.2", "fd00::2"},
+		},
+		{
+			config:   &HappyEyeballsConfig{Interleave: 2},
+			attempts: []string{"fd00::1", "fd00::2", "10.0.0.1", "10.0.0.2"},
+		},
+	}
+
+	for _, c := range cases {
+		d := &fakeDialer{
+			dial: func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+				// Fail after both lookups are done.
+				time.Sleep(10 * time.Millisecond)
+				return nil, errors.New("fake error")
+			},
+		}
+		if _, err := DialHappyEyeballs(context.Background(), dest, c.config, lookupIPv4, lookupIPv6, d.Dial); err == nil {
+			t.Error("expect error when all attempts fail")
+		}
+		if r := cmp.Diff(d.attempts, c.attempts); r != "" {
+			t.Error(r)
+		}
+	}
+}
+
+func TestHappyEyeballsFallback(t *testing.T) {
+	dest := net.TCPDestination(net.DomainAddress("v2fly.org"), 443)
+	d := &fakeDialer{
+		dial: func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+			if dest.Address.Family().IsIPv6() {
+				// Broken IPv6 path.
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			conn, _ := gonet.Pipe()
+			return conn, nil
+		},
+	}
+
+	start := time.Now()
+	conn, err := DialHappyEyeballs(context.Background(), dest, &HappyEyeballsConfig{TryDelayMs: 100}, staticLookup("10.0.0.1"), staticLookup("fd00::1"), d.Dial)
+	common.Must(err)
+	defer conn.Close()
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > 5*time.Second {
+		t.Error("unexpected time to fall back to IPv4: ", elapsed)
+	}
+	if r := cmp.Diff(d.attempts, []string{"fd00::1", "10.0.0.1"}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestHappyEyeballsNoAddress(t *testing.T) {
+	dest := net.TCPDestination(net.DomainAddress("v2fly.org"), 443)
+	d := &fakeDialer{
+		dial: func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+			t.Error("unexpected dial to ", dest)
+			return nil, errors.New("fake error")
+		},
+	}
+	if _, err := DialHappyEyeballs(context.Background(), dest, &HappyEyeballsConfig{}, staticLookup(), nil, d.Dial); err == nil {
+		t.Error("expect error when no address is resolved")
+	}
+}
@@ -66,7 +66,7 @@ func applyOutboundSocketOptions(network string, address string, fd uintptr, conf
 		}
 	}
 
-	return nil
+	return applyExtendedSocketOptions(network, fd, config)
 }
 
 func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig) error {
@@ -102,6 +102,71 @@ func applyInboundSocketOptions(network string, fd uintptr, config *SocketConfig)
 		}
 	}
 
+	return applyExtendedSocketOptions(network, fd, config)
+}
+
+// applyExtendedSocketOptions applies the options that are the same for incoming and out-going connections.
+// Options set on a listening socket are inherited by the accepted connections.
+func applyExtendedSocketOptions(network string, fd uintptr, config *SocketConfig) error {
+	if len(config.Interface) > 0 {
+		if err := unix.BindToDevice(int(fd), config.Interface); err != nil {
+			return newError("failed to set SO_BINDTODEVICE=", config.Interface).Base(err)
+		}
+	}
+
+	if config.FreeBind {
+		err1 := syscall.SetsockoptInt(int(fd), syscall.SOL_IP, unix.IP_FREEBIND, 1)
+		err2 := syscall.SetsockoptInt(int(fd), syscall.SOL_IPV6, unix.IPV6_FREEBIND, 1)
+		if err1 != nil && err2 != nil {
+			return newError("failed to set IP_FREEBIND").Base(err1)
+		}
+	}
+
+	if config.Tos != 0 {
+		err1 := syscall.SetsockoptInt(int(fd), syscall.SOL_IP, syscall.IP_TOS, int(config.Tos))
+		err2 := syscall.SetsockoptInt(int(fd), syscall.SOL_IPV6, syscall.IPV6_TCLASS, int(config.Tos))
+		if err1 != nil && err2 != nil {
+			return newError("failed to set IP_TOS=", config.Tos).Base(err1)
+		}
+	}
+
+	if !isTCPSocket(network) {
+		return nil
+	}
+
+	if config.HasTCPKeepAlive() {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1); err != nil {
+			return newError("failed to set SO_KEEPALIVE").Base(err)
+		}
+		if config.TcpKeepAliveIdle > 0 {
+			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE, int(config.TcpKeepAliveIdle)); err != nil {
+				return newError("failed to set TCP_KEEPIDLE").Base(err)
+			}
+		}
+		if config.TcpKeepAliveInterval > 0 {
+			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, int(config.TcpKeepAliveInterval)); err != nil {
+				return newError("failed to set TCP_KEEPINTVL").Base(err)
+			}
+		}
+		if config.TcpKeepAliveCount > 0 {
+			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT, int(config.TcpKeepAliveCount)); err != nil {
+				return newError("failed to set TCP_KEEPCNT").Base(err)
+			}
+		}
+	}
+
+	if config.TcpUserTimeout > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(config.TcpUserTimeout)); err != nil {
+			return newError("failed to set TCP_USER_TIMEOUT").Base(err)
+		}
+	}
+
+	if len(config.TcpCongestion) > 0 {
+		if err := syscall.SetsockoptString(int(fd), syscall.IPPROTO_TCP, unix.TCP_CONGESTION, config.TcpCongestion); err != nil {
+			return newError("failed to set TCP_CONGESTION=", config.TcpCongestion).Base(err)
+		}
+	}
+
 	return nil
 }
 
@@ -2,11 +2,15 @@ package internet_test
 
 import (
 	"context"
+	"strings"
 	"syscall"
 	"testing"
 
+	"golang.org/x/sys/unix"
+
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
 	"v2ray.com/core/testing/servers/tcp"
 	. "v2ray.com/core/transport/internet"
 )
@@ -40,3 +44,122 @@ func TestSockOptMark(t *testing.T) {
 	})
 	common.Must(err)
 }
+
+func TestSockOptExtended(t *testing.T) {
+	sockopt := &SocketConfig{
+		TcpKeepAliveIdle:     30,
+		TcpKeepAliveInterval: 10,
+		TcpKeepAliveCount:    3,
+		TcpUserTimeout:       5000,
+		TcpCongestion:        "reno",
+		Interface:            "lo",
+		Tos:                  0x28 << 2,
+		TcpNoDelayDisabled:   true,
+	}
+
+	listener, err := ListenSystem(context.Background(), &net.TCPAddr{
+		IP:   []byte{127, 0, 0, 1},
+		Port: int(tcp.PickPort()),
+	}, sockopt)
+	common.Must(err)
+	defer listener.Close()
+
+	dest, err := net.ParseDestination("tcp:" + listener.Addr().String())
+	common.Must(err)
+	dialer := DefaultSystemDialer{}
+	conn, err := dialer.Dial(context.Background(), nil, dest, sockopt)
+	common.Must(err)
+	defer conn.Close()
+
+	accepted, err := listener.Accept()
+	common.Must(err)
+	defer accepted.Close()
+
+	for _, c := range []net.Conn{conn, accepted} {
+		rawConn, err := c.(*net.TCPConn).SyscallConn()
+		common.Must(err)
+		common.Must(rawConn.Control(func(fd uintptr) {
+			for _, opt := range []struct {
+				level int
+				name  int
+				value int
+			}{
+				{syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1},
+				{syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE, 30},
+				{syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, 10},
+				{syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT, 3},
+				{syscall.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, 5000},
+				{syscall.IPPROTO_TCP, syscall.TCP_NODELAY, 0},
+				{syscall.SOL_IP, syscall.IP_TOS, 0x28 << 2},
+			} {
+				v, err := syscall.GetsockoptInt(int(fd), opt.level, opt.name)
+				common.Must(err)
+				if v != opt.value {
+					t.Error("unexpected value of socket option ", opt.name, ": ", v, " want ", opt.value)
+				}
+			}
+			congestion, err := unix.GetsockoptString(int(fd), syscall.IPPROTO_TCP, unix.TCP_CONGESTION)
+			common.Must(err)
+			if strings.TrimRight(congestion, "\x00") != "reno" {
+				t.Error("unexpected congestion control ", congestion)
+			}
+			device, err := unix.GetsockoptString(int(fd), syscall.SOL_SOCKET, unix.SO_BINDTODEVICE)
+			common.Must(err)
+			if strings.TrimRight(device, "\x00") != "lo" {
+				t.Error("unexpected device ", device)
+			}
+		}))
+	}
+}
+
+func TestSockOptFromSession(t *testing.T) {
+	listener, err := ListenSystem(context.Background(), &net.TCPAddr{
+		IP:   []byte{127, 0, 0, 1},
+		Port: int(tcp.PickPort()),
+	}, nil)
+	common.Must(err)
+	defer listener.Close()
+
+	dest, err := net.ParseDestination("tcp:" + listener.Addr().String())
+	common.Must(err)
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target:  dest,
+		Gateway: net.LocalHostIP,
+		Sockopt: &session.Sockopt{
+			Interface: "lo",
+			Source:    net.ParseAddress("127.0.0.2"),
+		},
+	})
+	conn, err := DialSystem(ctx, dest, nil)
+	common.Must(err)
+	defer conn.Close()
+
+	if ip := conn.LocalAddr().(*net.TCPAddr).IP.String(); ip != "127.0.0.2" {
+		t.Error("unexpected source address ", ip)
+	}
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	common.Must(err)
+	common.Must(rawConn.Control(func(fd uintptr) {
+		device, err := unix.GetsockoptString(int(fd), syscall.SOL_SOCKET, unix.SO_BINDTODEVICE)
+		common.Must(err)
+		if strings.TrimRight(device, "\x00") != "lo" {
+			t.Error("unexpected device ", device)
+		}
+	}))
+}
+
+func TestSockOptFreeBind(t *testing.T) {
+	// The address is not assigned to any interface.
+	addr := &net.TCPAddr{
+		IP:   []byte{192, 0, 2, 1},
+		Port: int(tcp.PickPort()),
+	}
+	if l, err := ListenSystem(context.Background(), addr, nil); err == nil {
+		l.Close()
+		t.Skip("address is assigned locally")
+	}
+
+	l, err := ListenSystem(context.Background(), addr, &SocketConfig{FreeBind: true})
+	common.Must(err)
+	common.Must(l.Close())
+}
@@ -44,6 +44,20 @@ func hasBindAddr(sockopt *SocketConfig) bool {
 }
 
 func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest net.Destination, sockopt *SocketConfig) (net.Conn, error) {
+	if dest.Network == net.Network_TCP && dest.Address.Family().IsDomain() && sockopt != nil && sockopt.HappyEyeballs != nil {
+		if client := dnsClientFromContext(ctx); client != nil {
+			lookupIPv4, lookupIPv6 := LookupFuncs(client)
+			if src != nil && src.Family().IsIPv4() {
+				lookupIPv6 = nil
+			} else if src != nil && src.Family().IsIPv6() {
+				lookupIPv4 = nil
+			}
+			return DialHappyEyeballs(ctx, dest, sockopt.HappyEyeballs, lookupIPv4, lookupIPv6, func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+				return d.Dial(ctx, src, dest, sockopt)
+			})
+		}
+	}
+
 	if dest.Network == net.Network_UDP && !hasBindAddr(sockopt) {
 		srcAddr := resolveSrcAddr(net.Network_UDP, src)
 		if srcAddr == nil {
@@ -95,7 +109,24 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 		}
 	}
 
-	return dialer.DialContext(ctx, dest.Network.SystemString(), dest.NetAddr())
+	if sockopt != nil && sockopt.HasTCPKeepAlive() {
+		// Keepalive options are set in Control, and should not be overwritten.
+		dialer.KeepAlive = -1
+	}
+
+	conn, err := dialer.DialContext(ctx, dest.Network.SystemString(), dest.NetAddr())
+	if err != nil {
+		return nil, err
+	}
+	if sockopt != nil && sockopt.TcpNoDelayDisabled {
+		// TCP_NODELAY is always enabled by Go after the connection is established.
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			if err := tcpConn.SetNoDelay(false); err != nil {
+				newError("failed to disable TCP_NODELAY").Base(err).WriteToLog(session.ExportIDToError(ctx))
+			}
+		}
+	}
+	return conn, nil
 }
 
 type packetConnWrapper struct {
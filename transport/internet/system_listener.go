@@ -38,20 +38,73 @@ func getControlFunc(ctx context.Context, sockopt *SocketConfig, controllers []co
 	}
 }
 
-func (dl *DefaultListener) Listen(ctx context.Context, addr net.Addr, sockopt *SocketConfig) (net.Listener, error) {
-	var lc net.ListenConfig
+// applyControllers applies socket options and controllers to a socket that is not created by this listener.
+func (dl *DefaultListener) applyControllers(ctx context.Context, addr net.Addr, socket interface{}, sockopt *SocketConfig) {
+	sc, ok := socket.(syscall.Conn)
+	if !ok {
+		return
+	}
+	rawConn, err := sc.SyscallConn()
+	if err != nil {
+		newError("failed to get raw conn").Base(err).WriteToLog(session.ExportIDToError(ctx))
+		return
+	}
+	getControlFunc(ctx, sockopt, dl.controllers)(addr.Network(), addr.String(), rawConn) // nolint: errcheck
+}
 
-	lc.Control = getControlFunc(ctx, sockopt, dl.controllers)
+func (dl *DefaultListener) Listen(ctx context.Context, addr net.Addr, sockopt *SocketConfig) (net.Listener, error) {
+	l, adopted, err := handoff.adoptListener(ctx, addr)
+	if !adopted && sockopt != nil && len(sockopt.SystemdSocket) > 0 {
+		l, err = listenSystemd(sockopt.SystemdSocket)
+		if err == nil {
+			dl.applyControllers(ctx, addr, l, sockopt)
+		}
+	} else if !adopted {
+		var lc net.ListenConfig
+
+		lc.Control = getControlFunc(ctx, sockopt, dl.controllers)
+		if sockopt != nil && sockopt.HasTCPKeepAlive() {
+			// Keep the keepalive options of the listening socket in accepted connections.
+			lc.KeepAlive = -1
+		}
+
+		l, err = lc.Listen(ctx, addr.Network(), addr.String())
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return lc.Listen(ctx, addr.Network(), addr.String())
+	if key, ok := handoffKey(ctx, addr); ok {
+		handoff.track(key, l)
+	}
+	if sockopt != nil && sockopt.TcpNoDelayDisabled {
+		l = &noDelayDisabledListener{Listener: l}
+	}
+	return l, nil
 }
 
 func (dl *DefaultListener) ListenPacket(ctx context.Context, addr net.Addr, sockopt *SocketConfig) (net.PacketConn, error) {
-	var lc net.ListenConfig
-
-	lc.Control = getControlFunc(ctx, sockopt, dl.controllers)
+	conn, adopted, err := handoff.adoptPacketConn(ctx, addr)
+	if !adopted && sockopt != nil && len(sockopt.SystemdSocket) > 0 {
+		conn, err = listenPacketSystemd(sockopt.SystemdSocket)
+		if err == nil {
+			dl.applyControllers(ctx, addr, conn, sockopt)
+		}
+	} else if !adopted {
+		var lc net.ListenConfig
+
+		lc.Control = getControlFunc(ctx, sockopt, dl.controllers)
+
+		conn, err = lc.ListenPacket(ctx, addr.Network(), addr.String())
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return lc.ListenPacket(ctx, addr.Network(), addr.String())
+	if key, ok := handoffKey(ctx, addr); ok {
+		handoff.track(key, conn)
+	}
+	return conn, nil
 }
 
 // RegisterListenerController adds a controller to the effective system listener.
@@ -66,3 +119,21 @@ func RegisterListenerController(controller func(network, address string, fd uint
 	effectiveListener.controllers = append(effectiveListener.controllers, controller)
 	return nil
 }
+
+// noDelayDisabledListener disables TCP_NODELAY on accepted connections, as it is always enabled by Go.
+type noDelayDisabledListener struct {
+	net.Listener
+}
+
+func (l *noDelayDisabledListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		if err := tcpConn.SetNoDelay(false); err != nil {
+			newError("failed to disable TCP_NODELAY").Base(err).WriteToLog()
+		}
+	}
+	return conn, nil
+}
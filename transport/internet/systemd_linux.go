@@ -0,0 +1,109 @@
+// +build linux
+
+package internet
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+
+	"v2ray.com/core/common/net"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd.
+const listenFdsStart = 3
+
+type systemdSocket struct {
+	name   string
+	file   *os.File
+	sotype int
+}
+
+var systemdSockets struct {
+	once    sync.Once
+	sockets []*systemdSocket
+}
+
+// loadSystemdSockets reads the sockets passed by systemd socket activation, as in sd_listen_fds(3).
+func loadSystemdSockets() []*systemdSocket {
+	systemdSockets.once.Do(func() {
+		pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+		if err != nil || pid != os.Getpid() {
+			return
+		}
+		n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil || n <= 0 {
+			return
+		}
+		names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+		os.Unsetenv("LISTEN_PID")     // nolint: errcheck
+		os.Unsetenv("LISTEN_FDS")     // nolint: errcheck
+		os.Unsetenv("LISTEN_FDNAMES") // nolint: errcheck
+
+		for i := 0; i < n; i++ {
+			fd := listenFdsStart + i
+			unix.CloseOnExec(fd)
+			name := "unknown"
+			if i < len(names) && names[i] != "" {
+				name = names[i]
+			}
+			sotype, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_TYPE)
+			if err != nil {
+				newError("file descriptor ", fd, " passed by systemd is not a socket").Base(err).AtWarning().WriteToLog()
+				sotype = -1
+			}
+			systemdSockets.sockets = append(systemdSockets.sockets, &systemdSocket{
+				name:   name,
+				file:   os.NewFile(uintptr(fd), name),
+				sotype: sotype,
+			})
+		}
+	})
+	return systemdSockets.sockets
+}
+
+// findSystemdSocket returns the socket of the given type, matched by name, or by index if no name matches.
+func findSystemdSocket(nameOrIndex string, sotype int) (*os.File, error) {
+	sockets := loadSystemdSockets()
+	for _, s := range sockets {
+		if s.name == nameOrIndex && s.sotype == sotype {
+			return s.file, nil
+		}
+	}
+	if i, err := strconv.Atoi(nameOrIndex); err == nil && i >= 0 && i < len(sockets) {
+		if sockets[i].sotype != sotype {
+			return nil, newError("systemd socket ", i, " is not of the expected type")
+		}
+		return sockets[i].file, nil
+	}
+	return nil, newError("systemd socket not found: ", nameOrIndex)
+}
+
+func listenSystemd(nameOrIndex string) (net.Listener, error) {
+	f, err := findSystemdSocket(nameOrIndex, unix.SOCK_STREAM)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, newError("failed to listen on systemd socket ", nameOrIndex).Base(err)
+	}
+	newError("listening on systemd socket ", nameOrIndex, " at ", l.Addr()).AtInfo().WriteToLog()
+	return l, nil
+}
+
+func listenPacketSystemd(nameOrIndex string) (net.PacketConn, error) {
+	f, err := findSystemdSocket(nameOrIndex, unix.SOCK_DGRAM)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, newError("failed to listen on systemd socket ", nameOrIndex).Base(err)
+	}
+	newError("listening on systemd socket ", nameOrIndex, " at ", conn.LocalAddr()).AtInfo().WriteToLog()
+	return conn, nil
+}
@@ -0,0 +1,97 @@
+// +build linux
+
+package internet_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/transport/internet"
+)
+
+const systemdHelperEnv = "V2RAY_TEST_SYSTEMD_HELPER"
+
+// TestSystemdHelper runs in the process started by TestSystemdSocket.
+func TestSystemdHelper(t *testing.T) {
+	if os.Getenv(systemdHelperEnv) == "" {
+		t.Skip("not started by TestSystemdSocket")
+	}
+
+	// The address is ignored for systemd sockets.
+	addr := &net.TCPAddr{IP: net.IP{127, 0, 0, 1}, Port: 1}
+	l, err := internet.ListenSystem(context.Background(), addr, &internet.SocketConfig{SystemdSocket: "web"})
+	common.Must(err)
+	conn, err := internet.ListenSystemPacket(context.Background(), &net.UDPAddr{IP: addr.IP, Port: 1}, &internet.SocketConfig{SystemdSocket: "1"})
+	common.Must(err)
+	if _, err := internet.ListenSystem(context.Background(), addr, &internet.SocketConfig{SystemdSocket: "dns"}); err == nil {
+		t.Error("expect error when listening TCP on a UDP socket")
+	}
+
+	c, err := l.Accept()
+	common.Must(err)
+	common.Must2(c.Write([]byte("web")))
+	common.Must(c.Close())
+
+	b := make([]byte, 16)
+	n, from, err := conn.ReadFrom(b)
+	common.Must(err)
+	common.Must2(conn.WriteTo(b[:n], from))
+}
+
+func TestSystemdSocket(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})
+	common.Must(err)
+	defer l.Close()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	common.Must(err)
+	defer conn.Close()
+
+	lf, err := l.File()
+	common.Must(err)
+	cf, err := conn.File()
+	common.Must(err)
+
+	// LISTEN_PID is the pid of the shell, which is kept by exec.
+	cmd := exec.Command("/bin/sh", "-c", `LISTEN_PID=$$ exec "$0" "$@"`, os.Args[0], "-test.run=^TestSystemdHelper$")
+	cmd.Env = append(os.Environ(), "LISTEN_FDS=2", "LISTEN_FDNAMES=web:dns", systemdHelperEnv+"=1")
+	cmd.ExtraFiles = []*os.File{lf, cf}
+	cmd.Stderr = os.Stderr
+	common.Must(cmd.Start())
+	lf.Close()
+	cf.Close()
+
+	// Only the new process accepts connections and reads packets from now on.
+	common.Must(l.Close())
+	common.Must(conn.Close())
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	common.Must(err)
+	common.Must(c.SetReadDeadline(time.Now().Add(10 * time.Second)))
+	b := make([]byte, 16)
+	n, err := c.Read(b)
+	common.Must(err)
+	if string(b[:n]) != "web" {
+		t.Error("unexpected response on TCP: ", string(b[:n]))
+	}
+	common.Must(c.Close())
+
+	u, err := net.Dial("udp", conn.LocalAddr().String())
+	common.Must(err)
+	common.Must2(u.Write([]byte("dns")))
+	common.Must(u.SetReadDeadline(time.Now().Add(10 * time.Second)))
+	n, err = u.Read(b)
+	common.Must(err)
+	if string(b[:n]) != "dns" {
+		t.Error("unexpected response on UDP: ", string(b[:n]))
+	}
+	common.Must(u.Close())
+
+	if err := cmd.Wait(); err != nil {
+		t.Error("helper failed: ", err)
+	}
+}
@@ -0,0 +1,15 @@
+// +build !linux
+
+package internet
+
+import (
+	"v2ray.com/core/common/net"
+)
+
+func listenSystemd(nameOrIndex string) (net.Listener, error) {
+	return nil, newError("systemd socket activation is not supported on this platform")
+}
+
+func listenPacketSystemd(nameOrIndex string) (net.PacketConn, error) {
+	return nil, newError("systemd socket activation is not supported on this platform")
+}
@@ -9,6 +9,7 @@ import (
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/bytespool"
 	"v2ray.com/core/common/signal"
 	"v2ray.com/core/common/signal/done"
 )
@@ -24,6 +25,7 @@ const (
 type pipeOption struct {
 	limit           int32 // maximum buffer size in bytes
 	discardOverflow bool
+	pauseOnPressure bool
 }
 
 func (o *pipeOption) isFull(curSize int32) bool {
@@ -135,6 +137,13 @@ func (p *pipe) WriteMultiBuffer(mb buf.MultiBuffer) error {
 		return nil
 	}
 
+	if p.option.pauseOnPressure {
+		if err := p.waitForMemory(); err != nil {
+			buf.ReleaseMulti(mb)
+			return err
+		}
+	}
+
 	for {
 		err := p.writeMultiBufferInternal(mb)
 		if err == nil {
@@ -169,6 +178,22 @@ func (p *pipe) WriteMultiBuffer(mb buf.MultiBuffer) error {
 	}
 }
 
+// waitForMemory blocks until the memory pressure drops below bytespool.PressurePause, or the pipe is done.
+func (p *pipe) waitForMemory() error {
+	for {
+		relief := bytespool.Relief()
+		if bytespool.CurrentPressure() < bytespool.PressurePause {
+			return nil
+		}
+
+		select {
+		case <-relief:
+		case <-p.done.Wait():
+			return io.ErrClosedPipe
+		}
+	}
+}
+
 func (p *pipe) Close() error {
 	p.Lock()
 	defer p.Unlock()
@@ -200,3 +225,14 @@ func (p *pipe) Interrupt() {
 
 	common.Must(p.done.Close())
 }
+
+// discard releases the data that is not read yet.
+func (p *pipe) discard() {
+	p.Lock()
+	defer p.Unlock()
+
+	if !p.data.IsEmpty() {
+		buf.ReleaseMulti(p.data)
+		p.data = nil
+	}
+}
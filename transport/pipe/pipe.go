@@ -3,6 +3,7 @@ package pipe
 import (
 	"context"
 
+	"v2ray.com/core/common/bytespool"
 	"v2ray.com/core/common/signal"
 	"v2ray.com/core/common/signal/done"
 	"v2ray.com/core/features/policy"
@@ -32,13 +33,28 @@ func DiscardOverflow() Option {
 	}
 }
 
+// PauseOnPressure returns an Option for Pipe to block writes while memory pressure is at bytespool.PressurePause or above.
+func PauseOnPressure() Option {
+	return func(opt *pipeOption) {
+		opt.pauseOnPressure = true
+	}
+}
+
+// pressureSizeLimit is the maximum buffer size of pipes created under memory pressure.
+const pressureSizeLimit = 32 * 1024
+
 // OptionsFromContext returns a list of Options from context.
+// Under memory pressure, the buffer size is reduced to pressureSizeLimit.
 func OptionsFromContext(ctx context.Context) []Option {
 	var opt []Option
 
 	bp := policy.BufferPolicyFromContext(ctx)
-	if bp.PerConnection >= 0 {
-		opt = append(opt, WithSizeLimit(bp.PerConnection))
+	limit := bp.PerConnection
+	if (limit < 0 || limit > pressureSizeLimit) && bytespool.CurrentPressure() >= bytespool.PressureReduce {
+		limit = pressureSizeLimit
+	}
+	if limit >= 0 {
+		opt = append(opt, WithSizeLimit(limit))
 	} else {
 		opt = append(opt, WithoutSizeLimit())
 	}
@@ -62,8 +78,8 @@ func New(opts ...Option) (*Reader, *Writer) {
 	}
 
 	return &Reader{
-			pipe: p,
-		}, &Writer{
-			pipe: p,
-		}
+		pipe: p,
+	}, &Writer{
+		pipe: p,
+	}
 }
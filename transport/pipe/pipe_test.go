@@ -1,6 +1,7 @@
 package pipe_test
 
 import (
+	"context"
 	"errors"
 	"io"
 	"testing"
@@ -11,6 +12,8 @@ import (
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/bytespool"
+	"v2ray.com/core/features/policy"
 	. "v2ray.com/core/transport/pipe"
 )
 
@@ -151,3 +154,141 @@ func BenchmarkPipeReadWrite(b *testing.B) {
 		c = d
 	}
 }
+
+func TestPipePauseOnPressure(t *testing.T) {
+	base, _ := bytespool.Usage()
+	defer bytespool.SetBudget(0, 0, 0, 0)
+	bytespool.SetBudget(base+2*buf.Size, 100, 100, 100)
+
+	// Buffers are charged to the budget until they are released.
+	pReader, pWriter := New(WithoutSizeLimit())
+	b := buf.New()
+	b.Extend(buf.Size)
+	b2 := buf.New()
+	b2.Extend(buf.Size)
+	common.Must(pWriter.WriteMultiBuffer(buf.MultiBuffer{b, b2}))
+	if p := bytespool.CurrentPressure(); p != bytespool.PressureReject {
+		t.Fatal("expect pressure ", bytespool.PressureReject, ", but got ", p)
+	}
+
+	_, pausedWriter := New(PauseOnPressure())
+	written := make(chan error, 1)
+	go func() {
+		b := buf.New()
+		common.Must2(b.WriteString("abcd"))
+		written <- pausedWriter.WriteMultiBuffer(buf.MultiBuffer{b})
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("expect write paused under memory pressure")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	rb, err := pReader.ReadMultiBuffer()
+	common.Must(err)
+	buf.ReleaseMulti(rb)
+
+	select {
+	case err := <-written:
+		common.Must(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect write resumed after memory pressure drops")
+	}
+	if usage, _ := bytespool.Usage(); usage != base+buf.Size {
+		t.Error("unexpected usage: ", usage-base)
+	}
+}
+
+func TestPipeInterruptAfterClose(t *testing.T) {
+	// Interrupting a closed pipe keeps the data for the reader.
+	pReader, pWriter := New(WithoutSizeLimit())
+	b := buf.New()
+	common.Must2(b.WriteString("abcd"))
+	common.Must(pWriter.WriteMultiBuffer(buf.MultiBuffer{b}))
+	common.Must(pWriter.Close())
+	pWriter.Interrupt()
+
+	rb, err := pReader.ReadMultiBuffer()
+	common.Must(err)
+	if rb.String() != "abcd" {
+		t.Error("unexpected content: ", rb.String())
+	}
+	buf.ReleaseMulti(rb)
+	if _, err := pReader.ReadMultiBuffer(); err != io.EOF {
+		t.Error("expect EOF, but got ", err)
+	}
+}
+
+func TestPipeDiscard(t *testing.T) {
+	defer bytespool.SetBudget(0, 0, 0, 0)
+	bytespool.SetBudget(1<<40, 0, 0, 0)
+	base, _ := bytespool.Usage()
+
+	pReader, pWriter := New(WithoutSizeLimit())
+	b := buf.New()
+	common.Must2(b.WriteString("abcd"))
+	common.Must(pWriter.WriteMultiBuffer(buf.MultiBuffer{b}))
+	common.Must(pWriter.Close())
+	pReader.Discard()
+
+	if usage, _ := bytespool.Usage(); usage != base {
+		t.Error("unexpected usage: ", usage-base)
+	}
+	if _, err := pReader.ReadMultiBuffer(); err != io.EOF {
+		t.Error("expect EOF, but got ", err)
+	}
+}
+
+// blocksOnSecondWrite returns true if the pipe is full after a write of the given size.
+func blocksOnSecondWrite(opts []Option, size int32) bool {
+	pReader, pWriter := New(opts...)
+	defer pReader.Interrupt()
+
+	b := buf.NewWithSize(size)
+	b.Extend(size)
+	common.Must(pWriter.WriteMultiBuffer(buf.MultiBuffer{b}))
+
+	written := make(chan struct{})
+	go func() {
+		b := buf.New()
+		common.Must2(b.WriteString("abcd"))
+		pWriter.WriteMultiBuffer(buf.MultiBuffer{b}) // nolint: errcheck
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		return false
+	case <-time.After(100 * time.Millisecond):
+		return true
+	}
+}
+
+func TestOptionsFromContextUnderPressure(t *testing.T) {
+	ctx := policy.ContextWithBufferPolicy(context.Background(), policy.Buffer{PerConnection: -1})
+	if blocksOnSecondWrite(OptionsFromContext(ctx), 64*1024) {
+		t.Error("expect unlimited pipe without memory pressure")
+	}
+
+	base, _ := bytespool.Usage()
+	defer bytespool.SetBudget(0, 0, 0, 0)
+	bytespool.SetBudget(base+1, 0, 100, 100)
+	if p := bytespool.CurrentPressure(); p != bytespool.PressureReduce {
+		t.Fatal("expect pressure ", bytespool.PressureReduce, ", but got ", p)
+	}
+
+	opts := OptionsFromContext(ctx)
+	if blocksOnSecondWrite(opts, 16*1024) {
+		t.Error("expect pipe not full under the reduced limit")
+	}
+	if !blocksOnSecondWrite(opts, 64*1024) {
+		t.Error("expect pipe limited under memory pressure")
+	}
+
+	// Smaller limits from the policy are kept.
+	ctx = policy.ContextWithBufferPolicy(context.Background(), policy.Buffer{PerConnection: 1024})
+	if !blocksOnSecondWrite(OptionsFromContext(ctx), 16*1024) {
+		t.Error("expect the limit from buffer policy")
+	}
+}
@@ -25,3 +25,8 @@ func (r *Reader) ReadMultiBufferTimeout(d time.Duration) (buf.MultiBuffer, error
 func (r *Reader) Interrupt() {
 	r.pipe.Interrupt()
 }
+
+// Discard releases the content in the pipe that is not read yet. The pipe is not closed by Discard.
+func (r *Reader) Discard() {
+	r.pipe.discard()
+}
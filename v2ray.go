@@ -9,6 +9,7 @@ import (
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/serial"
+	"v2ray.com/core/common/task"
 	"v2ray.com/core/features"
 	"v2ray.com/core/features/dns"
 	"v2ray.com/core/features/dns/localdns"
@@ -93,6 +94,11 @@ type Instance struct {
 	featureResolutions []resolution
 	running            bool
 
+	// apps are the app configs and the features created from them, for reloading.
+	apps         []*appFeature
+	reloadAccess sync.Mutex
+	configLoader func() (*Config, error)
+
 	ctx context.Context
 }
 
@@ -197,11 +203,14 @@ func initInstanceWithConfig(config *Config, server *Instance) (error, bool) {
 		if err != nil {
 			return err, true
 		}
+		app := &appFeature{config: settings}
 		if feature, ok := obj.(features.Feature); ok {
 			if err := server.AddFeature(feature); err != nil {
 				return err, true
 			}
+			app.feature = feature
 		}
+		server.apps = append(server.apps, app)
 	}
 
 	essentialFeatures := []struct {
@@ -261,6 +270,22 @@ func (s *Instance) Close() error {
 	return nil
 }
 
+// Drain implements common.Drainable. Inbounds stop accepting new connections, and ongoing sessions are allowed to finish until ctx is done.
+// The Instance still needs to be closed afterwards.
+func (s *Instance) Drain(ctx context.Context) error {
+	s.access.Lock()
+	tasks := make([]func() error, 0, len(s.features))
+	for _, f := range s.features {
+		f := f
+		tasks = append(tasks, func() error {
+			return common.Drain(ctx, f)
+		})
+	}
+	s.access.Unlock()
+
+	return task.Run(ctx, tasks...)
+}
+
 // RequireFeatures registers a callback, which will be called when all dependent features are registered.
 // The callback must be a func(). All its parameters must be features.Feature.
 func (s *Instance) RequireFeatures(callback interface{}) error {